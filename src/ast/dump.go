@@ -0,0 +1,120 @@
+package ast
+
+import (
+	"fmt"
+	"mikescript/src/token"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Tree of the nodes of the program, one node or field per line and
+// the children indented below their parent. Used by 'ms ast'.
+func Dump(program *Program) string {
+	var sb strings.Builder
+	dump(&sb, reflect.ValueOf(program), 0)
+	return sb.String()
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+func dump(sb *strings.Builder, v reflect.Value, indent int) {
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			sb.WriteString("nil\n")
+			return
+		}
+		if v.Type().Implements(stringerType) {
+			break
+		}
+		v = v.Elem()
+	}
+
+	// Types and other values printing themselves
+	if v.Type().Implements(stringerType) {
+		fmt.Fprintf(sb, "%v\n", v.Interface())
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:	dumpStruct(sb, v, indent)
+	case reflect.Slice:		dumpSlice(sb, v, indent)
+	case reflect.Map:		dumpMap(sb, v, indent)
+	default:				fmt.Fprintf(sb, "%v\n", v.Interface())
+	}
+}
+
+func dumpStruct(sb *strings.Builder, v reflect.Value, indent int) {
+
+	if v.Type() == tokenType {
+		sb.WriteString(tokenString(v.Interface().(token.Token)) + "\n")
+		return
+	}
+
+	// Nodes holding only a token fit on one line, like variables
+	if v.NumField() == 1 && v.Field(0).Type() == tokenType {
+		fmt.Fprintf(sb, "%s %s\n", v.Type().Name(), tokenString(v.Field(0).Interface().(token.Token)))
+		return
+	}
+
+	sb.WriteString(v.Type().Name() + "\n")
+
+	for i := 0 ; i < v.NumField() ; i++ {
+
+		field := v.Field(i)
+		if !v.Type().Field(i).IsExported() || empty(field) {
+			continue
+		}
+
+		// Lists start on the next line
+		sep := " "
+		if field.Kind() == reflect.Slice || field.Kind() == reflect.Map {
+			sep = ""
+		}
+
+		fmt.Fprintf(sb, "%s%s:%s", strings.Repeat("  ", indent + 1), v.Type().Field(i).Name, sep)
+		dump(sb, field, indent + 1)
+	}
+}
+
+func dumpSlice(sb *strings.Builder, v reflect.Value, indent int) {
+
+	sb.WriteString("\n")
+
+	for i := 0 ; i < v.Len() ; i++ {
+		fmt.Fprintf(sb, "%s- ", strings.Repeat("  ", indent + 1))
+		dump(sb, v.Index(i), indent + 2)
+	}
+}
+
+func dumpMap(sb *strings.Builder, v reflect.Value, indent int) {
+
+	// Keys are pointers, the entries are sorted by how they print
+	entries := []string{}
+
+	for _, key := range v.MapKeys() {
+
+		var k, val strings.Builder
+		dump(&k, key, indent + 2)
+		dump(&val, v.MapIndex(key), indent + 2)
+
+		entries = append(entries, fmt.Sprintf("%s- %s: %s", strings.Repeat("  ", indent + 1), strings.TrimSuffix(k.String(), "\n"), val.String()))
+	}
+
+	slices.Sort(entries)
+
+	sb.WriteString("\n" + strings.Join(entries, ""))
+}
+
+func empty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:	return v.Len() == 0
+	default:							return v.IsZero()
+	}
+}
+
+func tokenString(tk token.Token) string {
+	return fmt.Sprintf("%q (%d:%d)", tk.Lexeme, tk.Line, tk.Col)
+}
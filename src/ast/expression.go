@@ -21,18 +21,21 @@ type DeclAssignNodeS struct {
 type FuncAppNodeS struct {
 	Args 	[]ExpNodeI
 	Fun		ExpNodeI
+	Op		token.Token		// >>, >>=, *>>, *>>=
 }
 
 // exp, exp, ... .>> exp
 type IterableFuncAppNodeS struct {
 	Args 	ExpNodeI
 	Fun		ExpNodeI
+	Op		token.Token
 }
 
 // exp, exp, ... .>>= exp
 type IterableFuncAppAndCallNodeS struct {
 	Args 	ExpNodeI
 	Fun		ExpNodeI
+	Op		token.Token
 }
 
 // '=' exp
@@ -85,6 +88,7 @@ type GroupExpNodeS struct {
 type ArrayIndexNodeS struct {
 	Target ExpNodeI
 	Index ExpNodeI
+	Tk token.Token		// '['
 }
 
 type ArrayConstructorNodeS struct {
@@ -92,17 +96,20 @@ type ArrayConstructorNodeS struct {
 	Type mstype.MSType	
 	Vals []ExpNodeI
 	N ExpNodeI
+	Tk token.Token		// '['
 }
 
 type RangeConstructorNodeS struct {
 	From ExpNodeI // expects to be evaluate to int
 	To ExpNodeI // expects to be evaluate to int
+	Tk token.Token		// '['
 }
 
 type ArrayAssignmentNodeS struct {
 	Target ExpNodeI
 	Index ExpNodeI
 	Value ExpNodeI
+	Tk token.Token		// '[' of the index
 }
 
 type StructConstructorNodeS struct {
@@ -123,6 +130,62 @@ type FieldAssignmentNode struct {
 
 type StarredExpNodeS struct {
 	Node ExpNodeI
+	Tk token.Token		// '*', or the '*>>' it is sugar for
+}
+
+// 'match' {'->' type}? '{' { pattern '=>' exp ';' }* '}'
+type MatchNodeS struct {
+	Tk token.Token			// 'match' keyword
+	Arms []MatchArmS
+	Rt mstype.MSType		// result type, inferred by the type resolver when nil
+}
+
+// variant '(' IDENTIFIER {',' IDENTIFIER}* ')' '=>' exp
+type MatchArmS struct {
+	Variant *VariableExpNodeS		// variant name, '_' matches any variant
+	Bindings []*VariableExpNodeS	// names for the payload values
+	Body ExpNodeI
+}
+
+// 'map' '[' type ']' type '{' { exp ':' exp ',' }* '}'
+type MapConstructorNodeS struct {
+	Tk token.Token				// 'map' keyword
+	Type *mstype.MSMapTypeS
+	Keys []ExpNodeI
+	Vals []ExpNodeI
+}
+
+// "a {x} b", string parts and the interpolated expressions in
+// the order they appear. Evaluates to the concatenation of the
+// string representations of the parts.
+type InterpolationNodeS struct {
+	Tk token.Token			// first part of the string
+	Parts []ExpNodeI
+}
+
+// 'xif' { '|' cond '=>' body }+ { 'otherwise' body }?
+// Yields the body of the first true condition when used as an
+// expression. Used as a statement the bodies can also be blocks.
+type XifNodeS struct {
+	Tk token.Token			// 'xif' keyword
+	Arms []XifArmS
+	Otherwise ASTNodeI		// nil when there is no 'otherwise'
+}
+
+type XifArmS struct {
+	Cond ExpNodeI
+	Body ASTNodeI			// ExpNodeI, or *BlockNodeS in statements
+}
+
+// '(' params ')' {'->' type}? ( block | '=>' exp )
+// Anonymous function, evaluates to a function with the current
+// environment as closure. The short form returns 'Exp'.
+type FuncExpNodeS struct {
+	Tk token.Token			// '(' of the parameters
+	Params []FuncParamS
+	Rt mstype.MSType		// return type, inferred by the type resolver when nil
+	Body *BlockNodeS		// for the short form a block returning 'Exp'
+	Exp ExpNodeI			// body of the short form, nil for a block
 }
 
 // forces possible structs for ExpNode
@@ -149,6 +212,11 @@ func (*IterableFuncAppNodeS) expressionPlaceholder() {}
 func (*IterableFuncAppAndCallNodeS) expressionPlaceholder() {}
 func (*RangeConstructorNodeS) expressionPlaceholder() {}
 func (*StarredExpNodeS) expressionPlaceholder() {}
+func (*MatchNodeS) expressionPlaceholder() {}
+func (*XifNodeS) expressionPlaceholder() {}
+func (*MapConstructorNodeS) expressionPlaceholder() {}
+func (*InterpolationNodeS) expressionPlaceholder() {}
+func (*FuncExpNodeS) expressionPlaceholder() {}
 
 func (ve *VariableExpNodeS) VarName() string {
 
@@ -158,4 +226,20 @@ func (ve *VariableExpNodeS) VarName() string {
 	}
 
 	return ve.Name.Lexeme
-}
\ No newline at end of file
+}
+
+func (arm *MatchArmS) IsWildcard() bool {
+	return arm.Variant.VarName() == "_"
+}
+
+// All bodies of the xif, the 'otherwise' body comes last
+func (n *XifNodeS) Bodies() []ASTNodeI {
+	bodies := []ASTNodeI{}
+	for _, arm := range n.Arms {
+		bodies = append(bodies, arm.Body)
+	}
+	if n.Otherwise != nil {
+		bodies = append(bodies, n.Otherwise)
+	}
+	return bodies
+}
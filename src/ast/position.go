@@ -0,0 +1,65 @@
+package ast
+
+import "mikescript/src/token"
+
+// Returns a token which can be used to point at the expression in
+// the source code. Not all nodes carry a token of their own, for
+// those we look at the child nodes instead.
+func ExpToken(n ExpNodeI) token.Token {
+	switch e := n.(type) {
+	case *LiteralExpNodeS:				return e.Tk
+	case *VariableExpNodeS:				return e.Name
+	case *BinaryExpNodeS:				return e.Op
+	case *LogicalExpNodeS:				return e.Op
+	case *UnaryExpNodeS:				return e.Op
+	case *FuncCallNodeS:				return e.Op
+	case *MatchNodeS:					return e.Tk
+	case *XifNodeS:						return e.Tk
+	case *MapConstructorNodeS:			return e.Tk
+	case *InterpolationNodeS:			return e.Tk
+	case *FuncExpNodeS:					return e.Tk
+	case *IterableFuncCallNodeS:		return e.Op
+	case *GroupExpNodeS:				return e.TokenLeft
+	case *AssignmentNodeS:				return e.Identifier.Name
+	case *DeclAssignNodeS:				return e.Identifier.Name
+	case *FieldAccessNodeS:				return e.Field.Name
+	case *FieldAssignmentNode:			return e.Field.Name
+	case *FuncAppNodeS:					return e.Op
+	case *IterableFuncAppNodeS:			return e.Op
+	case *IterableFuncAppAndCallNodeS:	return e.Op
+	case *ArrayIndexNodeS:				return e.Tk
+	case *ArrayAssignmentNodeS:			return e.Tk
+	case *RangeConstructorNodeS:		return e.Tk
+	case *ArrayConstructorNodeS:		return e.Tk
+	case *StarredExpNodeS:				return e.Tk
+	case *TupleNodeS:					return firstExpToken(e.Expressions)
+	}
+	return token.Token{}
+}
+
+// Statement counterpart of ExpToken, the zero token for statements
+// without a position of their own.
+func StmtToken(n StmtNodeI) token.Token {
+	switch s := n.(type) {
+	case *IfNodeS:				return s.Tk
+	case *WhileNodeS:			return s.Tk
+	case *ForNodeS:				return s.Tk
+	case *XifNodeS:				return s.Tk
+	case *ReturnNodeS:			return s.Tk
+	case *BreakNodeS:			return s.Tk
+	case *ContinueNodeS:		return s.Tk
+	case *ImportNodeS:			return s.Tk
+	case *ThrowNodeS:			return s.Tk
+	case *TryNodeS:				return s.Tk
+	case *ExStmtNodeS:			return ExpToken(s.Ex)
+	case *VarDeclNodeS:			return s.Identifier.Name
+	}
+	return token.Token{}
+}
+
+func firstExpToken(exps []ExpNodeI) token.Token {
+	if len(exps) == 0 {
+		return token.Token{}
+	}
+	return ExpToken(exps[0])
+}
@@ -11,6 +11,8 @@ type Program struct {
 
 type BlockNodeS struct {
 	Statements []StmtNodeI
+	Start token.Token			// opening '{'
+	End token.Token				// closing '}'
 }
 
 type VarDeclNodeS struct {
@@ -26,17 +28,20 @@ type IfNodeS struct {
 	Condition 	ExpNodeI
 	ThenStmt 	StmtNodeI
 	ElseStmt 	StmtNodeI
+	Tk			token.Token		// 'if' keyword
 }
 
 type WhileNodeS struct {
 	Condition 	ExpNodeI
 	Body 		*BlockNodeS
+	Tk			token.Token		// 'while' keyword
 }
 
 type ForNodeS struct {
 	Iterable	ExpNodeI
 	LoopVar		*VariableExpNodeS
 	Body		*BlockNodeS
+	Tk			token.Token		// 'for' keyword
 }
 
 type ContinueNodeS struct {
@@ -49,13 +54,17 @@ type BreakNodeS struct {
 
 type ReturnNodeS struct {
 	Node ExpNodeI
+	Tk token.Token		// 'return' keyword, zero for the implicit return
 }
 
 type FuncDeclNodeS struct {
 	Fname *VariableExpNodeS				// Name
+	Receiver *VariableExpNodeS			// Struct of a method, nil for functions
+	TypeParams []*VariableExpNodeS		// Type parameters, empty when not generic
 	Params []FuncParamS 				// Parameters
 	Rt mstype.MSType					// Return type
 	Body *BlockNodeS					// Body of function, may be nil
+	Tk token.Token						// 'function' keyword
 }
 
 type TypeDefStatementS struct {
@@ -65,9 +74,43 @@ type TypeDefStatementS struct {
 
 type StructDeclarationNodeS struct {
 	Name *VariableExpNodeS
+	TypeParams []*VariableExpNodeS
 	Fields map[*VariableExpNodeS]mstype.MSType
 }
 
+type EnumDeclarationNodeS struct {
+	Name *VariableExpNodeS
+	Variants []EnumVariantS
+}
+
+type InterfaceDeclarationNodeS struct {
+	Name *VariableExpNodeS
+	Methods map[*VariableExpNodeS]*mstype.MSOperationTypeS
+}
+
+// 'import' STRING { '=>' IDENTIFIER }? ';'
+type ImportNodeS struct {
+	Tk token.Token					// 'import' keyword
+	Path token.Token				// path as written
+	Alias *VariableExpNodeS			// namespace, defaults to the file name
+	File string						// absolute path, set when the module is loaded
+}
+
+// 'throw' exp ';'
+type ThrowNodeS struct {
+	Tk token.Token					// 'throw' keyword
+	Node ExpNodeI					// string or error
+}
+
+// 'try' block { 'catch' { '(' IDENTIFIER ')' }? block }? { 'finally' block }?
+type TryNodeS struct {
+	Tk token.Token					// 'try' keyword
+	Body *BlockNodeS
+	Err *VariableExpNodeS			// name of the caught error, nil when not named
+	Catch *BlockNodeS				// nil when there is no 'catch'
+	Finally *BlockNodeS				// nil when there is no 'finally'
+}
+
 // forces possible structs for StmtNode
 func (*Program) statmentPlaceholder() {}
 func (*BlockNodeS) statmentPlaceholder() {}
@@ -82,6 +125,12 @@ func (*FuncDeclNodeS) statmentPlaceholder() {}
 func (*ReturnNodeS) statmentPlaceholder() {}
 func (*TypeDefStatementS) statmentPlaceholder() {}
 func (*StructDeclarationNodeS) statmentPlaceholder() {}
+func (*EnumDeclarationNodeS) statmentPlaceholder() {}
+func (*InterfaceDeclarationNodeS) statmentPlaceholder() {}
+func (*XifNodeS) statmentPlaceholder() {}
+func (*ImportNodeS) statmentPlaceholder() {}
+func (*ThrowNodeS) statmentPlaceholder() {}
+func (*TryNodeS) statmentPlaceholder() {}
 
 
 ////////////////////////////////////////////////////////////
@@ -101,10 +150,71 @@ func (fd *FuncDeclNodeS) GetFuncType() *mstype.MSOperationTypeS {
 	return &mstype.MSOperationTypeS{Left: typelist, Right: fd.Rt}
 }
 
+func (fe *FuncExpNodeS) GetFuncType() *mstype.MSOperationTypeS {
+	return (&FuncDeclNodeS{Params: fe.Params, Rt: fe.Rt}).GetFuncType()
+}
+
+func (sd *StructDeclarationNodeS) GetStructType() *mstype.MSStructTypeS {
+	fields := make(map[string]mstype.MSType)
+	for name, t := range sd.Fields {
+		fields[name.VarName()] = t
+	}
+	return &mstype.MSStructTypeS{Name: sd.Name.VarName(), Fields: fields, Params: TypeParamNames(sd.TypeParams)}
+}
+
+func (fd *FuncDeclNodeS) IsMethod() bool {
+	return fd.Receiver != nil
+}
+
+// Type of a method once the receiver is bound
+func (fd *FuncDeclNodeS) GetMethodType() *mstype.MSOperationTypeS {
+	ft := fd.GetFuncType()
+	return &mstype.MSOperationTypeS{Left: ft.Left[1:], Right: ft.Right}
+}
+
+func (fd *FuncDeclNodeS) TypeParamNames() []string {
+	return TypeParamNames(fd.TypeParams)
+}
+
+func TypeParamNames(ps []*VariableExpNodeS) []string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.VarName()
+	}
+	return names
+}
+
+type EnumVariantS struct {
+	Name *VariableExpNodeS		// Variant name, also the constructor
+	Types []mstype.MSType		// Payload types
+}
+
+func (ed *EnumDeclarationNodeS) GetEnumType() *mstype.MSEnumTypeS {
+	variants := make([]mstype.MSVariantS, len(ed.Variants))
+	for i, v := range ed.Variants {
+		variants[i] = mstype.MSVariantS{Name: v.Name.VarName(), Types: v.Types}
+	}
+	return &mstype.MSEnumTypeS{Name: ed.Name.VarName(), Variants: variants}
+}
+
+func (id *InterfaceDeclarationNodeS) GetInterfaceType() *mstype.MSInterfaceTypeS {
+	methods := make(map[string]*mstype.MSOperationTypeS)
+	for name, t := range id.Methods {
+		methods[name.VarName()] = t
+	}
+	return &mstype.MSInterfaceTypeS{Name: id.Name.VarName(), Methods: methods}
+}
+
 func (rs *ReturnNodeS) HasReturnValue() bool {
 	return rs.Node != nil
 }
 
+func (rs *ReturnNodeS) Implicit() bool {
+	// The parser appends a 'return nothing;' to every function
+	// body, this one has no 'return' keyword token.
+	return rs.Tk.Type != token.RETURN
+}
+
 func (vd *VarDeclNodeS) VarName() string {
 	return vd.Identifier.Name.Lexeme
 }
@@ -1,130 +1,871 @@
 package ast
 
-// import (
-// 	"fmt"
-// 	"mikescript/src/token"
-// 	"mikescript/src/utils"
-// 	"strings"
-// )
-
-// func (node *Program) String() string {
-// 	s := ""
-// 	for i, stmt := range node.Statements {
-// 		s += fmt.Sprintf("[%v] %v\n", i, stmt)
-// 	}
-// 	return s
-// }
-
-// func (node *ExStmtNodeS) String() string {
-// 	return fmt.Sprintf("%v;", node.Ex)
-// }
-
-// func (node *BinaryExpNodeS) String() string {
-// 	switch node.Op.Type {
-// 	case token.GREATER_GREATER:	return fmt.Sprintf("%v(%v)", node.Right, node.Left)
-// 	case token.COMMA:			return fmt.Sprintf("(%v, %v)", node.Left, node.Right)
-// 	default: 					return fmt.Sprintf("(%v %v %v)", node.Left, node.Op.Lexeme, node.Right)
-// 	}
-// }
-
-// func (node *UnaryExpNodeS) String() string {
-// 	return fmt.Sprintf("(%v %v)", node.Op.Lexeme, node.Node)
-// }
-
-// func (node *LiteralExpNodeS) String() string {
-// 	switch node.Tk.Type{
-// 	case token.STRING:			return fmt.Sprintf("\"%v\"", node.Tk.Lexeme)
-// 	case token.NUMBER_FLOAT:	return fmt.Sprintf("%v", node.Tk.Lexeme)
-// 	case token.NUMBER_INT:		return fmt.Sprintf("%v", node.Tk.Lexeme)
-// 	default:					return node.Tk.Lexeme
-// 	}
-// }
-
-// func (node *GroupExpNodeS) String() string {
-// 	return fmt.Sprintf("(%v)", node.Node)
-// }
-
-// func (node *VarDeclNodeS) String() string {
-// 	return fmt.Sprintf("%v %v", node.Vartype, node.Identifier)
-// }
-
-// func (node *AssignmentNodeS) String() string {
-// 	return fmt.Sprintf("(%v -> %v)", node.Exp, node.Identifier)
-// }
-
-// func (node *FuncAppNodeS) String() string {
-
-// 	// map array to strings
-// 	args := utils.MapArrayString(node.Args)
-
-// 	// join the strings
-// 	argsStr := strings.Join(args, ", ")
-
-// 	return fmt.Sprintf("(%v)>>%v", argsStr, node.Fun)
-// }
-
-// func (node *VariableExpNodeS) String() string {
-// 	return node.Name.Lexeme
-// }
-
-// func (node *BlockNodeS) String() string {
-// 	s := "{\n"
-// 	for _, stmt := range node.Statements {
-// 		s += fmt.Sprintf("%v\n", stmt)
-// 	}
-// 	s += "}"
-// 	return s
-// }
-
-// func (node *IfNodeS) String() string {
-// 	s := fmt.Sprintf("if %v %v", node.Condition, node.ThenStmt)
-// 	if node.ElseStmt != nil {
-// 		s += fmt.Sprintf(" else %v", node.ElseStmt)
-// 	}
-// 	return s
-// }
-
-// func (node *LogicalExpNodeS) String() string {
-// 	return fmt.Sprintf("(%v %v %v)", node.Left, node.Op.Lexeme, node.Right)
-// }
-
-// func (node *WhileNodeS) String() string {
-// 	return fmt.Sprintf("while %v %v", node.Condition, node.Body)
-// }
-
-// func (node *ContinueNodeS) String() string {
-// 	return "continue"
-// }
-
-// func (node *BreakNodeS) String() string {
-// 	return "break"
-// }
-
-// func (node *ReturnNodeS) String() string {
-// 	return fmt.Sprintf("return %s", node.Node)
-// }
-
-// func (node *FuncDeclNodeS) String() string {
-
-// 	// Get args string
-// 	argss := []string{}
-// 	for _, arg := range node.Params {
-// 		argss = append(argss, arg.String())
-// 	}
-
-// 	// format
-// 	return fmt.Sprintf(
-// 		"%s %s %s %s %s",
-// 		strings.Join(argss, ", "),		// int x, int y
-// 		token.GREATER_GREATER.String(), // >>
-// 		node.Fname.String(),			// fname
-// 		token.MINUS_GREAT.String(),		// ->
-// 		node.Rt.String(),				// int
-// 	)
-// }
-
-// func (fa *FuncParamS) String() string {
-// 	typeS := fa.Type.String()
-// 	nameS := fa.Iden.Name.Lexeme
-// 	return fmt.Sprintf("%s %s", typeS, nameS)
-// }
\ No newline at end of file
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+	"slices"
+	"strings"
+)
+
+// Prints a program in the canonical layout used by 'ms fmt'. The
+// comments are the ones found by the scanner, they are put back in
+// front of the statement that follows them or at the end of the line
+// they were on. Lists with comments between their items keep an item
+// per line. The source is needed for the blank lines between
+// statements and for raw strings, at most one blank line is kept.
+func Format(program *Program, comments []token.Token, src string) string {
+
+	p := &printer{lines: strings.Split(src, "\n"), comments: comments}
+
+	var sb strings.Builder
+	p.entries(&sb, p.statementEntries(program.Statements), math.MaxInt)
+
+	out := strings.TrimPrefix(sb.String(), "\n")
+
+	if out == "" {
+		return ""
+	}
+
+	return out + "\n"
+}
+
+const indentation = "    "
+
+type printer struct {
+	lines []string			// source lines
+	comments []token.Token	// comments which are not printed yet
+	indent int				// current indentation level
+}
+
+// A line of a block, printed after the comments before it
+type entry struct {
+	line int				// line in the source, 0 when not known
+	text func() string
+}
+
+func (p *printer) tabs() string {
+	return strings.Repeat(indentation, p.indent)
+}
+
+func (p *printer) entries(sb *strings.Builder, es []entry, end int) {
+	// Entries go on lines of their own, 'end' is the line closing
+	// the block. The comments before it belong to the block.
+
+	first := true
+
+	for _, e := range es {
+
+		first = p.commentsBefore(sb, e.line, first)
+
+		if !first && p.blankBefore(e.line) {
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("\n" + p.tabs() + e.text())
+		first = false
+	}
+
+	p.commentsBefore(sb, end, first)
+}
+
+func (p *printer) commentsBefore(sb *strings.Builder, line int, first bool) bool {
+	// Comments after code stay on the line of that code, others
+	// get a line of their own. So do the comments after code which
+	// was joined to the line of an earlier comment.
+
+	trailed := false
+
+	for len(p.comments) > 0 && p.comments[0].Line < line {
+
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		if p.trailing(c) && sb.Len() > 0 && !trailed {
+			sb.WriteString(" " + c.Lexeme)
+			trailed = true
+			continue
+		}
+
+		if !first && p.blankBefore(c.Line) {
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("\n" + p.tabs() + c.Lexeme)
+		first = false
+	}
+
+	return first
+}
+
+func (p *printer) trailing(c token.Token) bool {
+	if c.Line < 1 || c.Line > len(p.lines) {
+		return false
+	}
+	return !strings.HasPrefix(strings.TrimSpace(p.lines[c.Line-1]), "//")
+}
+
+func (p *printer) blankBefore(line int) bool {
+	if line < 2 || line > len(p.lines) {
+		return false
+	}
+	return strings.TrimSpace(p.lines[line-2]) == ""
+}
+
+// '{' entries '}', the '}' goes on the line after the last entry
+func (p *printer) braced(es []entry) string {
+
+	if len(es) == 0 {
+		return "{}"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{")
+
+	p.indent++
+	p.entries(&sb, es, es[len(es)-1].line + 1)
+	p.indent--
+
+	sb.WriteString("\n" + p.tabs() + "}")
+
+	return sb.String()
+}
+
+////////////////////////////////////////////////////////////
+// Statements
+////////////////////////////////////////////////////////////
+
+func (p *printer) statementEntries(stmts []StmtNodeI) []entry {
+
+	es := []entry{}
+
+	for _, stmt := range p.explicit(stmts) {
+		es = append(es, entry{line: stmtLine(stmt), text: func() string { return p.stmt(stmt) }})
+	}
+
+	return es
+}
+
+// Statements written in the source, without the 'return;' the
+// parser adds to function bodies.
+func (p *printer) explicit(stmts []StmtNodeI) []StmtNodeI {
+	return slices.DeleteFunc(slices.Clone(stmts), func(stmt StmtNodeI) bool {
+		ret, ok := stmt.(*ReturnNodeS)
+		return ok && ret.Implicit()
+	})
+}
+
+func (p *printer) block(b *BlockNodeS) string {
+
+	var sb strings.Builder
+	sb.WriteString("{")
+
+	p.indent++
+	p.entries(&sb, p.statementEntries(b.Statements), b.End.Line)
+	p.indent--
+
+	if sb.Len() == 1 {
+		return "{}"
+	}
+
+	sb.WriteString("\n" + p.tabs() + "}")
+
+	return sb.String()
+}
+
+// Blocks in expressions and xif arms which are written on a single
+// line stay on that line, when they hold one statement.
+func (p *printer) inlineBlock(b *BlockNodeS) string {
+
+	if !p.inline(b) {
+		return p.block(b)
+	}
+
+	return fmt.Sprintf("{ %s }", p.stmt(p.explicit(b.Statements)[0]))
+}
+
+func (p *printer) inline(b *BlockNodeS) bool {
+
+	stmts := p.explicit(b.Statements)
+
+	if len(stmts) != 1 || stmtLine(stmts[0]) != b.End.Line {
+		return false
+	}
+
+	return len(p.comments) == 0 || p.comments[0].Line >= b.End.Line
+}
+
+func (p *printer) stmt(n StmtNodeI) string {
+	switch s := n.(type) {
+	case *BlockNodeS:					return p.block(s)
+	case *VarDeclNodeS:					return fmt.Sprintf("var %s %s;", typeString(s.Vartype), s.VarName())
+	case *ExStmtNodeS:					return p.exp(s.Ex) + ";"
+	case *IfNodeS:						return p.ifStmt(s)
+	case *WhileNodeS:					return fmt.Sprintf("while %s %s", p.cond(s.Condition), p.block(s.Body))
+	case *ForNodeS:						return fmt.Sprintf("for %s .-> %s %s", p.exp(s.Iterable), s.LoopVar.VarName(), p.block(s.Body))
+	case *ContinueNodeS:				return "continue;"
+	case *BreakNodeS:					return "break;"
+	case *ReturnNodeS:					return p.returnStmt(s)
+	case *FuncDeclNodeS:				return p.funcDecl(s)
+	case *TypeDefStatementS:			return fmt.Sprintf("type %s %s;", typeString(s.Type), s.Tname.VarName())
+	case *StructDeclarationNodeS:		return p.structDecl(s)
+	case *EnumDeclarationNodeS:			return p.enumDecl(s)
+	case *InterfaceDeclarationNodeS:	return p.interfaceDecl(s)
+	case *XifNodeS:						return p.xif(s, true)
+	case *ImportNodeS:					return p.importStmt(s)
+	case *ThrowNodeS:					return fmt.Sprintf("throw %s;", p.exp(s.Node))
+	case *TryNodeS:						return p.tryStmt(s)
+	default:							return ""
+	}
+}
+
+func (p *printer) ifStmt(s *IfNodeS) string {
+
+	str := fmt.Sprintf("if %s %s", p.cond(s.Condition), p.stmt(s.ThenStmt))
+
+	if s.ElseStmt != nil {
+		str += " else " + p.stmt(s.ElseStmt)
+	}
+
+	return str
+}
+
+// Conditions are printed without the parentheses around them,
+// 'if (n <= 1)' becomes 'if n <= 1'.
+func (p *printer) cond(n ExpNodeI) string {
+
+	if g, ok := n.(*GroupExpNodeS) ; ok {
+		if _, tuple := g.Node.(*TupleNodeS) ; !tuple {
+			return p.cond(g.Node)
+		}
+	}
+
+	return p.exp(n)
+}
+
+func (p *printer) returnStmt(s *ReturnNodeS) string {
+
+	// 'return;' returns a literal without a position
+	if lit, ok := s.Node.(*LiteralExpNodeS) ; ok && lit.Tk.Line == 0 {
+		return "return;"
+	}
+
+	return fmt.Sprintf("return %s;", p.exp(s.Node))
+}
+
+func (p *printer) funcDecl(s *FuncDeclNodeS) string {
+
+	name := s.Fname.VarName()
+	if s.IsMethod() {
+		name = s.Receiver.VarName() + "." + name
+	}
+
+	str := fmt.Sprintf("function%s (%s) >> %s", typeParams(s.TypeParams), p.params(s.Params), name)
+
+	if !isNothing(s.Rt) {
+		str += " -> " + typeString(s.Rt)
+	}
+
+	return str + " " + p.block(s.Body)
+}
+
+func (p *printer) structDecl(s *StructDeclarationNodeS) string {
+
+	es := []entry{}
+	for _, field := range byPosition(s.Fields) {
+		text := fmt.Sprintf("%s %s;", typeString(s.Fields[field]), field.VarName())
+		es = append(es, entry{line: field.Name.Line, text: constant(text)})
+	}
+
+	return fmt.Sprintf("type struct%s %s %s", typeParams(s.TypeParams), s.Name.VarName(), p.braced(es))
+}
+
+func (p *printer) enumDecl(s *EnumDeclarationNodeS) string {
+
+	es := []entry{}
+	for _, v := range s.Variants {
+
+		text := v.Name.VarName()
+		if len(v.Types) > 0 {
+			text += "(" + typeList(v.Types) + ")"
+		}
+
+		es = append(es, entry{line: v.Name.Name.Line, text: constant(text + ",")})
+	}
+
+	return fmt.Sprintf("type enum %s %s", s.Name.VarName(), p.braced(es))
+}
+
+func (p *printer) interfaceDecl(s *InterfaceDeclarationNodeS) string {
+
+	es := []entry{}
+	for _, method := range byPosition(s.Methods) {
+		text := fmt.Sprintf("%s %s;", typeString(s.Methods[method]), method.VarName())
+		es = append(es, entry{line: method.Name.Line, text: constant(text)})
+	}
+
+	return fmt.Sprintf("type interface %s %s", s.Name.VarName(), p.braced(es))
+}
+
+func (p *printer) importStmt(s *ImportNodeS) string {
+
+	// Without an alias the parser names the module after the path,
+	// that name has the position of the path.
+	if s.Alias.Name.Line == s.Path.Line && s.Alias.Name.Col == s.Path.Col {
+		return fmt.Sprintf("import %s;", p.literal(s.Path))
+	}
+
+	return fmt.Sprintf("import %s => %s;", p.literal(s.Path), s.Alias.VarName())
+}
+
+func (p *printer) tryStmt(s *TryNodeS) string {
+
+	str := "try " + p.block(s.Body)
+
+	if s.Catch != nil {
+		str += " catch "
+		if s.Err != nil {
+			str += fmt.Sprintf("(%s) ", s.Err.VarName())
+		}
+		str += p.block(s.Catch)
+	}
+
+	if s.Finally != nil {
+		str += " finally " + p.block(s.Finally)
+	}
+
+	return str
+}
+
+func (p *printer) xif(s *XifNodeS, stmt bool) string {
+	// One line with a single condition:
+	//   xif | cond => body otherwise body
+	// Otherwise every arm gets a line, indented when the xif is
+	// part of an expression.
+
+	bodies := s.Bodies()
+	_, block := bodies[len(bodies)-1].(*BlockNodeS)
+	end := ""
+	if stmt && !block {
+		end = ";"
+	}
+
+	arms := []entry{}
+	for i, arm := range s.Arms {
+		arms = append(arms, entry{line: expLine(arm.Cond), text: func() string {
+			return fmt.Sprintf("| %s => %s", p.exp(arm.Cond), p.xifBody(arm.Body)) + p.last(i, len(bodies), end)
+		}})
+	}
+
+	if s.Otherwise != nil {
+		arms = append(arms, entry{line: nodeLine(s.Otherwise), text: func() string {
+			return "otherwise " + p.xifBody(s.Otherwise) + end
+		}})
+	}
+
+	if len(s.Arms) == 1 && p.inlineBodies(bodies) && !p.commented(arms) {
+		texts := make([]string, len(arms))
+		for i, arm := range arms {
+			texts[i] = arm.text()
+		}
+		return "xif " + strings.Join(texts, " ")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("xif")
+
+	if !stmt {
+		p.indent++
+		defer func() { p.indent-- }()
+	}
+
+	// The comments after the last arm of an expression come after
+	// the rest of the statement
+	last := arms[len(arms)-1].line
+	if stmt {
+		last++
+	}
+
+	p.entries(&sb, arms, last)
+
+	return sb.String()
+}
+
+func (p *printer) last(i, n int, s string) string {
+	if i == n - 1 {
+		return s
+	}
+	return ""
+}
+
+func (p *printer) xifBody(n ASTNodeI) string {
+	switch b := n.(type) {
+	case *BlockNodeS:	return p.inlineBlock(b)
+	case ExpNodeI:		return p.exp(b)
+	default:			return ""
+	}
+}
+
+func (p *printer) inlineBodies(bodies []ASTNodeI) bool {
+	for _, body := range bodies {
+		if b, ok := body.(*BlockNodeS) ; ok && !p.inline(b) {
+			return false
+		}
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////
+// Expressions
+////////////////////////////////////////////////////////////
+
+func (p *printer) exp(n ExpNodeI) string {
+	switch e := n.(type) {
+	case *LiteralExpNodeS:				return p.literal(e.Tk)
+	case *VariableExpNodeS:				return e.VarName()
+	case *GroupExpNodeS:				return "(" + p.exp(e.Node) + ")"
+	case *TupleNodeS:					return p.list(e.Expressions)
+	case *BinaryExpNodeS:				return p.binary(e)
+	case *LogicalExpNodeS:				return fmt.Sprintf("%s %s %s", p.exp(e.Left), e.Op.Lexeme, p.exp(e.Right))
+	case *UnaryExpNodeS:				return p.unary(e)
+	case *AssignmentNodeS:				return fmt.Sprintf("%s -> %s", p.exp(e.Exp), e.Identifier.VarName())
+	case *DeclAssignNodeS:				return fmt.Sprintf("%s => %s", p.exp(e.Exp), e.Identifier.VarName())
+	case *ArrayAssignmentNodeS:			return fmt.Sprintf("%s -> %s[%s]", p.exp(e.Value), p.exp(e.Target), p.exp(e.Index))
+	case *FieldAssignmentNode:			return fmt.Sprintf("%s -> %s.%s", p.exp(e.Value), p.exp(e.Target), e.Field.VarName())
+	case *FuncAppNodeS:					return p.application(e)
+	case *FuncCallNodeS:				return p.call(e)
+	case *IterableFuncAppNodeS:			return fmt.Sprintf("%s .>> %s", p.exp(e.Args), p.exp(e.Fun))
+	case *IterableFuncAppAndCallNodeS:	return fmt.Sprintf("%s .>>= %s", p.exp(e.Args), p.exp(e.Fun))
+	case *IterableFuncCallNodeS:		return prefix(e.Op.Lexeme, p.exp(e.Fun))
+	case *StarredExpNodeS:				return prefix("*", p.exp(e.Node))
+	case *ArrayIndexNodeS:				return fmt.Sprintf("%s[%s]", p.exp(e.Target), p.exp(e.Index))
+	case *FieldAccessNodeS:				return fmt.Sprintf("%s.%s", p.exp(e.Target), e.Field.VarName())
+	case *ArrayConstructorNodeS:		return p.array(e)
+	case *RangeConstructorNodeS:		return p.rangeExp(e)
+	case *MapConstructorNodeS:			return p.mapExp(e)
+	case *InterpolationNodeS:			return p.interpolation(e)
+	case *MatchNodeS:					return p.match(e)
+	case *XifNodeS:						return p.xif(e, false)
+	case *FuncExpNodeS:					return p.lambda(e)
+	default:							return ""
+	}
+}
+
+func (p *printer) list(exps []ExpNodeI) string {
+	strs := make([]string, len(exps))
+	for i, e := range exps {
+		strs[i] = p.exp(e)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// Items of a list written over several lines with comments between
+// them go on lines of their own, the comments stay next to their
+// items. Otherwise the items are joined on one line.
+func (p *printer) items(es []entry) string {
+
+	if !p.commented(es) {
+		strs := make([]string, len(es))
+		for i, e := range es {
+			strs[i] = e.text()
+		}
+		return strings.Join(strs, ", ")
+	}
+
+	last := len(es) - 1
+
+	lines := make([]entry, len(es))
+	for i, e := range es {
+		lines[i] = entry{line: e.line, text: func() string {
+			if i == last {
+				return e.text()
+			}
+			return e.text() + ","
+		}}
+	}
+
+	var sb strings.Builder
+
+	p.indent++
+	p.entries(&sb, lines, es[last].line + 1)
+	p.indent--
+
+	sb.WriteString("\n" + p.tabs())
+
+	return sb.String()
+}
+
+func (p *printer) commented(es []entry) bool {
+
+	if len(es) == 0 || es[0].line == es[len(es)-1].line {
+		return false
+	}
+
+	return slices.ContainsFunc(p.comments, func(c token.Token) bool {
+		return c.Line >= es[0].line && c.Line <= es[len(es)-1].line
+	})
+}
+
+func (p *printer) binary(e *BinaryExpNodeS) string {
+
+	// 'a - b' is parsed as 'a + (-b)', both operators have the
+	// position of the '-'
+	if neg, ok := e.Right.(*UnaryExpNodeS) ; ok && e.Op.Type == token.PLUS && neg.Op.Type == token.MINUS && samePosition(e.Op, neg.Op) {
+		return fmt.Sprintf("%s - %s", p.exp(e.Left), p.exp(neg.Node))
+	}
+
+	return fmt.Sprintf("%s %s %s", p.exp(e.Left), e.Op.Lexeme, p.exp(e.Right))
+}
+
+func (p *printer) unary(e *UnaryExpNodeS) string {
+
+	if eq, ok := notEqual(e) ; ok {
+		return fmt.Sprintf("%s != %s", p.exp(eq.Left), p.exp(eq.Right))
+	}
+
+	return prefix(e.Op.Lexeme, p.exp(e.Node))
+}
+
+// 'a != b' is parsed as '!(a == b)', both operators have the
+// position of the '!='
+func notEqual(e *UnaryExpNodeS) (*BinaryExpNodeS, bool) {
+	eq, ok := e.Node.(*BinaryExpNodeS)
+	return eq, ok && e.Op.Type == token.EXCLAMATION && eq.Op.Type == token.EQ_EQ && samePosition(e.Op, eq.Op)
+}
+
+// Prefix operators are written against their operand, unless the
+// two would scan as a different operator: '! =f' is not '!= f'.
+func prefix(op string, operand string) string {
+	if strings.HasPrefix(operand, "=") {
+		return op + " " + operand
+	}
+	return op + operand
+}
+
+func (p *printer) application(e *FuncAppNodeS) string {
+
+	// 'x *>> f' is parsed as '*x >> f', the star has the '*>>' token
+	args := e.Args
+	if len(args) == 1 {
+		if star, ok := args[0].(*StarredExpNodeS) ; ok && star.Tk.Type != token.MULT {
+			args = []ExpNodeI{star.Node}
+		}
+	}
+
+	if len(args) == 0 {
+		return fmt.Sprintf("%s %s", e.Op.Lexeme, p.exp(e.Fun))
+	}
+
+	return fmt.Sprintf("%s %s %s", p.list(args), e.Op.Lexeme, p.exp(e.Fun))
+}
+
+func (p *printer) call(e *FuncCallNodeS) string {
+
+	// 'x >>= f' is parsed as '=(x >>= f)', the application prints
+	// the '>>='
+	if e.Op.Type != token.EQ {
+		return p.exp(e.Fun)
+	}
+
+	return prefix("=", p.exp(e.Fun))
+}
+
+func (p *printer) array(e *ArrayConstructorNodeS) string {
+
+	n := ""
+	if e.N != nil {
+		n = p.exp(e.N)
+	}
+
+	vals := []entry{}
+	for _, val := range e.Vals {
+		vals = append(vals, entry{line: expLine(val), text: func() string { return p.exp(val) }})
+	}
+
+	return fmt.Sprintf("[%s]%s{%s}", n, typeString(e.Type), p.items(vals))
+}
+
+func (p *printer) rangeExp(e *RangeConstructorNodeS) string {
+
+	// '[.. n]' starts at a literal without a position
+	if lit, ok := e.From.(*LiteralExpNodeS) ; ok && lit.Tk.Line == 0 {
+		return fmt.Sprintf("[.. %s]", p.exp(e.To))
+	}
+
+	return fmt.Sprintf("[%s .. %s]", p.exp(e.From), p.exp(e.To))
+}
+
+func (p *printer) mapExp(e *MapConstructorNodeS) string {
+
+	entries := []entry{}
+	for i := range e.Keys {
+		entries = append(entries, entry{line: expLine(e.Keys[i]), text: func() string {
+			return fmt.Sprintf("%s: %s", p.exp(e.Keys[i]), p.exp(e.Vals[i]))
+		}})
+	}
+
+	return fmt.Sprintf("%s{%s}", typeString(e.Type), p.items(entries))
+}
+
+func (p *printer) match(e *MatchNodeS) string {
+
+	str := "match"
+	if e.Rt != nil {
+		str += " -> " + typeString(e.Rt)
+	}
+
+	arms := []entry{}
+	for _, arm := range e.Arms {
+		arms = append(arms, entry{line: arm.Variant.Name.Line, text: func() string {
+			return fmt.Sprintf("%s => %s;", pattern(arm), p.exp(arm.Body))
+		}})
+	}
+
+	return str + " " + p.braced(arms)
+}
+
+func pattern(arm MatchArmS) string {
+
+	if len(arm.Bindings) == 0 {
+		return arm.Variant.VarName()
+	}
+
+	names := make([]string, len(arm.Bindings))
+	for i, b := range arm.Bindings {
+		names[i] = b.VarName()
+	}
+
+	return fmt.Sprintf("%s(%s)", arm.Variant.VarName(), strings.Join(names, ", "))
+}
+
+func (p *printer) lambda(e *FuncExpNodeS) string {
+
+	str := fmt.Sprintf("(%s)", p.params(e.Params))
+
+	if !isNothing(e.Rt) {
+		str += " -> " + typeString(e.Rt)
+	}
+
+	if e.Exp != nil {
+		return str + " => " + p.exp(e.Exp)
+	}
+
+	return str + " " + p.inlineBlock(e.Body)
+}
+
+func (p *printer) interpolation(e *InterpolationNodeS) string {
+
+	// The string parts are literals, the expressions go between
+	// braces. A string literal as expression gives the same string.
+	var sb strings.Builder
+	sb.WriteString(`"`)
+
+	for _, part := range e.Parts {
+		if lit, ok := part.(*LiteralExpNodeS) ; ok && lit.Tk.Type == token.STRING {
+			sb.WriteString(escapes.Replace(lit.Tk.Lexeme))
+		} else {
+			sb.WriteString("{" + p.exp(part) + "}")
+		}
+	}
+
+	sb.WriteString(`"`)
+
+	return sb.String()
+}
+
+var escapes = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	`{`, `\{`,
+	`}`, `\}`,
+	"\n", `\n`,
+	"\t", `\t`,
+	"\r", `\r`,
+	"\x00", `\0`,
+)
+
+func (p *printer) literal(tk token.Token) string {
+
+	if tk.Type != token.STRING {
+		return tk.Lexeme
+	}
+
+	if p.raw(tk) {
+		return "`" + tk.Lexeme + "`"
+	}
+
+	return `"` + escapes.Replace(tk.Lexeme) + `"`
+}
+
+// Raw strings keep their contents as written, the position of a
+// string token is its closing quote.
+func (p *printer) raw(tk token.Token) bool {
+
+	if tk.Line < 1 || tk.Line > len(p.lines) {
+		return false
+	}
+
+	line := p.lines[tk.Line-1]
+
+	return tk.Col >= 1 && tk.Col <= len(line) && line[tk.Col-1] == '`'
+}
+
+////////////////////////////////////////////////////////////
+// Types
+////////////////////////////////////////////////////////////
+
+// Types as written in the source, 'String' of the types leaves out
+// 'nothing'.
+func typeString(t mstype.MSType) string {
+	switch tt := t.(type) {
+	case *mstype.MSSimpleTypeS:		return cmp.Or(tt.String(), "nothing")
+	case *mstype.MSArrayType:		return "[]" + typeString(tt.Type)
+	case *mstype.MSMapTypeS:		return fmt.Sprintf("map[%s]%s", typeString(tt.Key), typeString(tt.Value))
+	case *mstype.MSCompositeTypeS:	return fmt.Sprintf("(%s)", typeList(tt.Types))
+	case *mstype.MSOperationTypeS:	return operationType(tt)
+	case *mstype.MSNamedTypeS:		return namedType(tt)
+	default:						return t.String()
+	}
+}
+
+func typeList(ts []mstype.MSType) string {
+	strs := make([]string, len(ts))
+	for i, t := range ts {
+		strs[i] = typeString(t)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// '(int -> int)', '(int ->)', '(-> int)' and '(->)'
+func operationType(t *mstype.MSOperationTypeS) string {
+
+	str := "("
+	if len(t.Left) > 0 {
+		str += typeList(t.Left) + " "
+	}
+
+	str += "->"
+	if !isNothing(t.Right) {
+		str += " " + typeString(t.Right)
+	}
+
+	return str + ")"
+}
+
+func namedType(t *mstype.MSNamedTypeS) string {
+
+	name := t.Name
+	if t.Namespace != "" {
+		name = t.Namespace + "." + name
+	}
+
+	if len(t.Args) == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s<%s>", name, typeList(t.Args))
+}
+
+func isNothing(t mstype.MSType) bool {
+	return t == nil || mstype.MS_NOTHING.Eq(t)
+}
+
+func typeParams(ps []*VariableExpNodeS) string {
+	if len(ps) == 0 {
+		return ""
+	}
+	return "<" + strings.Join(TypeParamNames(ps), ", ") + ">"
+}
+
+func (p *printer) params(ps []FuncParamS) string {
+	es := []entry{}
+	for _, param := range ps {
+		text := fmt.Sprintf("%s %s", typeString(param.Type), param.VarName())
+		es = append(es, entry{line: param.Iden.Name.Line, text: constant(text)})
+	}
+	return p.items(es)
+}
+
+////////////////////////////////////////////////////////////
+// Positions
+////////////////////////////////////////////////////////////
+
+// Line of the first token of a statement
+func stmtLine(n StmtNodeI) int {
+	switch s := n.(type) {
+	case *ExStmtNodeS:					return expLine(s.Ex)
+	case *BlockNodeS:					return s.Start.Line
+	case *FuncDeclNodeS:				return s.Tk.Line
+	case *TypeDefStatementS:			return s.Tname.Name.Line
+	case *StructDeclarationNodeS:		return s.Name.Name.Line
+	case *EnumDeclarationNodeS:			return s.Name.Name.Line
+	case *InterfaceDeclarationNodeS:	return s.Name.Name.Line
+	default:							return StmtToken(n).Line
+	}
+}
+
+// Line of the first token of an expression, the token of ExpToken
+// can be an operator in the middle.
+func expLine(n ExpNodeI) int {
+	switch e := n.(type) {
+	case *BinaryExpNodeS:				return expLine(e.Left)
+	case *LogicalExpNodeS:				return expLine(e.Left)
+	case *TupleNodeS:					return expLine(e.Expressions[0])
+	case *AssignmentNodeS:				return expLine(e.Exp)
+	case *DeclAssignNodeS:				return expLine(e.Exp)
+	case *ArrayAssignmentNodeS:			return expLine(e.Value)
+	case *FieldAssignmentNode:			return expLine(e.Value)
+	case *IterableFuncAppNodeS:			return expLine(e.Args)
+	case *IterableFuncAppAndCallNodeS:	return expLine(e.Args)
+	case *ArrayIndexNodeS:				return expLine(e.Target)
+	case *FieldAccessNodeS:				return expLine(e.Target)
+	case *FuncAppNodeS:
+		if len(e.Args) > 0 {
+			return expLine(e.Args[0])
+		}
+	case *FuncCallNodeS:
+		if e.Op.Type != token.EQ {
+			return expLine(e.Fun)
+		}
+	case *StarredExpNodeS:
+		if e.Tk.Type != token.MULT {
+			return expLine(e.Node)
+		}
+	case *UnaryExpNodeS:
+		if eq, ok := notEqual(e) ; ok {
+			return expLine(eq)
+		}
+	}
+	return ExpToken(n).Line
+}
+
+func nodeLine(n ASTNodeI) int {
+	switch b := n.(type) {
+	case *BlockNodeS:	return b.Start.Line
+	case ExpNodeI:		return expLine(b)
+	default:			return 0
+	}
+}
+
+func samePosition(a, b token.Token) bool {
+	return a.Line == b.Line && a.Col == b.Col
+}
+
+// Fields and methods are kept in maps, they are printed in the
+// order of the source.
+func byPosition[V any](m map[*VariableExpNodeS]V) []*VariableExpNodeS {
+	names := make([]*VariableExpNodeS, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b *VariableExpNodeS) int {
+		return cmp.Or(cmp.Compare(a.Name.Line, b.Name.Line), cmp.Compare(a.Name.Col, b.Name.Col))
+	})
+	return names
+}
+
+func constant(s string) func() string {
+	return func() string { return s }
+}
@@ -0,0 +1,383 @@
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mikescript/src/ast"
+	"mikescript/src/interp"
+	"mikescript/src/parser"
+	"os"
+	"path/filepath"
+	"mikescript/src/resolver"
+	"mikescript/src/scanner"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/*
+Interactive step debugger used by 'ms debug', implements
+'interp.Debugger'. The program pauses before its first line, at
+breakpoints and after stepping, commands are read from the input:
+	- break <line>, clear <line>: set or remove a breakpoint in the
+	  program, 'break <file>:<line>' in an imported module
+	- continue: run until the next breakpoint
+	- step: run until the next line, entering function calls
+	- next: run until the next line of this function or its callers
+	- out: run until the function returns to its caller
+	- env: print the variables of the paused scope and the scopes
+	  around it
+	- print <exp>: evaluate an expression in the paused scope
+	- watch <exp>, unwatch <n>: expressions printed at every pause
+	- calls: the functions being called
+	- list: the source around the paused line
+	- quit: stop the program
+An empty line repeats the last command. Lines without a file are
+those of the source given to 'NewDebugger', the files of modules are
+relative to the working directory.
+*/
+
+type Debugger struct {
+	in *bufio.Scanner
+	out io.Writer
+	sources map[string][]string	// lines of the program and of the modules read so far
+	breakpoints map[location]bool
+	watches []string
+	mode mode					// when to pause next
+	depth int					// call depth when 'next' or 'out' was given
+	at location					// location and depth of the last statement
+	lineDepth int
+	seen map[ast.StmtNodeI]bool	// statements executed since the line was entered
+	last string					// last command
+}
+
+type mode uint8
+const (
+	CONTINUE mode = iota
+	STEP
+	NEXT
+	OUT
+)
+
+// A line of the program or of a module
+type location struct {
+	file string					// path of the module, "" for the program
+	line int
+}
+
+func (l location) String() string {
+	if l.file == "" {
+		return fmt.Sprintf("line %d", l.line)
+	}
+	return fmt.Sprintf("%s:%d", l.file, l.line)
+}
+
+// Returned by 'Before' when the user quits
+var ErrQuit = errors.New("debugger: quit")
+
+func NewDebugger(src string, in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		in: bufio.NewScanner(in),
+		out: out,
+		sources: map[string][]string{"": strings.Split(src, "\n")},
+		breakpoints: make(map[location]bool),
+		mode: STEP,
+		seen: make(map[ast.StmtNodeI]bool),
+	}
+}
+
+// Breakpoint at a line of the module file, "" for the program
+func (d *Debugger) SetBreakpoint(file string, line int) error {
+
+	at, err := d.location(file, line)
+
+	if err != nil {
+		return err
+	}
+
+	d.breakpoints[at] = true
+
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements interp.Debugger
+// --------------------------------------------------------
+
+func (d *Debugger) Before(ev *interp.MSEvaluator, node ast.StmtNodeI) error {
+
+	// Implicit returns and declarations have no position
+	line := ast.StmtToken(node).Line
+	if line == 0 {
+		return nil
+	}
+
+	at := location{ev.File(), line}
+
+	if !d.enter(node, at, ev.Depth()) || !d.shouldPause(at, ev.Depth()) {
+		return nil
+	}
+
+	return d.pause(ev, at)
+}
+
+// Tracks the line being executed, true when it is entered. A line is
+// entered again when one of its statements runs twice, like the body
+// of a loop.
+func (d *Debugger) enter(node ast.StmtNodeI, at location, depth int) bool {
+
+	if at == d.at && depth == d.lineDepth && !d.seen[node] {
+		d.seen[node] = true
+		return false
+	}
+
+	d.at, d.lineDepth = at, depth
+	d.seen = map[ast.StmtNodeI]bool{node: true}
+
+	return true
+}
+
+func (d *Debugger) shouldPause(at location, depth int) bool {
+	switch d.mode {
+	case STEP:	return true
+	case NEXT:	if depth <= d.depth { return true }
+	case OUT:	if depth < d.depth { return true }
+	}
+	return d.breakpoints[at]
+}
+
+// --------------------------------------------------------
+// Commands
+// --------------------------------------------------------
+
+func (d *Debugger) pause(ev *interp.MSEvaluator, at location) error {
+
+	fmt.Fprintf(d.out, "Paused at %s\n", at)
+	d.printLine(at, true)
+
+	for i, w := range d.watches {
+		fmt.Fprintf(d.out, "watch %d: %s = %s\n", i, w, d.evaluate(ev, w))
+	}
+
+	for {
+		fmt.Fprint(d.out, "(debug) ")
+
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			return ErrQuit
+		}
+
+		cmd := strings.TrimSpace(d.in.Text())
+		if cmd == "" {
+			cmd = d.last
+		}
+		d.last = cmd
+
+		name, arg, _ := strings.Cut(cmd, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch name {
+		case "c", "continue":	d.mode = CONTINUE ; return nil
+		case "s", "step":		d.mode = STEP ; return nil
+		case "n", "next":		d.mode, d.depth = NEXT, ev.Depth() ; return nil
+		case "o", "out":		d.mode, d.depth = OUT, ev.Depth() ; return nil
+		case "q", "quit":		return ErrQuit
+		case "b", "break":		d.breakCommand(arg, true)
+		case "clear":			d.breakCommand(arg, false)
+		case "p", "print":		fmt.Fprintln(d.out, d.evaluate(ev, arg))
+		case "w", "watch":		d.watches = append(d.watches, arg)
+		case "unwatch":			d.unwatchCommand(arg)
+		case "e", "env":		ev.WriteEnv(d.out)
+		case "calls":			d.callsCommand(ev)
+		case "l", "list":		d.listCommand(at)
+		case "h", "help":		fmt.Fprint(d.out, help)
+		case "":				continue
+		default:				fmt.Fprintf(d.out, "Unknown command '%s', type 'help' for the commands\n", name)
+		}
+	}
+}
+
+const help = `Commands:
+  break [file:]<line>          set a breakpoint, in a module with a file
+  clear [file:]<line>          remove a breakpoint
+  continue                     run until the next breakpoint
+  step                         run until the next line, entering calls
+  next                         run until the next line, over calls
+  out                          run until the function returns
+  env                          print the variables in scope
+  print <exp>                  evaluate an expression in the paused scope
+  watch <exp>, unwatch <n>     expressions printed at every pause
+  calls                        the functions being called
+  list                         the source around the paused line
+  quit                         stop the program
+`
+
+// 'line' or 'file:line'
+func (d *Debugger) breakCommand(arg string, set bool) {
+
+	file, num := "", arg
+	if i := strings.LastIndex(arg, ":") ; i >= 0 {
+		file, num = arg[:i], arg[i+1:]
+	}
+
+	line, err := strconv.Atoi(num)
+
+	if err != nil {
+		fmt.Fprintf(d.out, "Invalid line '%s'\n", arg)
+		return
+	}
+
+	at, err := d.location(file, line)
+
+	if err != nil {
+		fmt.Fprintln(d.out, err)
+		return
+	}
+
+	if set {
+		d.breakpoints[at] = true
+		fmt.Fprintf(d.out, "Breakpoint at %s\n", at)
+	} else {
+		delete(d.breakpoints, at)
+		fmt.Fprintf(d.out, "Cleared %s\n", at)
+	}
+}
+
+// Location of a line in the file, modules are known by their
+// absolute path like the evaluator knows them.
+func (d *Debugger) location(file string, line int) (location, error) {
+
+	if file != "" {
+
+		abs, err := filepath.Abs(file)
+
+		if err != nil {
+			return location{}, err
+		}
+
+		file = abs
+	}
+
+	at := location{file, line}
+
+	if line < 1 || line > len(d.source(file)) {
+		return location{}, fmt.Errorf("Invalid breakpoint, there is no %s", at)
+	}
+
+	return at, nil
+}
+
+// Lines of the program or of a module, nil when the module can't be
+// read
+func (d *Debugger) source(file string) []string {
+
+	if lines, ok := d.sources[file] ; ok {
+		return lines
+	}
+
+	src, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil
+	}
+
+	d.sources[file] = strings.Split(string(src), "\n")
+
+	return d.sources[file]
+}
+
+func (d *Debugger) unwatchCommand(arg string) {
+
+	i, err := strconv.Atoi(arg)
+
+	if err != nil || i < 0 || i >= len(d.watches) {
+		fmt.Fprintf(d.out, "Invalid watch '%s'\n", arg)
+		return
+	}
+
+	d.watches = slices.Delete(d.watches, i, i+1)
+}
+
+func (d *Debugger) callsCommand(ev *interp.MSEvaluator) {
+
+	calls := ev.Calls()
+
+	for i := len(calls) - 1 ; i >= 0 ; i-- {
+		fmt.Fprintf(d.out, "    at %s\n", calls[i])
+	}
+	fmt.Fprintln(d.out, "    at <program>")
+}
+
+// Number of lines shown before and after the paused line
+const listContext = 3
+
+func (d *Debugger) listCommand(at location) {
+	for l := max(at.line - listContext, 1) ; l <= min(at.line + listContext, len(d.source(at.file))) ; l++ {
+		d.printLine(location{at.file, l}, l == at.line)
+	}
+}
+
+func (d *Debugger) printLine(at location, current bool) {
+
+	lines := d.source(at.file)
+
+	if at.line < 1 || at.line > len(lines) {
+		return
+	}
+
+	marker := " "
+	switch {
+	case current:				marker = ">"
+	case d.breakpoints[at]:		marker = "*"
+	}
+
+	fmt.Fprintf(d.out, "%s%4d | %s\n", marker, at.line, lines[at.line-1])
+}
+
+// --------------------------------------------------------
+// Expressions
+// --------------------------------------------------------
+
+// Value of src in the paused scope, or the errors it gives
+func (d *Debugger) evaluate(ev *interp.MSEvaluator, src string) string {
+
+	s := scanner.MSScanner{}
+	tokens := s.Scan(src + ";")
+
+	if len(s.Errors) > 0 {
+		return fmt.Sprint(s.Errors[0])
+	}
+
+	p := parser.MSParser{}
+	p.SetSrc(src)
+	p.SetTokens(tokens)
+
+	program, _ := p.Parse(tokens)
+
+	if len(p.Errors) > 0 {
+		return fmt.Sprint(p.Errors[0])
+	}
+
+	if len(program.Statements) != 1 {
+		return "Expected a single expression"
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExStmtNodeS)
+
+	if !ok {
+		return "Expected an expression"
+	}
+
+	r := resolver.MSResolver{}
+	vlocals := r.ResolveExpression(stmt.Ex, ev.Scopes())
+
+	val, err := ev.EvaluateIn(stmt.Ex, vlocals)
+
+	if err != nil {
+		return err.Error()
+	}
+
+	return val.String()
+}
@@ -0,0 +1,165 @@
+package debugger
+
+import (
+	"bytes"
+	"errors"
+	"mikescript/src/mikescript"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const src = `function (int n) >> double -> int {
+    n * 2 => d;
+    return d;
+}
+0 => total;
+for [1 .. 4] .-> i {
+    i >>= double -> total;
+}
+total >>= print;`
+
+// Runs src in a debugger reading the commands, returns what the
+// debugger printed.
+func session(t *testing.T, commands ...string) string {
+
+	var out bytes.Buffer
+
+	in := mikescript.NewInterpreter()
+	in.SetStdout(&out)
+	in.SetDebugger(NewDebugger(src, strings.NewReader(strings.Join(commands, "\n")), &out))
+
+	if _, err := in.Eval(src) ; err != nil && !errors.Is(err, ErrQuit) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return out.String()
+}
+
+func cwd(t *testing.T) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDebugger(t *testing.T) {
+
+	tests := []struct {
+		name string
+		commands []string
+		expected []string
+	}{
+		{
+			name: "breakpoints",
+			commands: []string{"break 7", "continue", "continue", "clear 7", "continue"},
+			expected: []string{
+				"Paused at line 5\n>   5 | 0 => total;",
+				"Breakpoint at line 7",
+				"Paused at line 7\n>   7 |     i >>= double -> total;",
+				"Cleared line 7",
+				"6\n",
+			},
+		},
+		{
+			name: "invalid breakpoints",
+			commands: []string{"break x", "break 99", "break missing.ms:1", "quit"},
+			expected: []string{
+				"Invalid line 'x'",
+				"Invalid breakpoint, there is no line 99",
+				"Invalid breakpoint, there is no " + filepath.Join(cwd(t), "missing.ms") + ":1",
+			},
+		},
+		{
+			name: "stepping",
+			commands: []string{"step", "", "", "next", "out", "quit"},
+			expected: []string{
+				"Paused at line 6\n",
+				"Paused at line 7\n",
+				"Paused at line 2\n",
+				"Paused at line 3\n",
+				"Paused at line 7\n",
+			},
+		},
+		{
+			name: "watches",
+			commands: []string{"break 3", "watch d + 1", "continue", "print n", "calls", "continue", "quit"},
+			expected: []string{
+				"watch 0: d + 1 = 3",
+				"(debug) 1\n",
+				"    at double\n    at <program>",
+				"watch 0: d + 1 = 5",
+			},
+		},
+		{
+			name: "environment",
+			commands: []string{"break 3", "continue", "env", "quit"},
+			expected: []string{"| int                  | n                    | 1"},
+		},
+	}
+
+	for _, test := range tests {
+
+		received := session(t, test.commands...)
+
+		for _, expected := range test.expected {
+			if !strings.Contains(received, expected) {
+				t.Errorf("Session '%s' does not contain '%s'\nreceived:\n%s", test.name, expected, received)
+			}
+		}
+	}
+}
+
+// Breakpoints in a module stop in the module and not at the same
+// line of the program
+func TestDebuggerModules(t *testing.T) {
+
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.ms")
+	main := filepath.Join(dir, "main.ms")
+
+	files := map[string]string{
+		lib: "function (int n) >> twice -> int {\n    return 2 * n;\n}\n",
+		main: "import \"lib.ms\";\n1 >>= lib.twice => x;\nx >>= print;\n",
+	}
+
+	for path, src := range files {
+		if err := os.WriteFile(path, []byte(src), 0644) ; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+
+	commands := []string{"break " + lib + ":2", "continue", "print n", "list", "continue"}
+
+	in := mikescript.NewInterpreter()
+	in.SetStdout(&out)
+	in.SetDebugger(NewDebugger(files[main], strings.NewReader(strings.Join(commands, "\n")), &out))
+
+	if _, err := in.RunFile(main) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	received := out.String()
+
+	expected := []string{
+		"Breakpoint at " + lib + ":2",
+		"Paused at " + lib + ":2\n>   2 |     return 2 * n;",
+		"(debug) 1\n",
+		"    1 | function (int n) >> twice -> int {",
+		"2\n",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(received, e) {
+			t.Errorf("Session does not contain '%s'\nreceived:\n%s", e, received)
+		}
+	}
+
+	if strings.Count(received, "Paused at") != 2 {
+		t.Errorf("Expected to pause at the first line and the breakpoint\nreceived:\n%s", received)
+	}
+}
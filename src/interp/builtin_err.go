@@ -0,0 +1,86 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+)
+
+///////////////////////////////////////////////////////////////
+// mikescript builtins
+///////////////////////////////////////////////////////////////
+func MSBuiltinErr() MSVal {
+	return ErrFunction{args: []MSVal{}}
+}
+
+///////////////////////////////////////////////////////////////
+// Err function
+///////////////////////////////////////////////////////////////
+
+// Creates an error value: "kind", "message" >>= err
+type ErrFunction struct {
+	args []MSVal		// bound kind and message
+}
+
+var errParams = []mstype.MSType{mstype.MS_STRING, mstype.MS_STRING}
+
+// --------------------------------------------------------
+// Implements MSValue
+// --------------------------------------------------------
+
+func (ef ErrFunction) Type() mstype.MSType {
+	return &mstype.MSOperationTypeS{Left: errParams[len(ef.args):], Right: mstype.MS_ERROR}
+}
+
+func (ef ErrFunction) String() string {
+	return ">> err -> error"
+}
+
+func (ef ErrFunction) Nullable() bool {
+	return false
+}
+
+func (ef ErrFunction) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements FunctionResult
+// --------------------------------------------------------
+
+func (ef ErrFunction) Call(_evaluator *MSEvaluator) (MSVal, error) {
+
+	if ef.Arity() > 0 {
+		msg := fmt.Sprintf("Cannot call 'err', %v arguments are not bound", ef.Arity())
+		return nil, &EvalError{msg}
+	}
+
+	kind := ef.args[0].(MSString)
+	msg := ef.args[1].(MSString)
+
+	return MSError{Kind: kind.Val, Message: msg.Val}, nil
+}
+
+func (ef ErrFunction) Bind(args []MSVal) (MSVal, error) {
+
+	if len(args) > ef.Arity() {
+		msg := fmt.Sprintf("Exceeded arity of 'err' expected maximum %v arguments but received %v", ef.Arity(), len(args))
+		return nil, &BindingError{msg: msg}
+	}
+
+	for _, arg := range args {
+		if _, ok := arg.(MSString) ; !ok {
+			msg := fmt.Sprintf("Cannot bind '%s' of type '%s' to 'err', expected type 'string'", arg, arg.Type())
+			return nil, &BindingError{msg: msg}
+		}
+	}
+
+	bound := make([]MSVal, 0, len(ef.args) + len(args))
+	bound = append(bound, ef.args...)
+	bound = append(bound, args...)
+
+	return ErrFunction{args: bound}, nil
+}
+
+func (ef ErrFunction) Arity() int {
+	return len(errParams) - len(ef.args)
+}
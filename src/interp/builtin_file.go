@@ -0,0 +1,168 @@
+package interp
+
+import (
+	"errors"
+	"io/fs"
+	"mikescript/src/mstype"
+	"os"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////
+// mikescript builtins
+///////////////////////////////////////////////////////////////
+
+/*
+Builtins of the file system, paths are relative to the working
+directory of the process. Failures are thrown as errors, which
+'try' can catch:
+	- FileNotFoundError: the path does not exist
+	- PermissionError: the path can not be accessed
+	- OSError: any other failure
+*/
+
+var pathParam = []mstype.MSType{mstype.MS_STRING}
+var writeParams = []mstype.MSType{mstype.MS_STRING, mstype.MS_STRING}
+var stringsType = &mstype.MSArrayType{Type: mstype.MS_STRING}
+
+// "path" >>= read_file, the contents of the file
+func MSBuiltinReadFile() MSVal {
+	return newNativeFunction("read_file", pathParam, mstype.MS_STRING, readFile)
+}
+
+// "path" >>= read_lines, the lines of the file without line endings
+func MSBuiltinReadLines() MSVal {
+	return newNativeFunction("read_lines", pathParam, stringsType, readLines)
+}
+
+// "path", "contents" >>= write_file, replaces the file
+func MSBuiltinWriteFile() MSVal {
+	return newNativeFunction("write_file", writeParams, mstype.MS_NOTHING, writeFile)
+}
+
+// "path", "contents" >>= append_file, creates the file when needed
+func MSBuiltinAppendFile() MSVal {
+	return newNativeFunction("append_file", writeParams, mstype.MS_NOTHING, appendFile)
+}
+
+// "path" >>= list_dir, the names in the directory sorted
+func MSBuiltinListDir() MSVal {
+	return newNativeFunction("list_dir", pathParam, stringsType, listDir)
+}
+
+// "path" >>= exists
+func MSBuiltinExists() MSVal {
+	return newNativeFunction("exists", pathParam, mstype.MS_BOOL, exists)
+}
+
+// "path" >>= remove, removes a file or an empty directory
+func MSBuiltinRemove() MSVal {
+	return newNativeFunction("remove", pathParam, mstype.MS_NOTHING, remove)
+}
+
+func readFile(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	b, err := os.ReadFile(args[0].(MSString).Val)
+
+	if err != nil {
+		return nil, fileError(err)
+	}
+
+	return MSString{Val: string(b)}, nil
+}
+
+func readLines(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	b, err := os.ReadFile(args[0].(MSString).Val)
+
+	if err != nil {
+		return nil, fileError(err)
+	}
+
+	if len(b) == 0 {
+		return stringArray(nil), nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return stringArray(lines), nil
+}
+
+func writeFile(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	if err := os.WriteFile(args[0].(MSString).Val, []byte(args[1].(MSString).Val), 0644) ; err != nil {
+		return nil, fileError(err)
+	}
+
+	return MSNothing{}, nil
+}
+
+func appendFile(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	file, err := os.OpenFile(args[0].(MSString).Val, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return nil, fileError(err)
+	}
+
+	_, err = file.WriteString(args[1].(MSString).Val)
+
+	// Writes can fail when closing the file
+	if cerr := file.Close() ; err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return nil, fileError(err)
+	}
+
+	return MSNothing{}, nil
+}
+
+func listDir(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	entries, err := os.ReadDir(args[0].(MSString).Val)
+
+	if err != nil {
+		return nil, fileError(err)
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+
+	return stringArray(names), nil
+}
+
+func exists(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	_, err := os.Stat(args[0].(MSString).Val)
+
+	switch {
+	case err == nil:						return MSBool{Val: true}, nil
+	case errors.Is(err, fs.ErrNotExist):	return MSBool{Val: false}, nil
+	default:								return nil, fileError(err)
+	}
+}
+
+func remove(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	if err := os.Remove(args[0].(MSString).Val) ; err != nil {
+		return nil, fileError(err)
+	}
+
+	return MSNothing{}, nil
+}
+
+// Error thrown for a failing file operation, the kind tells why
+func fileError(err error) *ThrownError {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):	return throwError(NotFoundErrorKind, err.Error())
+	case errors.Is(err, fs.ErrPermission):	return throwError(PermissionErrorKind, err.Error())
+	default:								return throwError(OSErrorKind, err.Error())
+	}
+}
@@ -0,0 +1,228 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+	"reflect"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////
+// Go function
+///////////////////////////////////////////////////////////////
+
+/*
+Wraps an ordinary Go function so it can be called from MikeScript.
+Parameters and results are converted using 'FromMSVal' and 'ToMSVal':
+	- no results return nothing
+	- one result is returned as is
+	- more results are returned as a tuple
+A trailing 'error' result is not returned, a non nil error is thrown
+as an 'Error' which programs can catch. So is a panic of the function.
+*/
+
+type GoFunction struct {
+	name string
+	fn reflect.Value
+	params []mstype.MSType
+	rtype mstype.MSType
+	results []reflect.Type		// results, without the trailing error
+	fails bool					// has a trailing error result
+	args []MSVal				// bound arguments
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func NewGoFunction(name string, fn any) (MSVal, error) {
+
+	v := reflect.ValueOf(fn)
+
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, &EvalError{fmt.Sprintf("Cannot use '%T' as function '%s', expected a Go func", fn, name)}
+	}
+
+	t := v.Type()
+
+	if t.IsVariadic() {
+		return nil, &EvalError{fmt.Sprintf("Cannot use variadic Go func as function '%s'", name)}
+	}
+
+	params := make([]mstype.MSType, t.NumIn())
+	for i := range params {
+
+		pt, err := GoType(t.In(i))
+
+		if err != nil {
+			return nil, err
+		}
+
+		params[i] = pt
+	}
+
+	results := []reflect.Type{}
+	for i := 0 ; i < t.NumOut() ; i++ {
+		results = append(results, t.Out(i))
+	}
+
+	fails := len(results) > 0 && results[len(results)-1] == errorType
+	if fails {
+		results = results[:len(results)-1]
+	}
+
+	rtypes := make([]mstype.MSType, len(results))
+	for i, r := range results {
+
+		rt, err := GoType(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rtypes[i] = rt
+	}
+
+	var rtype mstype.MSType
+	switch len(rtypes) {
+	case 0:		rtype = mstype.MS_NOTHING
+	case 1:		rtype = rtypes[0]
+	default:	rtype = &mstype.MSCompositeTypeS{Types: rtypes}
+	}
+
+	return GoFunction{
+		name: name,
+		fn: v,
+		params: params,
+		rtype: rtype,
+		results: results,
+		fails: fails,
+		args: []MSVal{},
+	}, nil
+}
+
+// --------------------------------------------------------
+// Implements MSValue
+// --------------------------------------------------------
+
+func (gf GoFunction) Type() mstype.MSType {
+	return &mstype.MSOperationTypeS{Left: gf.params[len(gf.args):], Right: gf.rtype}
+}
+
+func (gf GoFunction) String() string {
+
+	fs := fmt.Sprintf(">> %s -> %s", gf.name, gf.rtype)
+
+	if len(gf.args) == 0 {
+		return fs
+	}
+
+	strs := make([]string, len(gf.args))
+	for i, arg := range gf.args {
+		strs[i] = arg.String()
+	}
+
+	return fmt.Sprintf("%s %s", strings.Join(strs, ", "), fs)
+}
+
+func (gf GoFunction) Nullable() bool {
+	return false
+}
+
+func (gf GoFunction) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements FunctionResult
+// --------------------------------------------------------
+
+func (gf GoFunction) Call(_evaluator *MSEvaluator) (MSVal, error) {
+
+	if gf.Arity() > 0 {
+		msg := fmt.Sprintf("Cannot call '%s', %v arguments are not bound", gf, gf.Arity())
+		return nil, &EvalError{msg}
+	}
+
+	in := make([]reflect.Value, len(gf.args))
+	for i, arg := range gf.args {
+
+		v, err := FromMSVal(arg, gf.fn.Type().In(i))
+
+		if err != nil {
+			return nil, err
+		}
+
+		in[i] = v
+	}
+
+	out, err := gf.call(in)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if gf.fails {
+
+		if err, _ := out[len(out)-1].Interface().(error) ; err != nil {
+			return nil, throwError(ErrorKind, fmt.Sprintf("%s: %v", gf.name, err))
+		}
+
+		out = out[:len(out)-1]
+	}
+
+	vals := make([]MSVal, len(out))
+	for i, o := range out {
+
+		v, err := ToMSVal(o.Interface())
+
+		if err != nil {
+			return nil, err
+		}
+
+		vals[i] = v
+	}
+
+	switch len(vals) {
+	case 0:		return MSNothing{}, nil
+	case 1:		return vals[0], nil
+	default:	return MSTuple{Values: vals}, nil
+	}
+}
+
+// Calls the Go function, a panic does not end the host program
+func (gf GoFunction) call(in []reflect.Value) (out []reflect.Value, err error) {
+
+	defer func() {
+		if r := recover() ; r != nil {
+			err = throwError(ErrorKind, fmt.Sprintf("%s panicked: %v", gf.name, r))
+		}
+	}()
+
+	return gf.fn.Call(in), nil
+}
+
+func (gf GoFunction) Bind(args []MSVal) (MSVal, error) {
+
+	if len(args) > gf.Arity() {
+		msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", gf, gf.Arity(), len(args))
+		return nil, &BindingError{msg: msg}
+	}
+
+	for i, arg := range args {
+		if param := gf.params[len(gf.args) + i] ; !arg.Type().Eq(param) {
+			msg := fmt.Sprintf("Cannot bind '%s' of type '%s' to parameter %v of '%s' of type '%s'", arg, arg.Type(), len(gf.args) + i, gf.name, param)
+			return nil, &BindingError{msg: msg}
+		}
+	}
+
+	bound := make([]MSVal, 0, len(gf.args) + len(args))
+	bound = append(bound, gf.args...)
+	bound = append(bound, args...)
+
+	gf.args = bound
+
+	return gf, nil
+}
+
+func (gf GoFunction) Arity() int {
+	return len(gf.params) - len(gf.args)
+}
@@ -0,0 +1,134 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////
+// mikescript builtins
+///////////////////////////////////////////////////////////////
+
+// m, key >>= has, whether key is in the map
+func MSBuiltinHas() MSVal {
+	return MapFunction{name: "has", rtype: mstype.MS_BOOL, call: hasKey}
+}
+
+// m, key >>= delete, removes key from the map when it is in it
+func MSBuiltinDelete() MSVal {
+	return MapFunction{name: "delete", rtype: mstype.MS_NOTHING, call: deleteKey}
+}
+
+func hasKey(m MSMap, key MSVal) (MSVal, error) {
+	ok, err := m.Has(key)
+	return MSBool{Val: ok}, err
+}
+
+func deleteKey(m MSMap, key MSVal) (MSVal, error) {
+	return MSNothing{}, m.Delete(key)
+}
+
+///////////////////////////////////////////////////////////////
+// Map function
+///////////////////////////////////////////////////////////////
+
+// Builtin taking a map of any type and a key of the map. Like 'len'
+// it can't be described using an operation type, the map bound
+// first gives the type of the key.
+type MapFunction struct {
+	name string
+	rtype mstype.MSType
+	call func(m MSMap, key MSVal) (MSVal, error)
+	args []MSVal				// bound arguments, the map first
+}
+
+// --------------------------------------------------------
+// Implements MSValue
+// --------------------------------------------------------
+
+func (mf MapFunction) Type() mstype.MSType {
+
+	left := []mstype.MSType{}
+	if len(mf.args) == 1 {
+		left = append(left, mf.args[0].(MSMap).KType)
+	}
+
+	return &mstype.MSOperationTypeS{Left: left, Right: mf.rtype}
+}
+
+func (mf MapFunction) String() string {
+
+	rt := mf.rtype.String()
+	if rt == "" {
+		rt = "nothing"
+	}
+
+	fs := fmt.Sprintf(">> %s -> %s", mf.name, rt)
+
+	if len(mf.args) == 0 {
+		return fs
+	}
+
+	strs := make([]string, len(mf.args))
+	for i, arg := range mf.args {
+		strs[i] = arg.String()
+	}
+
+	return fmt.Sprintf("%s %s", strings.Join(strs, ", "), fs)
+}
+
+func (mf MapFunction) Nullable() bool {
+	return false
+}
+
+func (mf MapFunction) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements FunctionResult
+// --------------------------------------------------------
+
+func (mf MapFunction) Call(_evaluator *MSEvaluator) (MSVal, error) {
+
+	if mf.Arity() > 0 {
+		msg := fmt.Sprintf("Cannot call '%s', %v arguments are not bound", mf.name, mf.Arity())
+		return nil, &EvalError{msg}
+	}
+
+	return mf.call(mf.args[0].(MSMap), mf.args[1])
+}
+
+func (mf MapFunction) Bind(args []MSVal) (MSVal, error) {
+
+	if len(args) > mf.Arity() {
+		msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", mf.name, mf.Arity(), len(args))
+		return nil, &BindingError{msg: msg}
+	}
+
+	bound := make([]MSVal, 0, len(mf.args) + len(args))
+	bound = append(bound, mf.args...)
+	bound = append(bound, args...)
+
+	m, ok := bound[0].(MSMap)
+
+	if !ok {
+		msg := fmt.Sprintf("'%s' expected argument of map type, got '%s'", mf.name, bound[0].Type())
+		return nil, &BindingError{msg: msg}
+	}
+
+	if len(bound) > 1 {
+		if err := m.ValidIndex(bound[1]) ; err != nil {
+			return nil, err
+		}
+	}
+
+	mf.args = bound
+
+	return mf, nil
+}
+
+func (mf MapFunction) Arity() int {
+	return 2 - len(mf.args)
+}
@@ -0,0 +1,108 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////
+// Native function
+///////////////////////////////////////////////////////////////
+
+/*
+Builtin with a fixed list of parameters, implemented in Go on
+MikeScript values. Arguments are bound like those of 'err' and
+the function runs when all of them are bound. Used by the builtins
+of 'builtin_os.go' and 'builtin_file.go'.
+*/
+
+type NativeFunction struct {
+	name string
+	params []mstype.MSType
+	rtype mstype.MSType
+	call func(ev *MSEvaluator, args []MSVal) (MSVal, error)
+	args []MSVal				// bound arguments
+}
+
+func newNativeFunction(name string, params []mstype.MSType, rtype mstype.MSType, call func(*MSEvaluator, []MSVal) (MSVal, error)) NativeFunction {
+	return NativeFunction{name: name, params: params, rtype: rtype, call: call, args: []MSVal{}}
+}
+
+// --------------------------------------------------------
+// Implements MSValue
+// --------------------------------------------------------
+
+func (nf NativeFunction) Type() mstype.MSType {
+	return &mstype.MSOperationTypeS{Left: nf.params[len(nf.args):], Right: nf.rtype}
+}
+
+func (nf NativeFunction) String() string {
+
+	rt := nf.rtype.String()
+	if rt == "" {
+		rt = "nothing"
+	}
+
+	fs := fmt.Sprintf(">> %s -> %s", nf.name, rt)
+
+	if len(nf.args) == 0 {
+		return fs
+	}
+
+	strs := make([]string, len(nf.args))
+	for i, arg := range nf.args {
+		strs[i] = arg.String()
+	}
+
+	return fmt.Sprintf("%s %s", strings.Join(strs, ", "), fs)
+}
+
+func (nf NativeFunction) Nullable() bool {
+	return false
+}
+
+func (nf NativeFunction) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements FunctionResult
+// --------------------------------------------------------
+
+func (nf NativeFunction) Call(evaluator *MSEvaluator) (MSVal, error) {
+
+	if nf.Arity() > 0 {
+		msg := fmt.Sprintf("Cannot call '%s', %v arguments are not bound", nf.name, nf.Arity())
+		return nil, &EvalError{msg}
+	}
+
+	return nf.call(evaluator, nf.args)
+}
+
+func (nf NativeFunction) Bind(args []MSVal) (MSVal, error) {
+
+	if len(args) > nf.Arity() {
+		msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", nf.name, nf.Arity(), len(args))
+		return nil, &BindingError{msg: msg}
+	}
+
+	for i, arg := range args {
+		if param := nf.params[len(nf.args) + i] ; !arg.Type().Eq(param) {
+			msg := fmt.Sprintf("Cannot bind '%s' of type '%s' to parameter %v of '%s' of type '%s'", arg, arg.Type(), len(nf.args) + i, nf.name, param)
+			return nil, &BindingError{msg: msg}
+		}
+	}
+
+	bound := make([]MSVal, 0, len(nf.args) + len(args))
+	bound = append(bound, nf.args...)
+	bound = append(bound, args...)
+
+	nf.args = bound
+
+	return nf, nil
+}
+
+func (nf NativeFunction) Arity() int {
+	return len(nf.params) - len(nf.args)
+}
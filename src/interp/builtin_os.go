@@ -0,0 +1,107 @@
+package interp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mikescript/src/mstype"
+	"os"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////
+// mikescript builtins
+///////////////////////////////////////////////////////////////
+
+// Arguments of the program, '[]string'
+func MSBuiltinArgs(args []string) MSVal {
+	return stringArray(args)
+}
+
+// "name" >>= getenv, the empty string when the variable is not set
+func MSBuiltinGetenv() MSVal {
+	return newNativeFunction("getenv", []mstype.MSType{mstype.MS_STRING}, mstype.MS_STRING, getenv)
+}
+
+// "name", "value" >>= setenv
+func MSBuiltinSetenv() MSVal {
+	return newNativeFunction("setenv", []mstype.MSType{mstype.MS_STRING, mstype.MS_STRING}, mstype.MS_NOTHING, setenv)
+}
+
+// =input, the next line of the standard input without its line
+// ending. Throws an 'EOFError' when there are no more lines.
+func MSBuiltinInput() MSVal {
+	return newNativeFunction("input", []mstype.MSType{}, mstype.MS_STRING, input)
+}
+
+// 1 >>= exit, stops the program with the exit status
+func MSBuiltinExit() MSVal {
+	return newNativeFunction("exit", []mstype.MSType{mstype.MS_INT}, mstype.MS_NOTHING, exit)
+}
+
+func getenv(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+	return MSString{Val: os.Getenv(args[0].(MSString).Val)}, nil
+}
+
+func setenv(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	if err := os.Setenv(args[0].(MSString).Val, args[1].(MSString).Val) ; err != nil {
+		return nil, throwError(OSErrorKind, err.Error())
+	}
+
+	return MSNothing{}, nil
+}
+
+func input(evaluator *MSEvaluator, _args []MSVal) (MSVal, error) {
+
+	line, err := evaluator.stdinReader().ReadString('\n')
+
+	if err == io.EOF && line == "" {
+		return nil, throwError(EOFErrorKind, "no more input")
+	}
+
+	if err != nil && err != io.EOF {
+		return nil, throwError(OSErrorKind, err.Error())
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	return MSString{Val: line}, nil
+}
+
+// Reads Stdin, the buffered input is kept between the calls of
+// 'input' until Stdin is replaced.
+func (evaluator *MSEvaluator) stdinReader() *bufio.Reader {
+
+	if evaluator.stdin == nil || evaluator.stdinOf != evaluator.Stdin {
+		evaluator.stdin = bufio.NewReader(evaluator.Stdin)
+		evaluator.stdinOf = evaluator.Stdin
+	}
+
+	return evaluator.stdin
+}
+
+func stringArray(strs []string) MSArray {
+
+	vals := make([]MSVal, len(strs))
+	for i, s := range strs {
+		vals[i] = MSString{Val: s}
+	}
+
+	return MSArray{Values: vals, VType: mstype.MS_STRING}
+}
+
+// Stops the evaluation like a limit does, only 'finally' blocks
+// run on the way out. Exit statuses are a byte, larger codes would
+// be truncated by the operating system.
+func exit(_evaluator *MSEvaluator, args []MSVal) (MSVal, error) {
+
+	code := args[0].(MSInt).Val
+
+	if code < 0 || code > 255 {
+		return nil, throwError(ValueErrorKind, fmt.Sprintf("exit code %d is not between 0 and 255", code))
+	}
+
+	return nil, &ExitError{Code: code}
+}
@@ -62,14 +62,14 @@ func (i PrintFunction) NullVal() MSVal {
 // Implements FunctionResult
 // --------------------------------------------------------
 
-func (pf PrintFunction) Call(_evaluator *MSEvaluator) (MSVal, error) {
+func (pf PrintFunction) Call(evaluator *MSEvaluator) (MSVal, error) {
 
 	strs := make([]string, len(pf.args))
 	for i, arg := range pf.args {
 		strs[i] = arg.String()
 	}
 
-	fmt.Println(strings.Join(strs, ", "))
+	fmt.Fprintln(evaluator.stdout(), strings.Join(strs, ", "))
 
 	return MSNothing{}, nil
 }
@@ -49,7 +49,7 @@ func (i PrintEnvFunction) NullVal() MSVal {
 // --------------------------------------------------------
 
 func (pf PrintEnvFunction) Call(_evaluator *MSEvaluator) (MSVal, error) {
-	_evaluator.env.printEnv()
+	_evaluator.PrintEnv()
 	return MSNothing{}, nil
 }
 
@@ -0,0 +1,211 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+	"reflect"
+)
+
+// Conversion between Go values and MikeScript values, used by
+// functions and globals defined in Go. Supported are the Go numbers,
+// strings and bools, slices and arrays of those, which are MikeScript
+// arrays, and structs of those, which are tuples of the struct fields
+// in order. Values which already are a MSVal are used as is.
+
+// MikeScript type of values of Go type t
+func GoType(t reflect.Type) (mstype.MSType, error) {
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return mstype.MS_INT, nil
+	case reflect.Float32, reflect.Float64:
+		return mstype.MS_FLOAT, nil
+	case reflect.String:
+		return mstype.MS_STRING, nil
+	case reflect.Bool:
+		return mstype.MS_BOOL, nil
+	case reflect.Slice, reflect.Array:
+
+		elem, err := GoType(t.Elem())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &mstype.MSArrayType{Type: elem}, nil
+
+	case reflect.Struct:
+
+		if t.NumField() == 0 {
+			break
+		}
+
+		types := make([]mstype.MSType, t.NumField())
+		for i := range types {
+
+			// Unexported fields could not be set from a tuple
+			if !t.Field(i).IsExported() {
+				return nil, &EvalError{fmt.Sprintf("Go type '%s' has unexported field '%s'", t, t.Field(i).Name)}
+			}
+
+			ft, err := GoType(t.Field(i).Type)
+
+			if err != nil {
+				return nil, err
+			}
+
+			types[i] = ft
+		}
+
+		return &mstype.MSCompositeTypeS{Types: types}, nil
+	}
+
+	return nil, &EvalError{fmt.Sprintf("Go type '%s' has no MikeScript counterpart", t)}
+}
+
+// Converts a Go value to a MikeScript value
+func ToMSVal(v any) (MSVal, error) {
+
+	if val, ok := v.(MSVal) ; ok {
+		return val, nil
+	}
+
+	if v == nil {
+		return MSNothing{}, nil
+	}
+
+	return toMSVal(reflect.ValueOf(v))
+}
+
+func toMSVal(v reflect.Value) (MSVal, error) {
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return MSInt{Val: int(v.Int())}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return MSInt{Val: int(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return MSFloat{Val: v.Float()}, nil
+	case reflect.String:
+		return MSString{Val: v.String()}, nil
+	case reflect.Bool:
+		return MSBool{Val: v.Bool()}, nil
+	case reflect.Slice, reflect.Array:
+
+		elem, err := GoType(v.Type().Elem())
+
+		if err != nil {
+			return nil, err
+		}
+
+		vals := make([]MSVal, v.Len())
+		for i := range vals {
+			if vals[i], err = toMSVal(v.Index(i)) ; err != nil {
+				return nil, err
+			}
+		}
+
+		return MSArray{Values: vals, VType: elem}, nil
+
+	case reflect.Struct:
+
+		if _, err := GoType(v.Type()) ; err != nil {
+			return nil, err
+		}
+
+		vals := make([]MSVal, v.NumField())
+		for i := range vals {
+
+			val, err := toMSVal(v.Field(i))
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[i] = val
+		}
+
+		return MSTuple{Values: vals}, nil
+	}
+
+	return nil, &EvalError{fmt.Sprintf("Go value '%v' of type '%s' has no MikeScript counterpart", v, v.Type())}
+}
+
+// Converts a MikeScript value to a Go value of type t
+func FromMSVal(val MSVal, t reflect.Type) (reflect.Value, error) {
+
+	mismatch := func() (reflect.Value, error) {
+		msg := fmt.Sprintf("Cannot convert '%s' of type '%s' to Go type '%s'", val, val.Type(), t)
+		return reflect.Value{}, &EvalError{msg}
+	}
+
+	switch v := val.(type) {
+	case MSInt:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.ValueOf(v.Val).Convert(t), nil
+		}
+	case MSFloat:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return reflect.ValueOf(v.Val).Convert(t), nil
+		}
+	case MSString:
+		if t.Kind() == reflect.String {
+			return reflect.ValueOf(v.Val).Convert(t), nil
+		}
+	case MSBool:
+		if t.Kind() == reflect.Bool {
+			return reflect.ValueOf(v.Val).Convert(t), nil
+		}
+	case MSArray:
+
+		var arr reflect.Value
+
+		switch {
+		case t.Kind() == reflect.Slice:
+			arr = reflect.MakeSlice(t, len(v.Values), len(v.Values))
+		case t.Kind() == reflect.Array && t.Len() == len(v.Values):
+			arr = reflect.New(t).Elem()
+		default:
+			return mismatch()
+		}
+
+		for i, elem := range v.Values {
+
+			e, err := FromMSVal(elem, t.Elem())
+
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			arr.Index(i).Set(e)
+		}
+
+		return arr, nil
+
+	case MSTuple:
+
+		if _, err := GoType(t) ; err != nil || t.Kind() != reflect.Struct || t.NumField() != len(v.Values) {
+			return mismatch()
+		}
+
+		st := reflect.New(t).Elem()
+		for i, elem := range v.Values {
+
+			f, err := FromMSVal(elem, t.Field(i).Type)
+
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			st.Field(i).Set(f)
+		}
+
+		return st, nil
+	}
+
+	return mismatch()
+}
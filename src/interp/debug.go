@@ -0,0 +1,82 @@
+package interp
+
+import (
+	"io"
+	"mikescript/src/ast"
+	"slices"
+)
+
+/*
+Hook for debuggers, 'Before' is called before the evaluator executes
+a statement. The evaluator is paused while it runs, so the debugger
+can look at the state of the program using:
+	- File: the module of the statement
+	- Depth and Calls: the functions being called
+	- WriteEnv: the variables in the scope of the statement
+	- Scopes and EvaluateIn: evaluate expressions in that scope
+An error returned by 'Before' stops the evaluation with that error.
+*/
+
+type Debugger interface {
+	Before(ev *MSEvaluator, node ast.StmtNodeI) error
+}
+
+// Path of the module whose code is executed, "" for the program.
+// Functions run in the module declaring them.
+func (evaluator *MSEvaluator) File() string {
+	return evaluator.env.global.file
+}
+
+// Number of function calls in progress
+func (evaluator *MSEvaluator) Depth() int {
+	return len(evaluator.calls)
+}
+
+// Names of the functions being called, the innermost call last
+func (evaluator *MSEvaluator) Calls() []string {
+	return slices.Clone(evaluator.calls)
+}
+
+// Prints the environment chain of the current scope, see 'env'
+func (evaluator *MSEvaluator) WriteEnv(w io.Writer) {
+	evaluator.env.printEnv(w)
+}
+
+// Names of the variables in the current scope and the scopes it is
+// nested in, outermost first. The global scope is left out, like
+// 'resolver.MSResolver' does.
+func (evaluator *MSEvaluator) Scopes() [][]string {
+
+	scopes := [][]string{}
+
+	for env := evaluator.env ; env != nil && env != env.global ; env = env.enclosing {
+
+		names := make([]string, 0, len(env.variables))
+		for name := range env.variables {
+			names = append(names, name)
+		}
+
+		scopes = append([][]string{names}, scopes...)
+	}
+
+	return scopes
+}
+
+// Evaluates exp in the current scope, vlocals are the depths of its
+// variables as resolved against 'Scopes'. Functions called by exp
+// run without the debugger.
+func (evaluator *MSEvaluator) EvaluateIn(exp ast.ExpNodeI, vlocals map[*ast.VariableExpNodeS]int) (MSVal, error) {
+
+	evaluator.UpdateVLocals(vlocals)
+	debugger := evaluator.Debugger
+	evaluator.Debugger = nil
+
+	defer func() {
+		for v := range vlocals {
+			delete(evaluator.vlocals, v)
+		}
+		evaluator.Debugger = debugger
+	}()
+
+	return evaluator.evaluateExpression(exp)
+}
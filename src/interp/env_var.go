@@ -2,7 +2,9 @@ package interp
 
 import (
 	"fmt"
+	"io"
 	"mikescript/src/mstype"
+	"slices"
 	"strings"
 )
 
@@ -29,14 +31,24 @@ type Environment struct {
 	variables map[string]MSVal
 	types map[string]mstype.MSType
 	enclosing *Environment
+	global *Environment		// outermost environment, every module has its own
+	file string				// path of the module of a global environment, "" for the program
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
-	return &Environment{
+
+	env := &Environment{
 		variables: make(map[string]MSVal),
 		types: make(map[string]mstype.MSType),
 		enclosing: enclosing,
 	}
+
+	env.global = env
+	if enclosing != nil {
+		env.global = enclosing.global
+	}
+
+	return env
 }
 
 ////////////////////////////////////////
@@ -83,6 +95,8 @@ func (env *Environment) SetVar(name string, value MSVal, depth int) error {
 		return varNotFound(name)
 	}
 
+	value = toInterface(targetEnv.variables[name].Type(), value)
+
 	if err := targetEnv.compatibleType(name, value) ; err != nil {
 		return err
 	}
@@ -104,27 +118,48 @@ func rowRepr(name string, value MSVal) string {
 	return fmt.Sprintf("| %-*v | %-*v | %-*v |", typecol_size, c1, namecol_size, c2, defcol_size, c3)
 }
 
-func (env *Environment) printEnv() int {
+// Prints variables like 'env' does, one table per scope with the
+// outermost first. Used by the bytecode vm, which has no environments.
+func WriteScopes(w io.Writer, scopes []map[string]MSVal) {
+
+	var env *Environment
+	for _, vars := range scopes {
+		env = NewEnvironment(env)
+		for name, val := range vars {
+			env.variables[name] = val
+		}
+	}
+
+	env.printEnv(w)
+}
+
+func (env *Environment) printEnv(w io.Writer) int {
 
 	if env == nil {
 		return 0
 	}
 
 	// print enclosing scope first
-	depth := env.enclosing.printEnv()
+	depth := env.enclosing.printEnv(w)
+
+	names := make([]string, 0, len(env.variables))
+	for name := range env.variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
 
 	rows := []string{}
-	for name, value := range env.variables {
-		rows = append(rows, rowRepr(name, value))
+	for _, name := range names {
+		rows = append(rows, rowRepr(name, env.variables[name]))
 	}
 
 	if depth == 0 {
-		fmt.Println(tblbar(depth))
+		fmt.Fprintln(w, tblbar(depth))
 	}
 	if len(rows) > 0 {
-		fmt.Println(strings.Join(rows, "\n"))
+		fmt.Fprintln(w, strings.Join(rows, "\n"))
 	}
-	fmt.Println(tblbar(depth + 1))
+	fmt.Fprintln(w, tblbar(depth + 1))
 	
 	return depth + 1
 }
@@ -194,6 +229,17 @@ func (env *Environment) GetType(name string, depth int) (mstype.MSType, error) {
 	return t, nil
 }
 
+func (env *Environment) LookupType(name string) (mstype.MSType, bool) {
+	// Finds a type by name in the enclosing scopes, used for type
+	// parameters which are declared when calling a generic function.
+	for target := env ; target != nil ; target = target.enclosing {
+		if t, ok := target.types[name] ; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
 func (env *Environment) NewType(name string, t mstype.MSType) error {
 
 	if typ, ok := env.types[name] ; ok {
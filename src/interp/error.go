@@ -1,5 +1,12 @@
 package interp
 
+import (
+	"fmt"
+	"mikescript/src/token"
+	"strings"
+	"time"
+)
+
 // Eval
 type EvalError struct {
 	message string
@@ -18,3 +25,188 @@ type EnvironmentError struct {
 func (e *EnvironmentError) Error() string {
 	return "Environment Error: " + e.message
 }
+
+
+// Error value on its way to a 'try', thrown using 'throw' or by
+// a failing operation. Other errors can not be caught.
+type ThrownError struct {
+	Val MSError
+}
+
+func (e *ThrownError) Error() string {
+	return "Uncaught " + e.Val.String()
+}
+
+func throwError(kind, msg string) *ThrownError {
+	return &ThrownError{MSError{Kind: kind, Message: msg}}
+}
+
+
+// Runtime error located in the source. Wraps the error of the node
+// that failed with its position and the calls that led there.
+type RuntimeError struct {
+	Err error
+	Tk token.Token			// innermost node with a position
+	Trace []TraceFrame		// innermost call first, the program last
+}
+
+// Function a runtime error passed through and where it was in that
+// function. The name is empty for the program itself.
+type TraceFrame struct {
+	Name string
+	Tk token.Token
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%v at line %v col %v", e.Err, e.Tk.Line, e.Tk.Col)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Error with the failing line of src, a caret under the failing
+// node and the call stack.
+func (e *RuntimeError) Report(src string) string {
+
+	lines := []string{e.Error()}
+
+	if snippet := sourceSnippet(src, e.Tk) ; snippet != "" {
+		lines = append(lines, snippet)
+	}
+
+	if len(e.Trace) > 1 {
+		lines = append(lines, "Call stack:")
+		for i, f := range e.Trace {
+
+			// Deep recursion only shows both ends of the stack
+			if skipped := len(e.Trace) - 2 * maxTraceEnds ; skipped > 0 && i >= maxTraceEnds && i < len(e.Trace) - maxTraceEnds {
+				if i == maxTraceEnds {
+					lines = append(lines, fmt.Sprintf("    ... %v more calls", skipped))
+				}
+				continue
+			}
+
+			lines = append(lines, "    at " + f.String())
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Number of calls shown at either end of long call stacks
+const maxTraceEnds = 10
+
+func (f TraceFrame) String() string {
+
+	name := f.Name
+	switch {
+	case f.Name == "" && f.Tk.Line == 0:	return "<program>"
+	case f.Name == "":						name = "<program>"
+	}
+
+	if f.Tk.Line == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s (line %v col %v)", name, f.Tk.Line, f.Tk.Col)
+}
+
+// Records where the error passes through the frame at the given
+// call depth. Only the innermost node of each frame is kept.
+func (e *RuntimeError) locate(depth int, tk token.Token) {
+
+	i := len(e.Trace) - 1 - depth
+
+	if i < 0 || tk.Line == 0 || e.Trace[i].Tk.Line != 0 {
+		return
+	}
+
+	e.Trace[i].Tk = tk
+}
+
+// Line of src holding tk with carets under it. Empty when the line
+// does not hold tk, e.g. for nodes of an imported module.
+func sourceSnippet(src string, tk token.Token) string {
+
+	lines := strings.Split(src, "\n")
+
+	if tk.Line < 1 || tk.Line > len(lines) || tk.Lexeme == "" {
+		return ""
+	}
+
+	line := lines[tk.Line-1]
+
+	// Columns point just past the token
+	end := tk.Col - 1
+	start := end - len(tk.Lexeme)
+
+	if start < 0 || end > len(line) || line[start:end] != tk.Lexeme {
+		return ""
+	}
+
+	prefix := fmt.Sprintf("%5d | ", tk.Line)
+	margin := strings.Repeat(" ", len(prefix) - 2) + "| "
+
+	// Tabs are kept so the carets line up with the source
+	indent := []rune{}
+	for _, r := range line[:start] {
+		if r == '\t' {
+			indent = append(indent, '\t')
+		} else {
+			indent = append(indent, ' ')
+		}
+	}
+
+	return prefix + line + "\n" + margin + string(indent) + strings.Repeat("^", len(tk.Lexeme))
+}
+
+
+// The program called 'exit', not an error of the program. Hosts
+// use the code as the exit status of the process.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("Exit with status %v", e.Code)
+}
+
+
+// Execution limits of the evaluator, see 'limits.go'
+type MaxDepthError struct {
+	depth int
+}
+
+func (e *MaxDepthError) Error() string {
+	return fmt.Sprintf("Limit error: exceeded the maximum call depth of %v", e.depth)
+}
+
+type StepLimitError struct {
+	steps int
+}
+
+func (e *StepLimitError) Error() string {
+	return fmt.Sprintf("Limit error: exceeded the budget of %v statements", e.steps)
+}
+
+type TimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("Limit error: exceeded the timeout of %v", e.timeout)
+}
+
+// The context of the evaluation is done
+type CanceledError struct {
+	err error
+}
+
+func (e *CanceledError) Error() string {
+	return "Canceled: " + e.err.Error()
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.err
+}
@@ -1,8 +1,15 @@
 package interp
 
 import (
+	"bufio"
+	"context"
+	"io"
 	"mikescript/src/ast"
 	"mikescript/src/mstype"
+	"mikescript/src/resolver"
+	"mikescript/src/token"
+	"os"
+	"time"
 )
 
 ////////////////////////////////////////////////////////////////////////
@@ -16,25 +23,88 @@ type MSEvaluator struct {
 	glb *Environment 				// Fixed reference to global scope (outermost env)
 	vlocals map[*ast.VariableExpNodeS]int	// How deep do we need to go to resolve variables?
 	tlocals map[*mstype.MSNamedTypeS]int 	// How deep do we need to go to resolve types?
+	instances map[instanceKey]*mstype.MSStructTypeS	// instantiated generic structs
+	structEnvs map[*mstype.MSStructTypeS]*Environment	// env a struct is declared in
+	methods map[*mstype.MSStructTypeS]map[string]MSVal	// methods of the structs
+	modules *resolver.MSModuleLoader		// loads the modules used in 'import'
+	namespaces map[string]*MSNamespace		// evaluated modules by path
+	calls []string							// names of the functions being called
+	args []string							// arguments of the program, see 'SetArgs'
+	Stdout io.Writer						// used by 'print' and 'env'
+	Stderr io.Writer
+	Stdin io.Reader							// used by 'input'
+	stdin *bufio.Reader						// buffers stdinOf, see 'stdinReader'
+	stdinOf io.Reader
+	MaxDepth int							// see 'limits.go'
+	MaxSteps int
+	Timeout time.Duration
+	ctx context.Context						// set by the host
+	running context.Context					// ctx and the timeout of the running evaluation
+	done <-chan struct{}					// closed when the evaluation must stop
+	steps int								// statements executed
+	Debugger Debugger						// see 'debug.go'
+	Profiler Profiler						// see 'profile.go'
 }
 
 func NewMSEvaluator() *MSEvaluator {
 
-	env := NewEnvironment(nil)
-	glb := env
+	glb := newGlobalEnvironment(nil)
+
+	return &MSEvaluator{
+		env: glb,
+		glb: glb,
+		vlocals: make(map[*ast.VariableExpNodeS]int),
+		tlocals: make(map[*mstype.MSNamedTypeS]int),
+		instances: make(map[instanceKey]*mstype.MSStructTypeS),
+		structEnvs: make(map[*mstype.MSStructTypeS]*Environment),
+		methods: make(map[*mstype.MSStructTypeS]map[string]MSVal),
+		namespaces: make(map[string]*MSNamespace),
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin: os.Stdin,
+		MaxDepth: DefaultMaxDepth,
+	}
+}
+
+func newGlobalEnvironment(args []string) *Environment {
+
+	glb := NewEnvironment(nil)
 
 	// Add builtins to glb
 	glb.NewVar("print", MSBuiltinPrint())
 	glb.NewVar("env", MSBuiltinPrintEnv())
 	glb.NewVar("rand", MSBuiltinRand())
 	glb.NewVar("len", MSBuiltinLen())
+	glb.NewVar("err", MSBuiltinErr())
+	glb.NewVar("args", MSBuiltinArgs(args))
+	glb.NewVar("getenv", MSBuiltinGetenv())
+	glb.NewVar("setenv", MSBuiltinSetenv())
+	glb.NewVar("exit", MSBuiltinExit())
+	glb.NewVar("input", MSBuiltinInput())
+	glb.NewVar("read_file", MSBuiltinReadFile())
+	glb.NewVar("read_lines", MSBuiltinReadLines())
+	glb.NewVar("write_file", MSBuiltinWriteFile())
+	glb.NewVar("append_file", MSBuiltinAppendFile())
+	glb.NewVar("list_dir", MSBuiltinListDir())
+	glb.NewVar("exists", MSBuiltinExists())
+	glb.NewVar("remove", MSBuiltinRemove())
+	glb.NewVar("has", MSBuiltinHas())
+	glb.NewVar("delete", MSBuiltinDelete())
 
-	return &MSEvaluator{
-		env: env,
-		glb: glb,
-		vlocals: make(map[*ast.VariableExpNodeS]int),
-		tlocals: make(map[*mstype.MSNamedTypeS]int),
-	}
+	return glb
+}
+
+// Arguments of the program, the 'args' of the program and the
+// modules it imports.
+func (evaluator *MSEvaluator) SetArgs(args []string) {
+	evaluator.args = args
+	evaluator.SetGlobal("args", MSBuiltinArgs(args))
+}
+
+// Shares the modules loaded by the type resolver, a module is only
+// loaded once and its AST carries the types the resolver inferred.
+func (evaluator *MSEvaluator) SetModules(l *resolver.MSModuleLoader) {
+	evaluator.modules = l
 }
 
 func (evaluator *MSEvaluator) UpdateVLocals(vlocals map[*ast.VariableExpNodeS]int) {
@@ -49,17 +119,75 @@ func (evaluator *MSEvaluator) UpdateTLocals(vlocals map[*mstype.MSNamedTypeS]int
 	}
 }
 
+// Defines a global variable, replacing the current value when
+// the name is already defined.
+func (evaluator *MSEvaluator) SetGlobal(name string, val MSVal) {
+	evaluator.glb.variables[name] = val
+}
+
+func (evaluator *MSEvaluator) GetGlobal(name string) (MSVal, bool) {
+	val, ok := evaluator.glb.variables[name]
+	return val, ok
+}
+
 func (evaluator *MSEvaluator) Eval(ast *ast.Program) (MSVal, error) {
 
 	evaluator.ast = ast
 
+	stop := evaluator.startLimits()
+	defer stop()
+
 	return evaluator.executeStatements(evaluator.ast)
 }
 
+// Locates err at tk, the position of the node it passes through.
+// Errors without a position wait for a node that has one.
+func (evaluator *MSEvaluator) locate(err error, tk token.Token) error {
+
+	rerr, ok := err.(*RuntimeError)
+
+	if !ok {
+
+		if tk.Line == 0 {
+			return err
+		}
+
+		trace := make([]TraceFrame, len(evaluator.calls) + 1)
+		for i, name := range evaluator.calls {
+			trace[len(evaluator.calls) - 1 - i].Name = name
+		}
+
+		rerr = &RuntimeError{Err: err, Tk: tk, Trace: trace}
+	}
+
+	rerr.locate(len(evaluator.calls), tk)
+
+	return rerr
+}
+
 func (evaluator *MSEvaluator) Errors() []error {
 	return evaluator.err
 }
 
 func (evaluator *MSEvaluator) PrintEnv() {
-	evaluator.env.printEnv()
+	evaluator.env.printEnv(evaluator.stdout())
+}
+
+// Streams of the program. Builtins called by the bytecode vm get an
+// evaluator holding only the streams, they default to the process
+// streams when not set.
+
+func (evaluator *MSEvaluator) stdout() io.Writer {
+	if evaluator == nil || evaluator.Stdout == nil {
+		return os.Stdout
+	}
+	return evaluator.Stdout
 }
+
+func (evaluator *MSEvaluator) stderr() io.Writer {
+	if evaluator == nil || evaluator.Stderr == nil {
+		return os.Stderr
+	}
+	return evaluator.Stderr
+}
+
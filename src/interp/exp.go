@@ -6,6 +6,17 @@ import (
 )
 
 func (evaluator *MSEvaluator) evaluateExpression(node ast.ExpNodeI) (MSVal, error) {
+
+	val, err := evaluator.evaluateNode(node)
+
+	if err != nil {
+		return nil, evaluator.locate(err, ast.ExpToken(node))
+	}
+
+	return val, nil
+}
+
+func (evaluator *MSEvaluator) evaluateNode(node ast.ExpNodeI) (MSVal, error) {
 	switch node := node.(type) {
 	case *ast.BinaryExpNodeS:				return evaluator.evaluateBinaryExpression(node)
 	case *ast.UnaryExpNodeS:				return evaluator.evaluateUnaryExpression(node)
@@ -28,6 +39,11 @@ func (evaluator *MSEvaluator) evaluateExpression(node ast.ExpNodeI) (MSVal, erro
 	case *ast.FieldAssignmentNode:			return evaluator.evaluateFieldAssign(node)
 	case *ast.RangeConstructorNodeS:		return evaluator.evaluateRangeConstructor(node)
 	case *ast.StarredExpNodeS:				return evaluator.evaluateStarredExpression(node)
+	case *ast.MatchNodeS:					return evaluator.evaluateMatch(node)
+	case *ast.XifNodeS:						return evaluator.evaluateXif(node)
+	case *ast.MapConstructorNodeS:			return evaluator.evaluateMapConstructor(node)
+	case *ast.InterpolationNodeS:			return evaluator.evaluateInterpolation(node)
+	case *ast.FuncExpNodeS:					return evaluator.evaluateFuncExpression(node)
 	default:								return nil, &EvalError{fmt.Sprintf("Unknown expression type: '%#v'", node)}
 	}
 }
@@ -82,6 +82,7 @@ func (e *MSEvaluator) evaluateArrayConstructorWithInitializer(n *ast.ArrayConstr
 		}
 
 		// type check
+		val = toInterface(resolvedType, val)
 		if !val.Type().Eq(resolvedType) {
 			msg := fmt.Sprintf("Array value '%s' has type '%s' but expected '%s'", val, val.Type(), n.Type)
 			return nil, &EvalError{message: msg}
@@ -91,7 +92,7 @@ func (e *MSEvaluator) evaluateArrayConstructorWithInitializer(n *ast.ArrayConstr
 		vals = append(vals, val)
 	}
 
-	return MSArray{Values: vals, VType: n.Type}, nil
+	return MSArray{Values: vals, VType: resolvedType}, nil
 }
 
 func (e *MSEvaluator) evaluateArrayConstructorWithSize(n *ast.ArrayConstructorNodeS) (MSVal, error) {
@@ -164,15 +165,19 @@ func (e *MSEvaluator) evaluateArrayAssignment(n *ast.ArrayAssignmentNodeS) (MSVa
 		return nil, err
 	}
 
-	// Get current value and check if nullable if value is nothing
-	currentVal, err := indexable.Get(idx)
+	// Get current value and check if nullable if value is nothing,
+	// only done for nothing since new map keys have no current value.
+	if _, ok := val.(MSNothing) ; ok {
 
-	if err != nil {
-		return nil, err
-	}
+		currentVal, err := indexable.Get(idx)
 
-	if _, ok := val.(MSNothing) ; ok && currentVal.Nullable() {
-		val = currentVal.NullVal()
+		if err != nil {
+			return nil, err
+		}
+
+		if currentVal.Nullable() {
+			val = currentVal.NullVal()
+		}
 	}
 
 	return indexable.Set(idx, val)
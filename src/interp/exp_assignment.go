@@ -31,7 +31,7 @@ func (evaluator *MSEvaluator) evaluateAssignmentExpression(node *ast.AssignmentN
 	if ok {
 		err = evaluator.env.SetVar(name, res, depth)
 	} else {
-		err = evaluator.glb.SetVar(name, res, 0)
+		err = evaluator.env.global.SetVar(name, res, 0)
 	}
 
 	if err != nil {
@@ -49,7 +49,7 @@ func (evaluator *MSEvaluator) evalVariable(node *ast.VariableExpNodeS) (MSVal, e
 	if depth, ok := evaluator.vlocals[node] ; ok {
 		val, err = evaluator.env.GetVar(node.VarName(), depth)
 	} else {
-		val, err = evaluator.glb.GetVar(node.VarName(), 0)
+		val, err = evaluator.env.global.GetVar(node.VarName(), 0)
 	}
 	
 
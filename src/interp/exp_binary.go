@@ -20,21 +20,27 @@ func (evaluator *MSEvaluator) evaluateBinaryExpression(node *ast.BinaryExpNodeS)
 		return nil, rerr
 	}
 
-	switch node.Op.Type {
+	return BinaryOp(node.Op.Type, lval, rval)
+}
+
+// Applies a binary operator to two evaluated operands. Exported so
+// other backends share the operator semantics of the evaluator.
+func BinaryOp(op token.TokenType, lval, rval MSVal) (MSVal, error) {
+	switch op {
 	case token.PLUS: 				return evalAdd(lval, rval)
 	case token.MINUS:				return evalSub(lval, rval)
 	case token.MULT:				return evalMult(lval, rval)
 	case token.SLASH:				return evalDiv(lval, rval)
-	case token.GREATER:				return evalGreater(lval, rval, node.Op.Type)
-	case token.LESS:				return evalGreater(rval, lval, node.Op.Type)
-	case token.GREATER_EQ:			return evalGreaterEq(lval, rval, node.Op.Type)
-	case token.LESS_EQ:				return evalGreaterEq(rval, lval, node.Op.Type)
-	case token.EQ_EQ:				return evalEq(lval, rval, node.Op.Type)
-	case token.EXCLAMATION_EQ:		return evalNeq(lval, rval, node.Op.Type)
+	case token.GREATER:				return evalGreater(lval, rval, op)
+	case token.LESS:				return evalGreater(rval, lval, op)
+	case token.GREATER_EQ:			return evalGreaterEq(lval, rval, op)
+	case token.LESS_EQ:				return evalGreaterEq(rval, lval, op)
+	case token.EQ_EQ:				return evalEq(lval, rval, op)
+	case token.EXCLAMATION_EQ:		return evalNeq(lval, rval, op)
 	case token.GREATER_GREATER: 	return evalGrGr(lval, rval)
 	case token.COMMA:				return evalTuple(lval, rval)
 	case token.PERCENT:				return evalMod(lval, rval)
-	default:						return nil, &EvalError{unknownBinop(node)}
+	default:						return nil, &EvalError{unknownBinop(op)}
 
 	}
 }
@@ -48,8 +54,8 @@ func invalidLogop(left MSVal, op string) string {
 	return fmt.Sprintf("Logical operator '%v' is not defined for type '%v'", op, left)
 }
 
-func unknownBinop(node *ast.BinaryExpNodeS) string {
-	return fmt.Sprintf("Unknown binary operator: %v", node.Op.Lexeme)
+func unknownBinop(op token.TokenType) string {
+	return fmt.Sprintf("Unknown binary operator: %v", op)
 }
 
 func evalTuple(left, right MSVal) (MSVal, error) {
@@ -65,7 +71,7 @@ func evalMod(lval, rval MSVal) (MSVal, error) {
 		switch r := rval.(type){
 		case MSInt:
 			if r.Val == 0 {
-				return nil, &EvalError{message: "Division by zero."}
+				return nil, throwError(ZeroDivisionKind, "Division by zero.")
 			}
 			return MSInt{Val: l.Val % r.Val}, err
 		}
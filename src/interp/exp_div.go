@@ -31,7 +31,7 @@ func evalDiv(lval, rval MSVal) (MSVal, error) {
 	} 
 
 	if den == 0.0 {
-		return nil, &EvalError{"Division by zero."}
+		return nil, throwError(ZeroDivisionKind, "Division by zero.")
 	}
 
 	return MSFloat{Val: num / den}, err
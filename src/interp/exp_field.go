@@ -1,6 +1,7 @@
 package interp
 
 import (
+	"fmt"
 	"mikescript/src/ast"
 )
 
@@ -15,8 +16,27 @@ func (e *MSEvaluator) evaluateFieldAccess(n *ast.FieldAccessNodeS) (MSVal, error
 		return nil, err
 	}
 
-	fieldable := target.(MSFieldable)
+	// Interfaces dispatch to the value they hold
+	if i, ok := target.(MSInterface) ; ok {
+
+		if i.IsNil() {
+			msg := fmt.Sprintf("Cannot call method '%s' of 'nothing' of type '%s'", fieldName, i.IType.Name)
+			return nil, throwError(NothingErrorKind, msg)
+		}
+
+		target = i.Val
+	}
 
+	// Methods are bound to the struct they are accessed on
+	if s, ok := target.(MSStruct) ; ok {
+		if _, isField := s.Fields[fieldName] ; !isField {
+			if method := e.method(s, fieldName) ; method != nil {
+				return method.(MSCallable).Bind([]MSVal{s})
+			}
+		}
+	}
+
+	fieldable := target.(MSFieldable)
 
 	return fieldable.Get(fieldName)
 }
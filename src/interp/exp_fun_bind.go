@@ -51,6 +51,8 @@ func (b *ParamBindingS) bind(val MSVal) (ParamBindingS, error) {
 		return *b, BindingError{msg: msg}
 	}
 
+	val = toInterface(b.Type, val)
+
 	if !b.ValidBindingEvalResult(&val) {
 		vals := val.String()
 		typs := fmt.Sprintf("%v", val.Type())
@@ -0,0 +1,24 @@
+package interp
+
+import (
+	"mikescript/src/ast"
+)
+
+func (evaluator *MSEvaluator) evaluateFuncExpression(node *ast.FuncExpNodeS) (MSVal, error) {
+
+	// Set by the parser or inferred by the type resolver
+	if node.Rt == nil {
+		return nil, &EvalError{message: "Could not determine the return type of the anonymous function"}
+	}
+
+	decl := &ast.FuncDeclNodeS{Params: node.Params, Rt: node.Rt, Body: node.Body}
+
+	resolvedNode, err := evaluator.resolveFunctionDeclaration(decl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Closes over the environment it is evaluated in
+	return NewMSFunction(resolvedNode, evaluator.env), nil
+}
@@ -23,6 +23,34 @@ func (f MSFunction) Call(ev *MSEvaluator) (MSVal, error) {
 		env.NewVar(bind.strName(), bind.Value)
 	}
 
+	// Type parameters are types in the body
+	for _, tp := range f.typeParams {
+
+		t, ok := f.typeArgs[tp]
+
+		if !ok {
+			msg := fmt.Sprintf("Cannot call '%s', could not infer type parameter '%s' from the arguments", f.fname(), tp)
+			return nil, &EvalError{msg}
+		}
+
+		env.NewType(tp, t)
+	}
+
+	if err := ev.enterCall() ; err != nil {
+		return nil, err
+	}
+
+	// Keep track of the calls for the stack traces of errors
+	ev.calls = append(ev.calls, f.callName())
+	defer func() {
+		ev.calls = ev.calls[:len(ev.calls)-1]
+	}()
+
+	if ev.Profiler != nil {
+		ev.Profiler.Enter(f.callName())
+		defer ev.Profiler.Leave()
+	}
+
 	// Call the body using env
 	res, err := ev.executeBlock(f.fbody, env)
 
@@ -32,6 +60,7 @@ func (f MSFunction) Call(ev *MSEvaluator) (MSVal, error) {
 
 	// Check if we can cast to MSReturn
 	returnVal := res.(MSReturn)
+	returnVal.Val = toInterface(f.GetOutputType(), returnVal.Val)
 
 	if !returnVal.Type().Eq(f.GetOutputType()) {
 		msg := fmt.Sprintf("Tried returning '%s' of type '%s', expected type '%s'", returnVal, returnVal.Type(), f.GetOutputType())
@@ -67,7 +96,7 @@ func (f MSFunction) Bind(args []MSVal) (MSVal, error) {
 // -----------------------------------------------------------
 
 func (f *MSFunction) GetOutputType() mstype.MSType{
-	return f.returnType
+	return mstype.Substitute(f.returnType, f.typeArgs)
 }
 
 func (f *MSFunction) initialized() bool {
@@ -78,6 +107,13 @@ func (f *MSFunction) fname() string {
 	return f.name.VarName()
 }
 
+func (f *MSFunction) callName() string {
+	if f.name == nil {
+		return "<anonymous>"
+	}
+	return f.fname()
+}
+
 func (f *MSFunction) copyBound() []ParamBindingS {
 	new := make([]ParamBindingS, len(f.boundParams))
 	for i, bp := range f.boundParams {
@@ -94,16 +130,32 @@ func (f *MSFunction) copyUnBound() []ParamBindingS {
 	return new
 }
 
+func (f *MSFunction) copyTypeArgs() map[string]mstype.MSType {
+	new := make(map[string]mstype.MSType)
+	for name, t := range f.typeArgs {
+		new[name] = t
+	}
+	return new
+}
 
 func (f *MSFunction) bindArgs(args []MSVal) (*MSFunction, error) {
 
 	// Copy bindings
 	newBound := f.copyBound()
 	newUnbound := f.copyUnBound()
+	typeArgs := f.copyTypeArgs()
 
 	for i, arg := range args {
 
 		up := newUnbound[i]
+
+		// Bind type parameters to the types of the arguments
+		if err := mstype.Unify(up.Type, arg.Type(), typeArgs) ; err != nil {
+			msg := fmt.Sprintf("Cannot bind '%s' of type '%s' to parameter '%s' of type '%s', %s", arg, arg.Type(), up.Name.VarName(), up.Type, err)
+			return nil, BindingError{msg: msg}
+		}
+
+		up.Type = mstype.Substitute(up.Type, typeArgs)
 		up, err := up.bind(arg)
 
 		if err != nil {
@@ -123,6 +175,8 @@ func (f *MSFunction) bindArgs(args []MSVal) (*MSFunction, error) {
 		returnType: f.returnType,
 		name: f.name,
 		closure: f.closure,
+		typeParams: f.typeParams,
+		typeArgs: typeArgs,
 	}
 
 	return &fnew, nil
@@ -0,0 +1,25 @@
+package interp
+
+import (
+	"mikescript/src/ast"
+	"strings"
+)
+
+func (e *MSEvaluator) evaluateInterpolation(node *ast.InterpolationNodeS) (MSVal, error) {
+
+	var str strings.Builder
+
+	// Parts are evaluated in the current scope, left to right
+	for _, part := range node.Parts {
+
+		val, err := e.evaluateExpression(part)
+
+		if err != nil {
+			return nil, err
+		}
+
+		str.WriteString(val.String())
+	}
+
+	return MSString{Val: str.String()}, nil
+}
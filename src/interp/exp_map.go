@@ -0,0 +1,41 @@
+package interp
+
+import (
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+)
+
+func (e *MSEvaluator) evaluateMapConstructor(n *ast.MapConstructorNodeS) (MSVal, error) {
+	// map[K]V{k: v, ...}
+
+	resolved, err := e.resolveType(n.Type)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mtype := resolved.(*mstype.MSMapTypeS)
+	m := NewMSMap(mtype.Key, mtype.Value)
+
+	for i := range n.Keys {
+
+		key, err := e.evaluateExpression(n.Keys[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := e.evaluateExpression(n.Vals[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Later entries overwrite earlier ones with the same key
+		if _, err := m.Set(key, val) ; err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,70 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+)
+
+func (e *MSEvaluator) evaluateMatch(node *ast.MatchNodeS) (MSVal, error) {
+
+	// Set by the parser or inferred by the type resolver
+	if node.Rt == nil {
+		return nil, &EvalError{message: "Could not determine the result type of match"}
+	}
+
+	rtype, err := e.resolveType(node.Rt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	etype, err := e.matchedEnumType(node)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return MSMatch{node: node, etype: etype, rtype: rtype, closure: e.env}, nil
+}
+
+func (e *MSEvaluator) matchedEnumType(node *ast.MatchNodeS) (mstype.MSType, error) {
+	// The enum is found through the variants used in the patterns
+
+	for _, arm := range node.Arms {
+
+		if arm.IsWildcard() {
+			continue
+		}
+
+		v, err := e.evalVariable(arm.Variant)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch variant := v.(type) {
+		case MSEnum:				return variant.EType, nil
+		case MSVariantConstructor:	return variant.EType, nil
+		}
+
+		msg := fmt.Sprintf("'%s' is not a variant of an enum", arm.Variant.VarName())
+		return nil, &EvalError{message: msg}
+	}
+
+	return nil, &EvalError{message: "Match expression needs at least one variant"}
+}
+
+func (e *MSEvaluator) evaluateExpressionIn(node ast.ExpNodeI, env *Environment) (MSVal, error) {
+
+	previous := e.env
+
+	e.env = env
+
+	// Restore the environment when we are done
+	defer func() {
+		e.env = previous
+	}()
+
+	return e.evaluateExpression(node)
+}
@@ -14,13 +14,17 @@ func (evaluator *MSEvaluator) evaluateUnaryExpression(node *ast.UnaryExpNodeS) (
 		return nil, err
 	}
 
-	// handle unary operators
-	switch node.Op.Type {
+	return UnaryOp(node.Op, res)
+}
+
+// Applies a unary operator to an evaluated operand, shared with
+// other backends like BinaryOp.
+func UnaryOp(op token.Token, res MSVal) (MSVal, error) {
+	switch op.Type {
 	case token.MINUS:		return evaluateMinus(res)
 	case token.EXCLAMATION:	return evaluateExcl(res)
-	default: 				return nil, &EvalError{unknownUnop(node.Op.Lexeme, res)}
+	default: 				return nil, &EvalError{unknownUnop(op.Lexeme, res)}
 	}
-	
 }
 
 func evaluateMinus(res MSVal) (MSVal, error) {
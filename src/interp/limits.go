@@ -0,0 +1,87 @@
+package interp
+
+import "context"
+
+// Default maximum call depth, deep enough for any sensible recursion
+// and well before the Go stack runs out.
+const DefaultMaxDepth = 10000
+
+/*
+Limits of a single 'Eval', each one fails the evaluation with its
+own error type when exceeded:
+	- MaxDepth: function calls in progress, 'MaxDepthError'
+	- MaxSteps: statements executed and loop iterations, 'StepLimitError'
+	- Timeout: time spent, 'TimeoutError'
+	- the context set using 'SetContext' is done, 'CanceledError'
+A zero limit means there is no limit. The time and the context are
+checked every loop iteration and function call.
+*/
+
+// Context of the evaluations, they stop when it is done
+func (evaluator *MSEvaluator) SetContext(ctx context.Context) {
+	evaluator.ctx = ctx
+}
+
+// Starts the limits of an evaluation, the returned function
+// releases them.
+func (evaluator *MSEvaluator) startLimits() func() {
+
+	evaluator.steps = 0
+
+	ctx := evaluator.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cancel := func() {}
+	if evaluator.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, evaluator.Timeout)
+	}
+
+	evaluator.running = ctx
+	evaluator.done = ctx.Done()
+
+	return cancel
+}
+
+// Counts a statement or loop iteration against the budget
+func (evaluator *MSEvaluator) step() error {
+
+	evaluator.steps++
+
+	if evaluator.MaxSteps > 0 && evaluator.steps > evaluator.MaxSteps {
+		return &StepLimitError{evaluator.MaxSteps}
+	}
+
+	return nil
+}
+
+// Checks the time and the context
+func (evaluator *MSEvaluator) interrupted() error {
+
+	select {
+	case <-evaluator.done:
+	default:
+		return nil
+	}
+
+	// The host context is done, or else the timeout passed
+	if evaluator.ctx != nil && evaluator.ctx.Err() != nil {
+		return &CanceledError{evaluator.ctx.Err()}
+	}
+
+	if evaluator.running.Err() == context.DeadlineExceeded {
+		return &TimeoutError{evaluator.Timeout}
+	}
+
+	return &CanceledError{evaluator.running.Err()}
+}
+
+func (evaluator *MSEvaluator) enterCall() error {
+
+	if evaluator.MaxDepth > 0 && len(evaluator.calls) >= evaluator.MaxDepth {
+		return &MaxDepthError{evaluator.MaxDepth}
+	}
+
+	return evaluator.interrupted()
+}
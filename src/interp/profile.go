@@ -0,0 +1,16 @@
+package interp
+
+import "mikescript/src/ast"
+
+/*
+Hook for profilers. 'Statement' is called before the evaluator
+executes a statement with the file of the statement, see 'File'.
+'Enter' and 'Leave' are called around the calls of user defined
+functions. Builtins are part of the function calling them.
+*/
+
+type Profiler interface {
+	Statement(file string, node ast.StmtNodeI)
+	Enter(name string)
+	Leave()
+}
@@ -1,6 +1,7 @@
 package interp
 
 import (
+	"fmt"
 	"mikescript/src/ast"
 	"mikescript/src/mstype"
 )
@@ -9,10 +10,14 @@ func (e *MSEvaluator) resolveType(t mstype.MSType) (mstype.MSType, error) {
 	switch tt := t.(type) {
 	case *mstype.MSSimpleTypeS:		return tt, nil
 	case *mstype.MSStructTypeS:		return tt, nil
+	case *mstype.MSEnumTypeS:		return tt, nil
+	case *mstype.MSInterfaceTypeS:	return tt, nil
 	case *mstype.MSCompositeTypeS:	return e.resolveCompositeType(tt)
 	case *mstype.MSArrayType:		return e.resolveArrayType(tt)
+	case *mstype.MSMapTypeS:		return e.resolveMapType(tt)
 	case *mstype.MSNamedTypeS:		return e.resolveNamedType(tt)
 	case *mstype.MSOperationTypeS:	return e.resolveOperationType(tt)
+	case *mstype.MSTypeVarS:		return e.resolveTypeVar(tt), nil
 	default:						_ = []int{}[0] ; return nil, nil
 	}
 }
@@ -45,13 +50,17 @@ func (e *MSEvaluator) resolveNamedType(nt *mstype.MSNamedTypeS) (mstype.MSType,
 	var resolved mstype.MSType
 	var err error
 
+	if nt.Namespace != "" {
+		return e.resolveModuleType(nt)
+	}
+
 	// Look up scope depth
 	depth, ok := e.tlocals[nt]
 
 	if ok {
 		resolved, err = e.env.GetType(nt.Name, depth)
 	} else {
-		resolved, err = e.glb.GetType(nt.Name, depth)
+		resolved, err = e.env.global.GetType(nt.Name, depth)
 	}
 
 	// Yikes, todo better error handling
@@ -59,14 +68,115 @@ func (e *MSEvaluator) resolveNamedType(nt *mstype.MSNamedTypeS) (mstype.MSType,
 		return nil, err
 	}
 
+	if st, ok := resolved.(*mstype.MSStructTypeS) ; ok && (st.Generic() || len(nt.Args) > 0) {
+		return e.instantiateStruct(st, nt)
+	}
+
 	return e.resolveType(resolved)
 }
 
+func (e *MSEvaluator) resolveTypeVar(tv *mstype.MSTypeVarS) mstype.MSType {
+	// Type parameters are declared in the environment of a generic
+	// function call. Outside of a call, while declaring the function,
+	// the type parameter is kept.
+	if t, ok := e.env.LookupType(tv.Name) ; ok {
+		return t
+	}
+	return tv
+}
+
+// --------------------------------------------------------
+// generic structs
+// --------------------------------------------------------
+
+type instanceKey struct {
+	decl *mstype.MSStructTypeS		// generic struct
+	args string						// resolved type arguments
+}
+
+func (e *MSEvaluator) instantiateStruct(st *mstype.MSStructTypeS, nt *mstype.MSNamedTypeS) (mstype.MSType, error) {
+
+	args, err := e.resolveTypes(nt.Args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Instances are reused, every new instance adds type locals
+	key := instanceKey{decl: st, args: fmt.Sprint(args)}
+
+	if inst, ok := e.instances[key] ; ok {
+		return inst, nil
+	}
+
+	inst, err := st.Instantiate(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if env, ok := e.structEnvs[st] ; ok {
+		e.structEnvs[inst] = env
+	}
+
+	for name, ft := range st.Fields {
+		e.copyTypeLocals(ft, inst.Fields[name])
+	}
+
+	e.instances[key] = inst
+
+	return inst, nil
+}
+
+func (e *MSEvaluator) copyTypeLocals(original, substituted mstype.MSType) {
+	// Substituting type parameters creates new named types, they
+	// are resolved in the same scope as the original.
+
+	switch ot := original.(type) {
+	case *mstype.MSNamedTypeS:
+
+		st := substituted.(*mstype.MSNamedTypeS)
+
+		if depth, ok := e.tlocals[ot] ; ok {
+			e.tlocals[st] = depth
+		}
+
+		for i := range ot.Args {
+			e.copyTypeLocals(ot.Args[i], st.Args[i])
+		}
+
+	case *mstype.MSArrayType:
+		e.copyTypeLocals(ot.Type, substituted.(*mstype.MSArrayType).Type)
+	case *mstype.MSMapTypeS:
+		smt := substituted.(*mstype.MSMapTypeS)
+		e.copyTypeLocals(ot.Key, smt.Key)
+		e.copyTypeLocals(ot.Value, smt.Value)
+	case *mstype.MSCompositeTypeS:
+		for i, t := range ot.Types {
+			e.copyTypeLocals(t, substituted.(*mstype.MSCompositeTypeS).Types[i])
+		}
+	case *mstype.MSOperationTypeS:
+		sot := substituted.(*mstype.MSOperationTypeS)
+		for i, t := range ot.Left {
+			e.copyTypeLocals(t, sot.Left[i])
+		}
+		e.copyTypeLocals(ot.Right, sot.Right)
+	}
+}
+
 func (e *MSEvaluator) resolveArrayType(at *mstype.MSArrayType) (*mstype.MSArrayType, error) {
 	resolvedBase, err := e.resolveType(at.Type)
 	return &mstype.MSArrayType{Type: resolvedBase}, err
 }
 
+func (e *MSEvaluator) resolveMapType(mt *mstype.MSMapTypeS) (*mstype.MSMapTypeS, error) {
+	resolved, err := e.resolveTypes([]mstype.MSType{mt.Key, mt.Value})
+	if err != nil {
+		return nil, err
+	}
+	return &mstype.MSMapTypeS{Key: resolved[0], Value: resolved[1]}, nil
+}
+
 func (e *MSEvaluator) resolveOperationType(ot *mstype.MSOperationTypeS) (*mstype.MSOperationTypeS, error) {
 	resolvedLeft, err := e.resolveTypes(ot.Left)
 	if err != nil {
@@ -93,9 +203,12 @@ func (e *MSEvaluator) resolveFunctionDeclaration(f *ast.FuncDeclNodeS) (*ast.Fun
 
 	resolvedFuncDecl := ast.FuncDeclNodeS{
 		Fname: f.Fname,
+		Receiver: f.Receiver,
+		TypeParams: f.TypeParams,
 		Params: resolvedParams,
 		Rt: resolvedReturn,
 		Body: f.Body,
+		Tk: f.Tk,
 	}
 
 	return &resolvedFuncDecl, nil
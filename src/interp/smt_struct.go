@@ -2,18 +2,15 @@ package interp
 
 import (
 	"mikescript/src/ast"
-	"mikescript/src/mstype"
 )
 
 func (e *MSEvaluator) executeStructDeclaration(n *ast.StructDeclarationNodeS) (MSVal, error) {
-	sname := n.Name.VarName()
-	fields := make(map[string]mstype.MSType)
+	st := n.GetStructType()
 
-	for fname, field := range n.Fields {
-		fields[fname.VarName()] = field
-	}
+	// Field types are resolved where the struct is declared
+	e.structEnvs[st] = e.env
 
-	err := e.env.NewType(sname, &mstype.MSStructTypeS{Name: sname, Fields: fields})
+	err := e.env.NewType(n.Name.VarName(), st)
 
 	return MSNothing{}, err
 }
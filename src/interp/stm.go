@@ -7,6 +7,31 @@ import (
 
 
 func (evaluator *MSEvaluator) executeStatement(node ast.StmtNodeI) (MSVal, error) {
+
+	if err := evaluator.step() ; err != nil {
+		return nil, evaluator.locate(err, ast.StmtToken(node))
+	}
+
+	if evaluator.Profiler != nil {
+		evaluator.Profiler.Statement(evaluator.File(), node)
+	}
+
+	if evaluator.Debugger != nil {
+		if err := evaluator.Debugger.Before(evaluator, node) ; err != nil {
+			return nil, evaluator.locate(err, ast.StmtToken(node))
+		}
+	}
+
+	val, err := evaluator.executeNode(node)
+
+	if err != nil {
+		return nil, evaluator.locate(err, ast.StmtToken(node))
+	}
+
+	return val, nil
+}
+
+func (evaluator *MSEvaluator) executeNode(node ast.StmtNodeI) (MSVal, error) {
 	switch node := node.(type) {
 	case *ast.Program:					return evaluator.executeStatements(node)
 	case *ast.VarDeclNodeS:				return evaluator.executeDeclarationStatement(node)
@@ -20,7 +45,13 @@ func (evaluator *MSEvaluator) executeStatement(node ast.StmtNodeI) (MSVal, error
 	case *ast.ReturnNodeS: 				return evaluator.executeReturnStatement(node)
 	case *ast.TypeDefStatementS:		return evaluator.executeTypeDeclaration(node)
 	case *ast.StructDeclarationNodeS:	return evaluator.executeStructDeclaration(node)
+	case *ast.EnumDeclarationNodeS:		return evaluator.executeEnumDeclaration(node)
+	case *ast.InterfaceDeclarationNodeS:	return evaluator.executeInterfaceDeclaration(node)
 	case *ast.ForNodeS:					return evaluator.executeForStatement(node)
+	case *ast.XifNodeS:					return evaluator.executeXifStatement(node)
+	case *ast.ImportNodeS:				return evaluator.executeImport(node)
+	case *ast.ThrowNodeS:				return evaluator.executeThrowStatement(node)
+	case *ast.TryNodeS:					return evaluator.executeTryStatement(node)
 	default:							return MSNothing{}, &EvalError{fmt.Sprintf("Unknown statement type: %v", node)}
 	}
 }
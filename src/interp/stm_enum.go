@@ -0,0 +1,37 @@
+package interp
+
+import (
+	"mikescript/src/ast"
+)
+
+func (e *MSEvaluator) executeEnumDeclaration(n *ast.EnumDeclarationNodeS) (MSVal, error) {
+
+	et := n.GetEnumType()
+
+	// Add the type first, payloads can refer to the enum itself
+	if err := e.env.NewType(n.Name.VarName(), et); err != nil {
+		return nil, err
+	}
+
+	for _, v := range et.Variants {
+
+		types, err := e.resolveTypes(v.Types)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Plain variants are values, the others constructors
+		var val MSVal = MSEnum{EType: et, Variant: v.Name}
+
+		if len(types) > 0 {
+			val = MSVariantConstructor{EType: et, Variant: v.Name, Types: types}
+		}
+
+		if err := e.env.NewVar(v.Name, val); err != nil {
+			return nil, err
+		}
+	}
+
+	return MSNothing{}, nil
+}
@@ -1,7 +1,9 @@
 package interp
 
 import (
+	"fmt"
 	"mikescript/src/ast"
+	"mikescript/src/mstype"
 )
 
 
@@ -17,6 +19,11 @@ func (evaluator *MSEvaluator) executeFuncDeclStatement(node *ast.FuncDeclNodeS)
 	// Wrap the decl with a callable
 	callable := NewMSFunction(resolvedNode, evaluator.env)
 
+	// Methods are found through the type of their receiver
+	if node.IsMethod() {
+		return evaluator.declareMethod(resolvedNode, callable)
+	}
+
 	// Add to current scope
 	fname := node.Fname.Name.Lexeme
 	err = evaluator.env.NewVar(fname, callable)
@@ -28,3 +35,33 @@ func (evaluator *MSEvaluator) executeFuncDeclStatement(node *ast.FuncDeclNodeS)
 	// The result of a function declartion is Nothing
 	return MSNothing{}, nil
 }
+
+func (evaluator *MSEvaluator) declareMethod(node *ast.FuncDeclNodeS, method MSVal) (MSVal, error) {
+
+	st, ok := node.Params[0].Type.(*mstype.MSStructTypeS)
+
+	if !ok {
+		msg := fmt.Sprintf("Cannot declare method '%s' on '%s', it is not a struct", node.Fname.VarName(), node.Params[0].Type)
+		return nil, &EvalError{msg}
+	}
+
+	if evaluator.methods[st] == nil {
+		evaluator.methods[st] = make(map[string]MSVal)
+	}
+
+	evaluator.methods[st][node.Fname.VarName()] = method
+
+	return MSNothing{}, nil
+}
+
+// Method of the struct, nil if there is none
+func (evaluator *MSEvaluator) method(s MSStruct, name string) MSVal {
+
+	st, ok := s.SType.(*mstype.MSStructTypeS)
+
+	if !ok {
+		return nil
+	}
+
+	return evaluator.methods[st][name]
+}
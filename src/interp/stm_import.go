@@ -0,0 +1,120 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/resolver"
+)
+
+func (e *MSEvaluator) executeImport(n *ast.ImportNodeS) (MSVal, error) {
+
+	ns, err := e.importModule(n)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return MSNothing{}, e.env.NewVar(n.Alias.VarName(), ns)
+}
+
+func (e *MSEvaluator) importModule(n *ast.ImportNodeS) (*MSNamespace, error) {
+
+	if e.modules == nil {
+		e.modules = resolver.NewMSModuleLoader(".")
+	}
+
+	// Set when the type resolver loaded the module
+	path := n.File
+	if path == "" {
+		path = n.Path.Lexeme
+	}
+
+	m, err := e.modules.Load(path)
+
+	if err != nil {
+		return nil, &EvalError{message: err.Error()}
+	}
+
+	// Every module is evaluated once, the namespace is
+	// nil while the module is being evaluated.
+	if ns, ok := e.namespaces[m.Path] ; ok {
+
+		if ns == nil {
+			msg := fmt.Sprintf("Import cycle, module '%s' is imported while it is being evaluated", m.Name())
+			return nil, &EvalError{message: msg}
+		}
+
+		return ns, nil
+	}
+
+	e.namespaces[m.Path] = nil
+
+	ns := &MSNamespace{Name: m.Name(), env: newGlobalEnvironment(e.args)}
+	ns.env.file = m.Path
+
+	e.UpdateVLocals(m.VLocals)
+	e.UpdateTLocals(m.TLocals)
+
+	if _, err := e.evaluateModule(m.Ast, ns.env) ; err != nil {
+		delete(e.namespaces, m.Path)
+		return nil, err
+	}
+
+	e.namespaces[m.Path] = ns
+
+	return ns, nil
+}
+
+func (e *MSEvaluator) evaluateModule(program *ast.Program, env *Environment) (MSVal, error) {
+
+	previous := e.env
+
+	e.env = env
+
+	// Restore the environment when we are done
+	defer func() {
+		e.env = previous
+	}()
+
+	return e.executeStatements(program)
+}
+
+func (e *MSEvaluator) resolveModuleType(nt *mstype.MSNamedTypeS) (mstype.MSType, error) {
+	// 'namespace.name', the type is resolved in the module declaring it
+
+	var nsval MSVal
+	var err error
+
+	if depth, ok := e.tlocals[nt] ; ok {
+		nsval, err = e.env.GetVar(nt.Namespace, depth)
+	} else {
+		nsval, err = e.env.global.GetVar(nt.Namespace, 0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	ns, ok := nsval.(*MSNamespace)
+
+	if !ok {
+		msg := fmt.Sprintf("Could not resolve type '%s', '%s' is not a module", nt, nt.Namespace)
+		return nil, &EvalError{message: msg}
+	}
+
+	// Type arguments are resolved where they are written
+	args, err := e.resolveTypes(nt.Args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	previous := e.env
+	e.env = ns.env
+	defer func() {
+		e.env = previous
+	}()
+
+	return e.resolveNamedType(&mstype.MSNamedTypeS{Name: nt.Name, Args: args})
+}
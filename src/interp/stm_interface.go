@@ -0,0 +1,14 @@
+package interp
+
+import (
+	"mikescript/src/ast"
+)
+
+func (e *MSEvaluator) executeInterfaceDeclaration(n *ast.InterfaceDeclarationNodeS) (MSVal, error) {
+
+	// Conformance is checked by the type resolver, the methods
+	// are looked up on the struct a value holds.
+	err := e.env.NewType(n.Name.VarName(), n.GetInterfaceType())
+
+	return MSNothing{}, err
+}
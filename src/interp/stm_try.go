@@ -0,0 +1,62 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"mikescript/src/ast"
+)
+
+func (evaluator *MSEvaluator) executeThrowStatement(node *ast.ThrowNodeS) (MSVal, error) {
+
+	val, err := evaluator.evaluateExpression(node.Node)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A string is the message of a plain 'Error'
+	switch v := val.(type) {
+	case MSError:	return nil, &ThrownError{v}
+	case MSString:	return nil, throwError(ErrorKind, v.Val)
+	}
+
+	msg := fmt.Sprintf("Cannot throw '%s' of type '%s', expected 'string' or 'error'", val, val.Type())
+	return nil, &EvalError{msg}
+}
+
+func (evaluator *MSEvaluator) executeTryStatement(node *ast.TryNodeS) (MSVal, error) {
+
+	res, err := evaluator.executeBlock(node.Body, NewEnvironment(evaluator.env))
+
+	// Only thrown errors are caught, limits and failures
+	// of the interpreter itself are not.
+	var thrown *ThrownError
+	if err != nil && node.Catch != nil && errors.As(err, &thrown) {
+
+		env := NewEnvironment(evaluator.env)
+
+		if node.Err != nil {
+			env.NewVar(node.Err.VarName(), thrown.Val)
+		}
+
+		res, err = evaluator.executeBlock(node.Catch, env)
+	}
+
+	if node.Finally == nil {
+		return res, err
+	}
+
+	// Always runs, a 'return', 'break', 'continue' or error
+	// in the finally block replaces the outcome of the others.
+	fres, ferr := evaluator.executeBlock(node.Finally, NewEnvironment(evaluator.env))
+
+	if ferr != nil {
+		return nil, ferr
+	}
+
+	switch fres.(type) {
+	case MSReturn, MSBreak, MSContinue:		return fres, nil
+	}
+
+	return res, err
+}
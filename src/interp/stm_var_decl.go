@@ -39,9 +39,12 @@ func (e *MSEvaluator) typeToVal(tk mstype.MSType, context bool) MSVal {
 	case *mstype.MSCompositeTypeS:	return e.compositeTypeToVal(t, context)
 	case *mstype.MSOperationTypeS:	return MSFunctionFromType(t, e.env)
 	case *mstype.MSArrayType:		return e.arrayTypeToVal(t)
+	case *mstype.MSMapTypeS:		return NewMSMap(t.Key, t.Value)
 	case *mstype.MSStructTypeS:		return e.structTypeToVal(t, context)
+	case *mstype.MSEnumTypeS:		return MSEnum{EType: t}		// always 'nothing', there is no default variant
+	case *mstype.MSInterfaceTypeS:	return MSInterface{IType: t}	// always 'nothing'
 	case *mstype.MSNamedTypeS:		return e.namedTypeToVal(t, context)
-	default:						fmt.Printf("Found unknown type: '%s'\n", t)
+	default:						fmt.Fprintf(e.stderr(), "Found unknown type: '%s'\n", t)
 	}
 	return nil
 }
@@ -52,6 +55,7 @@ func (e *MSEvaluator) simpleTypeToVal(rt *mstype.MSSimpleTypeS) MSVal {
 	case mstype.RT_FLOAT:	return MSFloat{0.0}
 	case mstype.RT_STRING:	return MSString{""}
 	case mstype.RT_BOOL:	return MSBool{false}
+	case mstype.RT_ERROR:	return MSError{Kind: ErrorKind}
 	default:				return nil
 	}
 }
@@ -74,6 +78,15 @@ func (e *MSEvaluator) structTypeToVal(st *mstype.MSStructTypeS, context bool) MS
 		return MSStruct{Name: st.Name, Fields: nil, SType: st}
 	}
 
+	// Field types are resolved in the environment of the declaration
+	if env, ok := e.structEnvs[st] ; ok && env != e.env {
+		previous := e.env
+		e.env = env
+		defer func() {
+			e.env = previous
+		}()
+	}
+
 	values := make(map[string]MSVal)
 	for name, field := range st.Fields {
 		values[name] = e.typeToVal(field, true)
@@ -84,17 +97,8 @@ func (e *MSEvaluator) structTypeToVal(st *mstype.MSStructTypeS, context bool) MS
 
 func (e *MSEvaluator) namedTypeToVal(nt *mstype.MSNamedTypeS, context bool) MSVal {
 
-	var resolved mstype.MSType
-	var err error
-
-	// Look up type in type locals
-	depth, ok := e.tlocals[nt]
-
-	if ok {
-		resolved, err = e.env.GetType(nt.Name, depth)
-	} else {
-		resolved, err = e.glb.GetType(nt.Name, depth)
-	}
+	// Also instantiates generic structs
+	resolved, err := e.resolveNamedType(nt)
 
 	// Yikes, todo better error handling
 	if err != nil {
@@ -102,4 +106,4 @@ func (e *MSEvaluator) namedTypeToVal(nt *mstype.MSNamedTypeS, context bool) MSVa
 	}
 
 	return e.typeToVal(resolved, context)
-}
\ No newline at end of file
+}
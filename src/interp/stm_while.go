@@ -9,6 +9,15 @@ func (evaluator *MSEvaluator) executeWhileStatement(node *ast.WhileNodeS) (MSVal
 
 	for {
 
+		if err := evaluator.interrupted() ; err != nil {
+			return nil, err
+		}
+
+		// An empty body executes no statements
+		if err := evaluator.step() ; err != nil {
+			return nil, err
+		}
+
 		cond, err := evaluator.evaluateExpression(node.Condition)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,67 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+)
+
+func (e *MSEvaluator) executeXifStatement(node *ast.XifNodeS) (MSVal, error) {
+
+	body, err := e.selectXifBody(node)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch b := body.(type) {
+	case nil:				return MSNothing{}, nil		// no condition holds and no 'otherwise'
+	case *ast.BlockNodeS:	return e.executeBlock(b, NewEnvironment(e.env))
+	case ast.ExpNodeI:		return e.evaluateExpressionIn(b, NewEnvironment(e.env))
+	}
+
+	return nil, &EvalError{fmt.Sprintf("Unknown xif body: %v", body)}
+}
+
+func (e *MSEvaluator) evaluateXif(node *ast.XifNodeS) (MSVal, error) {
+
+	body, err := e.selectXifBody(node)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The parser only allows expressions and requires 'otherwise'
+	exp, ok := body.(ast.ExpNodeI)
+
+	if !ok {
+		return nil, &EvalError{"Evaluator received an xif expression without a value, the parser is broken."}
+	}
+
+	// Expression bodies have their own scope, like blocks
+	return e.evaluateExpressionIn(exp, NewEnvironment(e.env))
+}
+
+func (e *MSEvaluator) selectXifBody(node *ast.XifNodeS) (ast.ASTNodeI, error) {
+	// Body of the first arm whose condition holds
+
+	for _, arm := range node.Arms {
+
+		cond, err := e.evaluateExpression(arm.Cond)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bcond, ok := cond.(MSBool)
+
+		if !ok {
+			return nil, &EvalError{fmt.Sprintf("Condition must be of type bool, got '%v'", cond.Type())}
+		}
+
+		if bcond.Val {
+			return arm.Body, nil
+		}
+	}
+
+	return node.Otherwise, nil
+}
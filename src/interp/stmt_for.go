@@ -27,6 +27,15 @@ func (evaluator *MSEvaluator) executeForStatement(node *ast.ForNodeS) (MSVal, er
 
 	for _, val := range elems {
 
+		if err := evaluator.interrupted() ; err != nil {
+			return MSNothing{}, err
+		}
+
+		// An empty body executes no statements
+		if err := evaluator.step() ; err != nil {
+			return MSNothing{}, err
+		}
+
 		// Create a new scope for the loop variable
 		env := NewEnvironment(evaluator.env)
 		env.NewVar(node.LoopVar.VarName(), val)
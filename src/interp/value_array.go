@@ -55,6 +55,8 @@ func (a MSArray) Set(at, val MSVal) (MSVal, error) {
 		return nil, err
 	}
 
+	val = toInterface(a.VType, val)
+
 	if err := a.ValidValue(val) ; err != nil {
 		return nil, err
 	}
@@ -82,7 +84,7 @@ func (a MSArray) ValidIndex(idx MSVal) error {
 
 	if idxInt.Val < 0 || idxInt.Val >= len(a.Values) {
 		msg := fmt.Sprintf("Array index out of bounds: '%d', expected value in '[%d, %d]'", idxInt.Val, 0, len(a.Values) - 1)
-		return &EvalError{message: msg}
+		return throwError(IndexErrorKind, msg)
 	}
 
 	return nil
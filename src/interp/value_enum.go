@@ -0,0 +1,125 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////
+// Enum value
+///////////////////////////////////////////////////////////////
+
+type MSEnum struct {
+	EType *mstype.MSEnumTypeS		// resolved enum type
+	Variant string					// variant name, empty for 'nothing'
+	Values []MSVal					// payload
+}
+
+func (e MSEnum) Type() mstype.MSType {
+	return e.EType
+}
+
+func (e MSEnum) String() string {
+	if e.IsNil() {
+		return "nothing"
+	}
+
+	if len(e.Values) == 0 {
+		return e.Variant
+	}
+
+	vals := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		vals[i] = v.String()
+	}
+	return fmt.Sprintf("%v(%v)", e.Variant, strings.Join(vals, ", "))
+}
+
+func (e MSEnum) Nullable() bool {
+	return true
+}
+
+func (e MSEnum) NullVal() MSVal {
+	return MSEnum{EType: e.EType}
+}
+
+func (e MSEnum) IsNil() bool {
+	return e.Variant == ""
+}
+
+///////////////////////////////////////////////////////////////
+// Variant constructor
+///////////////////////////////////////////////////////////////
+
+// Variants with a payload are constructed like functions are
+// called: 'x, y >>= variant'
+type MSVariantConstructor struct {
+	EType *mstype.MSEnumTypeS		// resolved enum type
+	Variant string					// variant name
+	Types []mstype.MSType			// resolved payload types
+	bound []MSVal					// payload values bound so far
+}
+
+// --------------------------------------------------------
+// Implements MSValue
+// --------------------------------------------------------
+
+func (c MSVariantConstructor) Type() mstype.MSType {
+	return &mstype.MSOperationTypeS{
+		Left: c.Types[len(c.bound):],
+		Right: c.EType,
+	}
+}
+
+func (c MSVariantConstructor) String() string {
+	return fmt.Sprintf(">> %v -> %v", c.Variant, c.EType.Name)
+}
+
+func (c MSVariantConstructor) Nullable() bool {
+	return false
+}
+
+func (c MSVariantConstructor) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements MSCallable
+// --------------------------------------------------------
+
+func (c MSVariantConstructor) Call(_evaluator *MSEvaluator) (MSVal, error) {
+
+	if c.Arity() > 0 {
+		msg := fmt.Sprintf("Cannot construct variant '%s', %d value(s) are not bound", c.Variant, c.Arity())
+		return nil, &EvalError{message: msg}
+	}
+
+	return MSEnum{EType: c.EType, Variant: c.Variant, Values: c.bound}, nil
+}
+
+func (c MSVariantConstructor) Bind(args []MSVal) (MSVal, error) {
+
+	if len(args) > c.Arity() {
+		msg := fmt.Sprintf("Variant '%s' expects %d value(s), received %d", c.Variant, c.Arity(), len(args))
+		return nil, &BindingError{msg: msg}
+	}
+
+	offset := len(c.bound)
+	for i, arg := range args {
+		if expected := c.Types[offset + i] ; !expected.Eq(arg.Type()) {
+			msg := fmt.Sprintf("Cannot bind '%s' of type '%s' to variant '%s' value of type '%s'", arg, arg.Type(), c.Variant, expected)
+			return nil, &BindingError{msg: msg}
+		}
+	}
+
+	bound := make([]MSVal, 0, len(c.Types))
+	bound = append(bound, c.bound...)
+	bound = append(bound, args...)
+
+	return MSVariantConstructor{EType: c.EType, Variant: c.Variant, Types: c.Types, bound: bound}, nil
+}
+
+func (c MSVariantConstructor) Arity() int {
+	return len(c.Types) - len(c.bound)
+}
@@ -0,0 +1,79 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+)
+
+// Kinds of the errors thrown by failing operations
+const (
+	ErrorKind			= "Error"				// 'throw' of a string
+	IndexErrorKind		= "IndexError"
+	KeyErrorKind		= "KeyError"
+	NothingErrorKind	= "NothingError"		// field of a 'nothing' struct, match on a 'nothing' enum
+	ZeroDivisionKind	= "ZeroDivisionError"
+	OSErrorKind			= "OSError"				// failing call to the operating system
+	NotFoundErrorKind	= "FileNotFoundError"
+	PermissionErrorKind	= "PermissionError"
+	EOFErrorKind		= "EOFError"			// 'input' at the end of the input
+	ValueErrorKind		= "ValueError"			// argument a builtin does not accept
+)
+
+////////////////////////////////////////////////////////////
+// error
+////////////////////////////////////////////////////////////
+
+// Value of type 'error', thrown using 'throw' and caught using
+// 'try'. Both fields are read only: 'e.kind' and 'e.message'.
+type MSError struct {
+	Kind string
+	Message string
+}
+
+func (e MSError) Type() mstype.MSType {
+	return mstype.MS_ERROR
+}
+
+func (e MSError) String() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+func (e MSError) Nullable() bool {
+	return false
+}
+
+func (e MSError) NullVal() MSVal {
+	return nil
+}
+
+// ----------------------------------------------------------------
+// implements MSFieldable
+// ----------------------------------------------------------------
+
+func (e MSError) Get(field string) (MSVal, error) {
+
+	if err := e.ValidField(field) ; err != nil {
+		return nil, err
+	}
+
+	switch field {
+	case "kind":	return MSString{Val: e.Kind}, nil
+	default:		return MSString{Val: e.Message}, nil
+	}
+}
+
+func (e MSError) Set(field string, val MSVal) (MSVal, error) {
+	return nil, &EvalError{fmt.Sprintf("Cannot assign to field '%s' of '%s', errors are read only", field, e)}
+}
+
+func (e MSError) ValidField(field string) error {
+	if field != "kind" && field != "message" {
+		return &EvalError{fmt.Sprintf("Error has no field '%s', expected 'kind' or 'message'", field)}
+	}
+	return nil
+}
+
+func (e MSError) ValidValue(field string, val MSVal) error {
+	_, err := e.Set(field, val)
+	return err
+}
@@ -22,6 +22,9 @@ to contain all information required to run "run":
 	- A closure, the environment when the function was declared:
 		- When declared using "function () >> f {...}"
 		- When declared using "var (->) f;" (bodyless).
+	- Type parameters for generic functions, "function<T> (T x) >> f {...}".
+	  The types they are bound to follow from the bound arguments, calling
+	  the function declares them as types in the function's environment.
 
 Note: 	using "var (->) f;" allows you to capture a closure for
 		an "unknown" or "to be declared" function, which may or may not be
@@ -36,6 +39,8 @@ type MSFunction struct {
 	returnType mstype.MSType			// return type for uninit functions
 	name *ast.VariableExpNodeS			// function name
 	closure *Environment				// env at declaration time
+	typeParams []string					// type parameters of generic functions
+	typeArgs map[string]mstype.MSType	// types bound to the type parameters
 }
 
 
@@ -56,6 +61,7 @@ func NewMSFunction(decl *ast.FuncDeclNodeS, closure *Environment) *MSFunction {
 		returnType: decl.Rt,		// declared return type
 		name: decl.Fname,			// name
 		closure: closure,			// env at declaration
+		typeParams: decl.TypeParamNames(),
 	}
 
 }
@@ -85,12 +91,12 @@ func (f MSFunction) Type() mstype.MSType {
 
 	ptypes := make([]mstype.MSType, len(f.unBoundParams))
 	for i, p := range f.unBoundParams {
-		ptypes[i] = p.Type
+		ptypes[i] = mstype.Substitute(p.Type, f.typeArgs)
 	}
 
 	return &mstype.MSOperationTypeS{
 		Left: ptypes,
-		Right: f.returnType,
+		Right: f.GetOutputType(),
 	}
 }
 
@@ -105,6 +111,7 @@ func (f MSFunction) String() string {
 		ps = append(ps, "(" + bp.String() + ")")
 	}
 	for _, up := range f.unBoundParams {
+		up.Type = mstype.Substitute(up.Type, f.typeArgs)
 		ps = append(ps, "(" + up.String() + ")")
 	}
 
@@ -114,7 +121,7 @@ func (f MSFunction) String() string {
 	if pss != "" {
 		strs = append(strs, pss)
 	}
-	strs = append(strs, ">>", f.fname(), "->", fmt.Sprintf("%v", f.returnType), "{...}")
+	strs = append(strs, ">>", f.callName(), "->", fmt.Sprintf("%v", f.GetOutputType()), "{...}")
 	
 	return strings.Join(strs, " ")
 
@@ -0,0 +1,68 @@
+package interp
+
+import (
+	"mikescript/src/mstype"
+)
+
+///////////////////////////////////////////////////////////////
+// Interface value
+///////////////////////////////////////////////////////////////
+
+// Value stored in a variable, parameter or field of an interface
+// type. It keeps the interface as its type so the variable can
+// hold any struct implementing it, methods are dispatched to the
+// struct it holds.
+type MSInterface struct {
+	IType *mstype.MSInterfaceTypeS	// resolved interface type
+	Val MSVal						// held value, nil for 'nothing'
+}
+
+func (i MSInterface) Type() mstype.MSType {
+	return i.IType
+}
+
+func (i MSInterface) String() string {
+	if i.IsNil() {
+		return "nothing"
+	}
+	return i.Val.String()
+}
+
+func (i MSInterface) Nullable() bool {
+	return true
+}
+
+func (i MSInterface) NullVal() MSVal {
+	return MSInterface{IType: i.IType}
+}
+
+func (i MSInterface) IsNil() bool {
+	return i.Val == nil
+}
+
+// Converts val when it is stored where a value of type t is
+// expected. The type resolver already checked the struct implements
+// the interface, other values are returned as is.
+func toInterface(t mstype.MSType, val MSVal) MSVal {
+
+	it, ok := t.(*mstype.MSInterfaceTypeS)
+
+	if !ok {
+		return val
+	}
+
+	switch v := val.(type) {
+	case MSInterface:
+
+		if v.IType == it {
+			return v
+		}
+
+		return MSInterface{IType: it, Val: v.Val}
+
+	case MSNothing:
+		return MSInterface{IType: it}
+	}
+
+	return MSInterface{IType: it, Val: val}
+}
@@ -0,0 +1,198 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////
+// Map
+///////////////////////////////////////////////////////////////
+
+/*
+Keyed collection of values. Keys are simple values (int, float,
+string, bool) which can be compared directly. Maps are shared like
+arrays: assigning a map to another variable does not copy the
+entries. Iterating a map produces '(key, value)' tuples in the
+order the keys were added.
+*/
+
+type MSMap struct {
+	entries *mapEntries
+	KType mstype.MSType
+	VType mstype.MSType
+}
+
+type mapEntries struct {
+	keys []MSVal				// insertion order
+	values map[MSVal]MSVal
+}
+
+func NewMSMap(ktype, vtype mstype.MSType) MSMap {
+	return MSMap{
+		entries: &mapEntries{keys: []MSVal{}, values: make(map[MSVal]MSVal)},
+		KType: ktype,
+		VType: vtype,
+	}
+}
+
+// --------------------------------------------------------
+// Implements MSValue
+// --------------------------------------------------------
+
+func (m MSMap) Type() mstype.MSType {
+	return &mstype.MSMapTypeS{Key: m.KType, Value: m.VType}
+}
+
+func (m MSMap) String() string {
+
+	strs := make([]string, len(m.entries.keys))
+	for i, k := range m.entries.keys {
+		strs[i] = fmt.Sprintf("%s: %s", k, m.entries.values[k])
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(strs, ", "))
+}
+
+func (m MSMap) Nullable() bool {
+	return false
+}
+
+func (m MSMap) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// implements indexable
+// --------------------------------------------------------
+
+func (m MSMap) Get(at MSVal) (MSVal, error) {
+
+	if err := m.ValidIndex(at) ; err != nil {
+		return nil, err
+	}
+
+	val, ok := m.entries.values[at]
+
+	if !ok {
+		msg := fmt.Sprintf("Key '%s' is not in the map", at)
+		return nil, throwError(KeyErrorKind, msg)
+	}
+
+	return val, nil
+}
+
+func (m MSMap) Set(at, val MSVal) (MSVal, error) {
+
+	if err := m.ValidIndex(at) ; err != nil {
+		return nil, err
+	}
+
+	val = toInterface(m.VType, val)
+
+	if err := m.ValidValue(val) ; err != nil {
+		return nil, err
+	}
+
+	if _, ok := m.entries.values[at] ; !ok {
+		m.entries.keys = append(m.entries.keys, at)
+	}
+
+	m.entries.values[at] = val
+
+	return val, nil
+}
+
+func (m MSMap) Has(at MSVal) (bool, error) {
+
+	if err := m.ValidIndex(at) ; err != nil {
+		return false, err
+	}
+
+	_, ok := m.entries.values[at]
+
+	return ok, nil
+}
+
+// Removes the entry of at, a key which is not in the map is ignored
+func (m MSMap) Delete(at MSVal) error {
+
+	if err := m.ValidIndex(at) ; err != nil {
+		return err
+	}
+
+	if _, ok := m.entries.values[at] ; !ok {
+		return nil
+	}
+
+	delete(m.entries.values, at)
+	m.entries.keys = slices.DeleteFunc(m.entries.keys, func(k MSVal) bool {
+		return k == at
+	})
+
+	return nil
+}
+
+func (m MSMap) ValidIndex(idx MSVal) error {
+	// Any key of the right type, it does not need to be in the map
+
+	if idx == nil {
+		msg := fmt.Sprintf("Trying to use invalid key '%s'", idx)
+		return &EvalError{message: msg}
+	}
+
+	if !m.KType.Eq(idx.Type()) {
+		msg := fmt.Sprintf("Cannot use '%s' of type '%s' as a key, expected type '%s'.", idx, idx.Type(), m.KType)
+		return &EvalError{message: msg}
+	}
+
+	// Keys are compared directly, which only works for simple values
+	if !mstype.IsKeyType(idx.Type()) {
+		msg := fmt.Sprintf("Cannot use '%s' of type '%s' as a key, keys must be of type int, float, string or bool.", idx, idx.Type())
+		return &EvalError{message: msg}
+	}
+
+	return nil
+}
+
+func (m MSMap) ValidValue(val MSVal) error {
+	if !m.VType.Eq(val.Type()) {
+		msg := fmt.Sprintf("Cannot assign '%s' of type '%s', expected type '%s'", val, val.Type(), m.VType)
+		return &EvalError{message: msg}
+	}
+	return nil
+}
+
+// --------------------------------------------------------
+// implements iterable
+// --------------------------------------------------------
+
+func (m MSMap) Elems() ([]MSVal, error) {
+
+	elems := make([]MSVal, len(m.entries.keys))
+	for i, k := range m.entries.keys {
+		elems[i] = MSTuple{Values: []MSVal{k, m.entries.values[k]}}
+	}
+
+	return elems, nil
+}
+
+func (m MSMap) From(vals []MSVal) (MSVal, error) {
+
+	// Mapping a function over the entries gives an array, the
+	// results don't need to be entries.
+	var elemType mstype.MSType
+	if len(vals) > 0 {
+		elemType = vals[0].Type()
+	} else {
+		elemType = &mstype.MSCompositeTypeS{Types: []mstype.MSType{m.KType, m.VType}}
+	}
+
+	return MSArray{Values: vals, VType: elemType}, nil
+}
+
+func (m MSMap) Len() (MSVal, error) {
+	return MSInt{Val: len(m.entries.keys)}, nil
+}
@@ -0,0 +1,153 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+)
+
+///////////////////////////////////////////////////////////////
+// Match expression
+///////////////////////////////////////////////////////////////
+
+/*
+A match expression evaluates to a function taking a single enum
+value, 'v >>= match {...}' binds and calls it. Calling it runs the
+first arm whose pattern matches the variant of the bound value, with
+the payload values bound to the names in the pattern.
+*/
+
+type MSMatch struct {
+	node *ast.MatchNodeS			// the arms
+	etype mstype.MSType				// resolved type of the matched enum
+	rtype mstype.MSType				// resolved result type
+	value MSVal						// bound value, nil when unbound
+	closure *Environment			// env at evaluation time
+}
+
+// --------------------------------------------------------
+// Implements MSValue
+// --------------------------------------------------------
+
+func (m MSMatch) Type() mstype.MSType {
+
+	left := []mstype.MSType{m.etype}
+
+	if m.value != nil {
+		left = []mstype.MSType{}
+	}
+
+	return &mstype.MSOperationTypeS{Left: left, Right: m.rtype}
+}
+
+func (m MSMatch) String() string {
+	if m.value != nil {
+		return fmt.Sprintf("(%v) >> match -> %v {...}", m.value, m.rtype)
+	}
+	return fmt.Sprintf("match -> %v {...}", m.rtype)
+}
+
+func (m MSMatch) Nullable() bool {
+	return false
+}
+
+func (m MSMatch) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements MSCallable
+// --------------------------------------------------------
+
+func (m MSMatch) Call(ev *MSEvaluator) (MSVal, error) {
+
+	if m.value == nil {
+		return nil, &EvalError{message: "Cannot call match without a value to match on"}
+	}
+
+	enum := m.value.(MSEnum)
+
+	if enum.IsNil() {
+		msg := fmt.Sprintf("Cannot match on 'nothing' of type '%s'", enum.EType.Name)
+		return nil, throwError(NothingErrorKind, msg)
+	}
+
+	arm, ok := m.findArm(enum.Variant)
+
+	if !ok {
+		msg := fmt.Sprintf("No match arm for variant '%s'", enum.Variant)
+		return nil, &EvalError{message: msg}
+	}
+
+	// Payload values are only visible in the arm
+	env := NewEnvironment(m.closure)
+
+	for i, b := range arm.Bindings {
+		if b.VarName() == "_" {
+			continue
+		}
+		if err := env.NewVar(b.VarName(), enum.Values[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := ev.evaluateExpressionIn(arm.Body, env)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res = toInterface(m.rtype, res)
+
+	if !res.Type().Eq(m.rtype) {
+		msg := fmt.Sprintf("Match arm '%s' produced '%s' of type '%s', expected type '%s'", arm.Variant.VarName(), res, res.Type(), m.rtype)
+		return nil, &EvalError{message: msg}
+	}
+
+	return res, nil
+}
+
+func (m MSMatch) Bind(args []MSVal) (MSVal, error) {
+
+	if len(args) > m.Arity() {
+		msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", m, m.Arity(), len(args))
+		return nil, &BindingError{msg: msg}
+	}
+
+	if len(args) == 0 {
+		return m, nil
+	}
+
+	if !m.etype.Eq(args[0].Type()) {
+		msg := fmt.Sprintf("Cannot match on '%s' of type '%s', expected type '%s'", args[0], args[0].Type(), m.etype)
+		return nil, &BindingError{msg: msg}
+	}
+
+	return MSMatch{
+		node: m.node,
+		etype: m.etype,
+		rtype: m.rtype,
+		value: args[0],
+		closure: m.closure,
+	}, nil
+}
+
+func (m MSMatch) Arity() int {
+	if m.value == nil {
+		return 1
+	}
+	return 0
+}
+
+// -----------------------------------------------------------
+// helpers
+// -----------------------------------------------------------
+
+func (m *MSMatch) findArm(variant string) (ast.MatchArmS, bool) {
+	for _, arm := range m.node.Arms {
+		if arm.IsWildcard() || arm.Variant.VarName() == variant {
+			return arm, true
+		}
+	}
+	return ast.MatchArmS{}, false
+}
@@ -0,0 +1,86 @@
+package interp
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+)
+
+///////////////////////////////////////////////////////////////
+// Namespace of an imported module
+///////////////////////////////////////////////////////////////
+
+// Value of the name a module is imported under, 'geo.area' gets
+// 'area' from the global environment of the module.
+type MSNamespace struct {
+	Name string				// module name
+	env *Environment		// global environment of the module
+}
+
+func (n *MSNamespace) Type() mstype.MSType {
+	return &msNamespaceTypeS{module: n}
+}
+
+func (n *MSNamespace) String() string {
+	return fmt.Sprintf("module %s", n.Name)
+}
+
+func (n *MSNamespace) Nullable() bool {
+	return false
+}
+
+func (n *MSNamespace) NullVal() MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements MSFieldable
+// --------------------------------------------------------
+
+func (n *MSNamespace) Get(field string) (MSVal, error) {
+
+	if err := n.ValidField(field) ; err != nil {
+		return nil, err
+	}
+
+	return n.env.GetVar(field, 0)
+}
+
+func (n *MSNamespace) Set(field string, val MSVal) (MSVal, error) {
+	msg := fmt.Sprintf("Cannot assign to '%s', members of module '%s' are read only", field, n.Name)
+	return nil, &EvalError{message: msg}
+}
+
+func (n *MSNamespace) ValidField(field string) error {
+
+	if _, ok := n.env.variables[field] ; !ok {
+		msg := fmt.Sprintf("Module '%s' has no member '%s'", n.Name, field)
+		return &EvalError{message: msg}
+	}
+
+	return nil
+}
+
+func (n *MSNamespace) ValidValue(field string, val MSVal) error {
+	return n.ValidField(field)
+}
+
+// --------------------------------------------------------
+// namespace type
+// --------------------------------------------------------
+
+type msNamespaceTypeS struct {
+	module *MSNamespace
+}
+
+func (t *msNamespaceTypeS) Eq(o mstype.MSType) bool {
+	other, ok := o.(*msNamespaceTypeS)
+	return ok && other.module == t.module
+}
+
+func (t *msNamespaceTypeS) String() string {
+	return t.module.String()
+}
+
+func (t *msNamespaceTypeS) Nullable() bool {
+	return false
+}
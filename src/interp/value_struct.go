@@ -3,6 +3,7 @@ package interp
 import (
 	"fmt"
 	"mikescript/src/mstype"
+	"slices"
 	"strings"
 )
 
@@ -21,9 +22,16 @@ func (i MSStruct) String() string {
 		return "nothing"
 	}
 
+	// Sorted by name, so structs always print the same
+	names := make([]string, 0, len(i.Fields))
+	for name := range i.Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
 	fieldss := make([]string, 0, len(i.Fields))
-	for name, val := range i.Fields {
-		fieldss = append(fieldss, fmt.Sprintf("%s: %s", name, val.String()))
+	for _, name := range names {
+		fieldss = append(fieldss, fmt.Sprintf("%s: %s", name, i.Fields[name].String()))
 	}
 	// join
 	fieldsStr := strings.Join(fieldss, ", ")
@@ -54,7 +62,7 @@ func (s MSStruct) Get(field string) (MSVal, error) {
 	// We can do proper type checking.
 	if s.IsNil() {
 		msg := fmt.Sprintf("Cannot access field '%s' of 'nothing' of type 'nothing'", field)
-		return nil, &EvalError{message: msg}
+		return nil, throwError(NothingErrorKind, msg)
 	}
 
 	if val, ok := s.Fields[field]; ok {
@@ -68,13 +76,15 @@ func (s MSStruct) Set(field string, val MSVal) (MSVal, error) {
 
 	if s.IsNil() {
 		msg := fmt.Sprintf("Cannot set field '%s' of 'nothing' of type 'nothing'", field)
-		return nil, &EvalError{message: msg}
+		return nil, throwError(NothingErrorKind, msg)
 	}
 
 	if err := s.ValidField(field); err != nil {
 		return nil, err
 	}
 
+	val = toInterface(s.Fields[field].Type(), val)
+
 	if err := s.ValidValue(field, val); err != nil {
 		return nil, err
 	}
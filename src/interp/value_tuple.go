@@ -64,6 +64,7 @@ func (a MSTuple) Set(at, val MSVal) (MSVal, error) {
 	idxInt := at.(MSInt)
 
 	targetVal := a.Values[idxInt.Val]
+	val = toInterface(targetVal.Type(), val)
 
 	if !targetVal.Type().Eq(val.Type()) {
 		msg := fmt.Sprintf("Cannot assign '%s' or type '%s' at index '%d' of type '%s'", val, val.Type(), idxInt.Val, targetVal.Type())
@@ -91,7 +92,7 @@ func (a MSTuple) ValidIndex(idx MSVal) error {
 
 	if idxInt.Val < 0 || idxInt.Val >= len(a.Values) {
 		msg := fmt.Sprintf("Array index out of bounds: '%d', expected value in '[%d, %d]'", idxInt.Val, 0, len(a.Values))
-		return &EvalError{message: msg}
+		return throwError(IndexErrorKind, msg)
 	}
 
 	return nil
@@ -0,0 +1,276 @@
+package lsp
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/parser"
+	"mikescript/src/resolver"
+	"mikescript/src/scanner"
+	"mikescript/src/token"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// An open file, analysed again on every change. Navigation only
+// works once the file parses, the diagnostics always do.
+type document struct {
+	uri string
+	src string
+	tokens []token.Token
+	program *ast.Program							// nil when the file does not parse
+	decls map[*ast.VariableExpNodeS]*ast.VariableExpNodeS	// identifier -> declaring identifier
+	idents map[token.Token]*ast.VariableExpNodeS	// identifiers by token
+	info map[*ast.VariableExpNodeS]string			// declared signatures, for hover
+	globals map[string]mstype.MSType				// global variables and builtins
+	types map[string]mstype.MSType					// global types
+	diagnostics []Diagnostic
+}
+
+// Errors of the scanner, parser and type resolver
+type positionedError interface {
+	Message() string
+	Position() (int, int)
+}
+
+func analyze(uri, src string) *document {
+
+	d := &document{
+		uri: uri,
+		src: src,
+		decls: make(map[*ast.VariableExpNodeS]*ast.VariableExpNodeS),
+		idents: make(map[token.Token]*ast.VariableExpNodeS),
+		info: make(map[*ast.VariableExpNodeS]string),
+		diagnostics: []Diagnostic{},
+	}
+
+	s := scanner.MSScanner{}
+	d.tokens = s.Scan(src)
+
+	if len(s.Errors) > 0 {
+		for _, err := range s.Errors {
+			d.report("scanner", err)
+		}
+		return d
+	}
+
+	p := parser.MSParser{}
+	p.SetSrc(src)
+	p.SetTokens(d.tokens)
+
+	program, _ := p.Parse(d.tokens)
+
+	if len(p.Errors) > 0 {
+		for _, err := range p.Errors {
+			d.report("parser", err)
+		}
+		return d
+	}
+
+	d.program = program
+	d.resolve(uri)
+
+	return d
+}
+
+func (d *document) resolve(uri string) {
+
+	// A crash of the resolvers should not take the server down
+	defer func() {
+		if r := recover() ; r != nil {
+			d.diagnostics = append(d.diagnostics, Diagnostic{
+				Severity: severityError,
+				Source: "mikescript",
+				Message: fmt.Sprintf("Internal error while resolving: %v", r),
+			})
+		}
+	}()
+
+	vr := resolver.NewMSResolver(d.program)
+	vr.Reset()
+	vr.Resolve()
+
+	d.decls = vr.Declarations()
+	for v := range d.decls {
+		d.idents[v.Name] = v
+	}
+
+	// Imports are relative to the file
+	tr := resolver.NewMSTypeResolver(d.program)
+	tr.SetModules(resolver.NewMSModuleLoader(filepath.Dir(uriPath(uri))))
+
+	for _, err := range tr.Resolve() {
+		d.report("types", err)
+	}
+
+	d.globals, d.types = tr.Globals()
+	d.describeStatements(d.program.Statements)
+}
+
+func (d *document) keepIndex(prev *document) {
+	d.tokens = prev.tokens
+	d.program = prev.program
+	d.decls = prev.decls
+	d.idents = prev.idents
+	d.info = prev.info
+	d.globals = prev.globals
+	d.types = prev.types
+}
+
+func (d *document) report(source string, err positionedError) {
+
+	line, col := err.Position()
+
+	d.diagnostics = append(d.diagnostics, Diagnostic{
+		Range: d.errorRange(line, col),
+		Severity: severityError,
+		Source: source,
+		Message: err.Message(),
+	})
+}
+
+// --------------------------------------------------------
+// positions
+// --------------------------------------------------------
+
+// Token columns point just past the token
+func tokenRange(tk token.Token) Range {
+	end := tk.Col - 1
+	return Range{
+		Start: Position{Line: tk.Line - 1, Character: end - len(tk.Lexeme)},
+		End: Position{Line: tk.Line - 1, Character: end},
+	}
+}
+
+// Errors only have the position just past the offending token, the
+// word before it is taken as the range.
+func (d *document) errorRange(line, col int) Range {
+
+	lines := strings.Split(d.src, "\n")
+	end := col - 1
+
+	if line < 1 || line > len(lines) || end < 1 || end > len(lines[line-1]) {
+		pos := Position{Line: max(line - 1, 0), Character: max(end - 1, 0)}
+		return Range{Start: pos, End: pos}
+	}
+
+	text := lines[line-1]
+	start := end - 1
+	for start > 0 && isWordChar(text[start-1]) && isWordChar(text[start]) {
+		start--
+	}
+
+	return Range{
+		Start: Position{Line: line - 1, Character: start},
+		End: Position{Line: line - 1, Character: end},
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (p Position) before(o Position) bool {
+	return p.Line < o.Line || p.Line == o.Line && p.Character < o.Character
+}
+
+func (r Range) contains(p Position) bool {
+	return !p.before(r.Start) && !r.End.before(p)
+}
+
+// Index of the identifier token at pos, -1 if there is none
+func (d *document) tokenAt(pos Position) int {
+	for i, tk := range d.tokens {
+		if tk.Type == token.IDENTIFIER && tokenRange(tk).contains(pos) {
+			return i
+		}
+	}
+	return -1
+}
+
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "."
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// --------------------------------------------------------
+// declarations
+// --------------------------------------------------------
+
+// Signatures of declarations as written in the source, globals
+// declared using '=>' get their type from the type resolver.
+
+func (d *document) describeStatements(stmts []ast.StmtNodeI) {
+	for _, stmt := range stmts {
+		d.describeStatement(stmt)
+	}
+}
+
+func (d *document) describeStatement(stmt ast.StmtNodeI) {
+	switch st := stmt.(type) {
+	case *ast.BlockNodeS:				d.describeStatements(st.Statements)
+	case *ast.VarDeclNodeS:				d.info[st.Identifier] = fmt.Sprintf("var %s %s", st.Vartype, st.VarName())
+	case *ast.FuncDeclNodeS:			d.describeFunction(st)
+	case *ast.StructDeclarationNodeS:	d.info[st.Name] = fmt.Sprintf("type struct %s", st.Name.VarName())
+	case *ast.InterfaceDeclarationNodeS:	d.info[st.Name] = fmt.Sprintf("type interface %s", st.Name.VarName())
+	case *ast.TypeDefStatementS:		d.info[st.Tname] = fmt.Sprintf("type %s %s", st.Type, st.Tname.VarName())
+	case *ast.ImportNodeS:				d.info[st.Alias] = fmt.Sprintf("import %s => %s", st.Path.Lexeme, st.Alias.VarName())
+	case *ast.EnumDeclarationNodeS:
+
+		d.info[st.Name] = fmt.Sprintf("type enum %s", st.Name.VarName())
+
+		for _, v := range st.Variants {
+			d.info[v.Name] = fmt.Sprintf("%s (variant of %s)", mstype.MSVariantS{Name: v.Name.VarName(), Types: v.Types}, st.Name.VarName())
+		}
+
+	case *ast.IfNodeS:
+
+		d.describeStatement(st.ThenStmt)
+		if st.ElseStmt != nil {
+			d.describeStatement(st.ElseStmt)
+		}
+
+	case *ast.WhileNodeS:				d.describeStatements(st.Body.Statements)
+	case *ast.ForNodeS:					d.describeStatements(st.Body.Statements)
+	case *ast.TryNodeS:
+
+		d.describeStatements(st.Body.Statements)
+
+		if st.Catch != nil {
+			if st.Err != nil {
+				d.info[st.Err] = fmt.Sprintf("error %s", st.Err.VarName())
+			}
+			d.describeStatements(st.Catch.Statements)
+		}
+
+		if st.Finally != nil {
+			d.describeStatements(st.Finally.Statements)
+		}
+	}
+}
+
+func (d *document) describeFunction(fd *ast.FuncDeclNodeS) {
+
+	params := make([]string, len(fd.Params))
+	for i, p := range fd.Params {
+		params[i] = fmt.Sprintf("%s %s", p.Type, p.VarName())
+		d.info[p.Iden] = params[i]
+	}
+
+	name := fd.Fname.VarName()
+	if fd.IsMethod() {
+		name = fd.Receiver.VarName() + "." + name
+	}
+
+	sig := fmt.Sprintf("function (%s) >> %s", strings.Join(params, ", "), name)
+	if fd.Rt != nil && fd.Rt.String() != "" {
+		sig += fmt.Sprintf(" -> %s", fd.Rt)
+	}
+
+	d.info[fd.Fname] = sig
+	d.describeStatements(fd.Body.Statements)
+}
@@ -0,0 +1,366 @@
+package lsp
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+	"slices"
+	"strings"
+)
+
+// --------------------------------------------------------
+// definition and references
+// --------------------------------------------------------
+
+// Identifier at pos and the identifier declaring it
+func (d *document) declarationAt(pos Position) (*ast.VariableExpNodeS, *ast.VariableExpNodeS, bool) {
+
+	i := d.tokenAt(pos)
+
+	if i < 0 {
+		return nil, nil, false
+	}
+
+	v, ok := d.idents[d.tokens[i]]
+
+	if !ok {
+		return nil, nil, false
+	}
+
+	decl, ok := d.decls[v]
+
+	return v, decl, ok
+}
+
+func (d *document) definition(pos Position) (Location, bool) {
+
+	_, decl, ok := d.declarationAt(pos)
+
+	if !ok {
+		return Location{}, false
+	}
+
+	return Location{URI: d.uri, Range: tokenRange(decl.Name)}, true
+}
+
+func (d *document) references(pos Position, includeDeclaration bool) []Location {
+
+	locations := []Location{}
+
+	_, decl, ok := d.declarationAt(pos)
+
+	if !ok {
+		return locations
+	}
+
+	for v, dv := range d.decls {
+		if dv == decl && (includeDeclaration || v != decl) {
+			locations = append(locations, Location{URI: d.uri, Range: tokenRange(v.Name)})
+		}
+	}
+
+	// In source order
+	slices.SortFunc(locations, func(a, b Location) int {
+		switch {
+		case a.Range.Start.before(b.Range.Start):	return -1
+		case b.Range.Start.before(a.Range.Start):	return 1
+		default:									return 0
+		}
+	})
+
+	return locations
+}
+
+// --------------------------------------------------------
+// hover
+// --------------------------------------------------------
+
+func (d *document) hover(pos Position) (Hover, bool) {
+
+	i := d.tokenAt(pos)
+
+	if i < 0 {
+		return Hover{}, false
+	}
+
+	tk := d.tokens[i]
+	text, ok := d.signature(tk)
+
+	if !ok {
+		return Hover{}, false
+	}
+
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```mikescript\n" + text + "\n```"},
+		Range: tokenRange(tk),
+	}, true
+}
+
+func (d *document) signature(tk token.Token) (string, bool) {
+
+	// Declarations with a signature in the source
+	if v, ok := d.idents[tk] ; ok {
+		if decl, ok := d.decls[v] ; ok {
+
+			if info, ok := d.info[decl] ; ok {
+				return info, true
+			}
+
+			// Globals declared using '=>'
+			if t, ok := d.globals[decl.VarName()] ; ok && d.isGlobal(decl) {
+				return fmt.Sprintf("%s %s", t, decl.VarName()), true
+			}
+
+			return decl.VarName(), true
+		}
+	}
+
+	// Builtins and types
+	if t, ok := d.globals[tk.Lexeme] ; ok {
+		return fmt.Sprintf("%s %s", t, tk.Lexeme), true
+	}
+
+	if t, ok := d.types[tk.Lexeme] ; ok {
+		return fmt.Sprintf("type %s", t), true
+	}
+
+	return "", false
+}
+
+// --------------------------------------------------------
+// completion
+// --------------------------------------------------------
+
+// Names which can be used at pos: declarations visible at pos,
+// builtins and types.
+func (d *document) completion(pos Position) []CompletionItem {
+
+	items := []CompletionItem{}
+	seen := make(map[string]bool)
+
+	add := func(item CompletionItem) {
+		if !seen[item.Label] {
+			seen[item.Label] = true
+			items = append(items, item)
+		}
+	}
+
+	// Innermost declarations first, they shadow the others. Those
+	// are the locals declared last.
+	locals := d.visible(pos)
+	slices.SortFunc(locals, func(a, b *ast.VariableExpNodeS) int {
+		switch ga, gb := d.isGlobal(a), d.isGlobal(b) ; {
+		case ga != gb && gb:												return -1
+		case ga != gb && ga:												return 1
+		case tokenRange(b.Name).Start.before(tokenRange(a.Name).Start):	return -1
+		case tokenRange(a.Name).Start.before(tokenRange(b.Name).Start):	return 1
+		default:															return 0
+		}
+	})
+
+	for _, v := range locals {
+
+		detail, kind := d.info[v], kindVariable
+
+		if d.isGlobal(v) {
+			if t, ok := d.globals[v.VarName()] ; ok {
+				detail, kind = t.String(), valueKind(t)
+			} else if t, ok := d.types[v.VarName()] ; ok {
+				detail, kind = "type", typeKind(t)
+			}
+		}
+
+		add(CompletionItem{Label: v.VarName(), Kind: kind, Detail: detail})
+	}
+
+	for _, name := range sortedKeys(d.globals) {
+		t := d.globals[name]
+		add(CompletionItem{Label: name, Kind: valueKind(t), Detail: t.String()})
+	}
+
+	for _, name := range sortedKeys(d.types) {
+		add(CompletionItem{Label: name, Kind: typeKind(d.types[name]), Detail: "type"})
+	}
+
+	return items
+}
+
+// Declarations visible at pos. Blocks are found using the
+// braces around a declaration, parameters and loop variables are
+// visible in the block following them.
+func (d *document) visible(pos Position) []*ast.VariableExpNodeS {
+
+	index := make(map[token.Token]int)
+	for i, tk := range d.tokens {
+		index[tk] = i
+	}
+
+	visible := []*ast.VariableExpNodeS{}
+
+	for v, decl := range d.decls {
+
+		i, ok := index[v.Name]
+
+		if v != decl || !ok {
+			continue
+		}
+
+		// Globals are visible everywhere, functions can be
+		// called before they are declared.
+		if d.isGlobal(v) {
+			visible = append(visible, v)
+			continue
+		}
+
+		if !tokenRange(v.Name).End.before(pos) {
+			continue
+		}
+
+		open := d.enclosingBrace(i)
+
+		if open < 0 {
+			continue
+		}
+
+		close := d.matchingBrace(open)
+
+		if d.tokenPos(open).before(pos) && (close < 0 || pos.before(tokenRange(d.tokens[close]).End)) {
+			visible = append(visible, v)
+		}
+	}
+
+	return visible
+}
+
+// Brace opening the block a declaration at token i belongs to
+func (d *document) enclosingBrace(i int) int {
+
+	// Loop variables, 'for xs .-> x {'
+	if i > 0 && d.tokens[i-1].Type == token.DOT_MINUS_GREAT {
+		return d.nextBrace(i)
+	}
+
+	braces, parens := 0, 0
+
+	for j := i - 1 ; j >= 0 ; j-- {
+		switch d.tokens[j].Type {
+		case token.RIGHT_BRACE:		braces++
+		case token.RIGHT_PAREN:		parens++
+		case token.LEFT_BRACE:
+
+			if braces == 0 {
+				return j
+			}
+			braces--
+
+		case token.LEFT_PAREN:
+
+			// Parameters, 'function (int x) >> f {' and 'catch (e) {'
+			if parens == 0 && braces == 0 {
+				return d.nextBrace(i)
+			}
+			parens--
+		}
+	}
+
+	return -1
+}
+
+func (d *document) nextBrace(i int) int {
+	for j := i + 1 ; j < len(d.tokens) ; j++ {
+		if d.tokens[j].Type == token.LEFT_BRACE {
+			return j
+		}
+	}
+	return -1
+}
+
+func (d *document) matchingBrace(open int) int {
+
+	depth := 0
+
+	for j := open ; j < len(d.tokens) ; j++ {
+		switch d.tokens[j].Type {
+		case token.LEFT_BRACE:		depth++
+		case token.RIGHT_BRACE:
+
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+
+	return -1
+}
+
+func (d *document) tokenPos(i int) Position {
+	return tokenRange(d.tokens[i]).Start
+}
+
+// Declared outside of any block, functions and parameters
+func (d *document) isGlobal(v *ast.VariableExpNodeS) bool {
+	for _, stmt := range d.program.Statements {
+		if declares(stmt, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func declares(stmt ast.StmtNodeI, v *ast.VariableExpNodeS) bool {
+	switch st := stmt.(type) {
+	case *ast.VarDeclNodeS:				return st.Identifier == v
+	case *ast.FuncDeclNodeS:			return st.Fname == v
+	case *ast.StructDeclarationNodeS:	return st.Name == v
+	case *ast.InterfaceDeclarationNodeS:	return st.Name == v
+	case *ast.TypeDefStatementS:		return st.Tname == v
+	case *ast.ImportNodeS:				return st.Alias == v
+	case *ast.EnumDeclarationNodeS:
+
+		if st.Name == v {
+			return true
+		}
+
+		for _, variant := range st.Variants {
+			if variant.Name == v {
+				return true
+			}
+		}
+
+	case *ast.ExStmtNodeS:
+
+		if da, ok := st.Ex.(*ast.DeclAssignNodeS) ; ok {
+			return da.Identifier == v
+		}
+	}
+
+	return false
+}
+
+func valueKind(t mstype.MSType) int {
+	// Builtins show the function type they have at runtime
+	if _, ok := t.(*mstype.MSOperationTypeS) ; ok || strings.Contains(t.String(), "->") {
+		return kindFunction
+	}
+	return kindVariable
+}
+
+func typeKind(t mstype.MSType) int {
+	switch t.(type) {
+	case *mstype.MSStructTypeS:		return kindStruct
+	case *mstype.MSInterfaceTypeS:	return kindInterface
+	case *mstype.MSEnumTypeS:		return kindEnum
+	}
+	return kindClass
+}
+
+func sortedKeys(m map[string]mstype.MSType) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
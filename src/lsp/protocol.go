@@ -0,0 +1,194 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The subset of the Language Server Protocol used by the server,
+// see https://microsoft.github.io/language-server-protocol/.
+// Messages are JSON-RPC 2.0, each preceded by a 'Content-Length'
+// header.
+
+type message struct {
+	JSONRPC string				`json:"jsonrpc"`
+	ID *json.RawMessage			`json:"id,omitempty"`		// nil for notifications
+	Method string				`json:"method,omitempty"`
+	Params json.RawMessage		`json:"params,omitempty"`
+	Result json.RawMessage		`json:"result,omitempty"`
+	Error *responseError		`json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code int		`json:"code"`
+	Message string	`json:"message"`
+}
+
+// JSON-RPC error codes
+const (
+	codeParseError = -32700
+	codeInvalidParams = -32602
+	codeMethodNotFound = -32601
+	codeInvalidRequest = -32600
+)
+
+func readMessage(r *bufio.Reader) (*message, error) {
+
+	length := -1
+
+	// Headers end with an empty line
+	for {
+		line, err := r.ReadString('\n')
+
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)) ; err != nil {
+				return nil, fmt.Errorf("invalid Content-Length '%s'", value)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, length)
+
+	if _, err := io.ReadFull(r, body) ; err != nil {
+		return nil, err
+	}
+
+	var msg message
+
+	if err := json.Unmarshal(body, &msg) ; err != nil {
+		return nil, &responseError{codeParseError, err.Error()}
+	}
+
+	return &msg, nil
+}
+
+func writeMessage(w io.Writer, msg *message) error {
+
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+
+	return err
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+// --------------------------------------------------------
+// types
+// --------------------------------------------------------
+
+// Lines and characters count from 0
+type Position struct {
+	Line int		`json:"line"`
+	Character int	`json:"character"`
+}
+
+type Range struct {
+	Start Position	`json:"start"`
+	End Position	`json:"end"`
+}
+
+type Location struct {
+	URI string		`json:"uri"`
+	Range Range		`json:"range"`
+}
+
+type Diagnostic struct {
+	Range Range			`json:"range"`
+	Severity int		`json:"severity"`
+	Source string		`json:"source"`
+	Message string		`json:"message"`
+}
+
+const severityError = 1
+
+type publishDiagnosticsParams struct {
+	URI string					`json:"uri"`
+	Diagnostics []Diagnostic	`json:"diagnostics"`
+}
+
+type textDocumentIdentifier struct {
+	URI string	`json:"uri"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI string		`json:"uri"`
+		Text string		`json:"text"`
+	}	`json:"textDocument"`
+}
+
+// Only full synchronisation is supported, every change holds the
+// complete text.
+type didChangeParams struct {
+	TextDocument textDocumentIdentifier		`json:"textDocument"`
+	ContentChanges []struct {
+		Text string		`json:"text"`
+	}	`json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier		`json:"textDocument"`
+}
+
+type positionParams struct {
+	TextDocument textDocumentIdentifier		`json:"textDocument"`
+	Position Position						`json:"position"`
+	Context struct {
+		IncludeDeclaration bool		`json:"includeDeclaration"`
+	}	`json:"context"`	// references only
+}
+
+type Hover struct {
+	Contents MarkupContent	`json:"contents"`
+	Range Range				`json:"range"`
+}
+
+type MarkupContent struct {
+	Kind string		`json:"kind"`
+	Value string	`json:"value"`
+}
+
+type CompletionItem struct {
+	Label string	`json:"label"`
+	Kind int		`json:"kind"`
+	Detail string	`json:"detail,omitempty"`
+}
+
+// Completion item kinds
+const (
+	kindFunction = 3
+	kindVariable = 6
+	kindClass = 7
+	kindInterface = 8
+	kindModule = 9
+	kindEnum = 13
+	kindStruct = 22
+)
@@ -0,0 +1,284 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+/*
+Language server for MikeScript files, speaking the Language Server
+Protocol over a pair of streams (stdin and stdout for 'ms lsp').
+Files are scanned, parsed and resolved on every change, supported
+are:
+	- diagnostics of the scanner, parser and type resolver
+	- go to definition and find references of variables, functions
+	  and types, using the declarations found by 'MSResolver'
+	- hover, showing declared signatures and the types of globals
+	- completion of visible variables, builtins and types
+*/
+
+type Server struct {
+	in *bufio.Reader
+	out io.Writer
+	docs map[string]*document		// open documents by uri
+	shutdown bool					// 'shutdown' was requested
+}
+
+var errNoShutdown = errors.New("lsp: exit without shutdown")
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in: bufio.NewReader(in),
+		out: out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serves until the client sends 'exit' or closes the input
+func (s *Server) Run() error {
+
+	for {
+		msg, err := readMessage(s.in)
+
+		var rerr *responseError
+		switch {
+		case errors.As(err, &rerr):
+			s.respond(nil, nil, rerr)
+			continue
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errNoShutdown
+			}
+			return nil
+		}
+
+		if err := s.handle(msg) ; err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) error {
+
+	result, rerr := s.dispatch(msg)
+
+	// Notifications get no response
+	if msg.ID == nil {
+		return nil
+	}
+
+	return s.respond(msg.ID, result, rerr)
+}
+
+func (s *Server) dispatch(msg *message) (any, *responseError) {
+	switch msg.Method {
+	case "initialize":					return s.initialize(), nil
+	case "initialized":					return nil, nil
+	case "shutdown":					s.shutdown = true ; return nil, nil
+	case "textDocument/didOpen":		return nil, s.didOpen(msg.Params)
+	case "textDocument/didChange":		return nil, s.didChange(msg.Params)
+	case "textDocument/didClose":		return nil, s.didClose(msg.Params)
+	case "textDocument/definition":		return s.definition(msg.Params)
+	case "textDocument/references":		return s.references(msg.Params)
+	case "textDocument/hover":			return s.hover(msg.Params)
+	case "textDocument/completion":		return s.completion(msg.Params)
+	}
+
+	if msg.Method == "" {
+		return nil, &responseError{codeInvalidRequest, "missing method"}
+	}
+
+	return nil, &responseError{codeMethodNotFound, "method not supported: " + msg.Method}
+}
+
+func (s *Server) respond(id *json.RawMessage, result any, rerr *responseError) error {
+
+	if rerr != nil {
+		return writeMessage(s.out, &message{ID: id, Error: rerr})
+	}
+
+	body, err := json.Marshal(result)
+
+	if err != nil {
+		return err
+	}
+
+	return writeMessage(s.out, &message{ID: id, Result: body})
+}
+
+func (s *Server) notify(method string, params any) error {
+
+	body, err := json.Marshal(params)
+
+	if err != nil {
+		return err
+	}
+
+	return writeMessage(s.out, &message{Method: method, Params: body})
+}
+
+// --------------------------------------------------------
+// lifecycle
+// --------------------------------------------------------
+
+func (s *Server) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": 1,		// full text on every change
+			"definitionProvider": true,
+			"referencesProvider": true,
+			"hoverProvider": true,
+			"completionProvider": map[string]any{},
+		},
+		"serverInfo": map[string]any{
+			"name": "mikescript",
+		},
+	}
+}
+
+// --------------------------------------------------------
+// documents
+// --------------------------------------------------------
+
+func (s *Server) didOpen(raw json.RawMessage) *responseError {
+
+	var params didOpenParams
+
+	if err := json.Unmarshal(raw, &params) ; err != nil {
+		return &responseError{codeInvalidParams, err.Error()}
+	}
+
+	s.open(params.TextDocument.URI, params.TextDocument.Text)
+
+	return nil
+}
+
+func (s *Server) didChange(raw json.RawMessage) *responseError {
+
+	var params didChangeParams
+
+	if err := json.Unmarshal(raw, &params) ; err != nil {
+		return &responseError{codeInvalidParams, err.Error()}
+	}
+
+	if n := len(params.ContentChanges) ; n > 0 {
+		s.open(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+	}
+
+	return nil
+}
+
+func (s *Server) didClose(raw json.RawMessage) *responseError {
+
+	var params didCloseParams
+
+	if err := json.Unmarshal(raw, &params) ; err != nil {
+		return &responseError{codeInvalidParams, err.Error()}
+	}
+
+	uri := params.TextDocument.URI
+	delete(s.docs, uri)
+
+	// Clears the diagnostics of the file
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{}})
+
+	return nil
+}
+
+func (s *Server) open(uri, text string) {
+
+	doc := analyze(uri, text)
+
+	// While typing the file often does not parse, navigation
+	// keeps using the last version which did.
+	if prev, ok := s.docs[uri] ; ok && doc.program == nil && prev.program != nil {
+		doc.keepIndex(prev)
+	}
+
+	s.docs[uri] = doc
+
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: doc.diagnostics})
+}
+
+// Document and position of a request, the document is nil when
+// it is not open or does not parse.
+func (s *Server) position(raw json.RawMessage) (*document, positionParams, *responseError) {
+
+	var params positionParams
+
+	if err := json.Unmarshal(raw, &params) ; err != nil {
+		return nil, params, &responseError{codeInvalidParams, err.Error()}
+	}
+
+	doc, ok := s.docs[params.TextDocument.URI]
+
+	if !ok || doc.program == nil {
+		return nil, params, nil
+	}
+
+	return doc, params, nil
+}
+
+// --------------------------------------------------------
+// features, see 'features.go'
+// --------------------------------------------------------
+
+func (s *Server) definition(raw json.RawMessage) (any, *responseError) {
+
+	doc, params, rerr := s.position(raw)
+
+	if doc == nil {
+		return nil, rerr
+	}
+
+	if loc, ok := doc.definition(params.Position) ; ok {
+		return loc, nil
+	}
+
+	return nil, nil
+}
+
+func (s *Server) references(raw json.RawMessage) (any, *responseError) {
+
+	doc, params, rerr := s.position(raw)
+
+	if doc == nil {
+		return []Location{}, rerr
+	}
+
+	return doc.references(params.Position, params.Context.IncludeDeclaration), nil
+}
+
+func (s *Server) hover(raw json.RawMessage) (any, *responseError) {
+
+	doc, params, rerr := s.position(raw)
+
+	if doc == nil {
+		return nil, rerr
+	}
+
+	if h, ok := doc.hover(params.Position) ; ok {
+		return h, nil
+	}
+
+	return nil, nil
+}
+
+func (s *Server) completion(raw json.RawMessage) (any, *responseError) {
+
+	doc, params, rerr := s.position(raw)
+
+	if doc == nil {
+		return []CompletionItem{}, rerr
+	}
+
+	return doc.completion(params.Position), nil
+}
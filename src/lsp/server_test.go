@@ -0,0 +1,143 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const uri = "file:///tmp/main.ms"
+
+const src = `function (int n) >> double -> int {
+    return n * 2;
+}
+var int x;
+3 -> x;
+x >>= double => y;
+function () >> other {
+    1 => hidden;
+}`
+
+// Runs a session with the requests, returns the responses by id
+// and the notifications.
+func session(t *testing.T, requests ...string) (map[int]json.RawMessage, []message) {
+
+	var in bytes.Buffer
+	for _, r := range requests {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(r), r)
+	}
+
+	var out bytes.Buffer
+
+	if err := NewServer(&in, &out).Run() ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	responses := make(map[int]json.RawMessage)
+	notifications := []message{}
+
+	reader := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(reader)
+
+		if err != nil {
+			break
+		}
+
+		if msg.ID == nil {
+			notifications = append(notifications, *msg)
+			continue
+		}
+
+		var id int
+		json.Unmarshal(*msg.ID, &id)
+		responses[id] = msg.Result
+	}
+
+	return responses, notifications
+}
+
+func open(text string) string {
+	body, _ := json.Marshal(text)
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"%s","languageId":"mikescript","version":1,"text":%s}}}`, uri, body)
+}
+
+func at(id int, method string, line, char int) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s","params":{"textDocument":{"uri":"%s"},"position":{"line":%d,"character":%d},"context":{"includeDeclaration":true}}}`, id, method, uri, line, char)
+}
+
+func TestServer(t *testing.T) {
+
+	responses, notifications := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		open(src),
+		at(2, "textDocument/definition", 5, 7),
+		at(3, "textDocument/references", 3, 8),
+		at(4, "textDocument/hover", 5, 7),
+		at(5, "textDocument/hover", 5, 16),
+		at(6, "textDocument/completion", 1, 4),
+		`{"jsonrpc":"2.0","id":7,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	if len(notifications) != 1 || !strings.Contains(string(notifications[0].Params), `"diagnostics":[]`) {
+		t.Errorf("Expected no diagnostics, got %v", notifications)
+	}
+
+	var def Location
+	json.Unmarshal(responses[2], &def)
+	if def.Range.Start != (Position{Line: 0, Character: 20}) {
+		t.Errorf("Expected definition of 'double' at 0:20, got %v", def.Range.Start)
+	}
+
+	var refs []Location
+	json.Unmarshal(responses[3], &refs)
+	if len(refs) != 3 || refs[1].Range.Start != (Position{Line: 4, Character: 5}) {
+		t.Errorf("Expected 3 references of 'x', got %v", refs)
+	}
+
+	var hover Hover
+	json.Unmarshal(responses[4], &hover)
+	if !strings.Contains(hover.Contents.Value, "function (int n) >> double -> int") {
+		t.Errorf("Expected signature of 'double', got '%s'", hover.Contents.Value)
+	}
+
+	json.Unmarshal(responses[5], &hover)
+	if !strings.Contains(hover.Contents.Value, "int y") {
+		t.Errorf("Expected inferred type of 'y', got '%s'", hover.Contents.Value)
+	}
+
+	var items []CompletionItem
+	json.Unmarshal(responses[6], &items)
+	labels := make(map[string]bool)
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+	for _, name := range []string{"n", "double", "x", "print"} {
+		if !labels[name] {
+			t.Errorf("Expected '%s' in completions %v", name, items)
+		}
+	}
+	if labels["hidden"] {
+		t.Errorf("Local 'hidden' of another function should not be completed")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+
+	_, notifications := session(t,
+		open("var int x;\n\"a\" -> x;"),
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	var params publishDiagnosticsParams
+	json.Unmarshal(notifications[0].Params, &params)
+
+	if len(params.Diagnostics) != 1 || params.Diagnostics[0].Range.Start.Line != 1 {
+		t.Errorf("Expected one diagnostic on line 1, got %v", params.Diagnostics)
+	}
+}
@@ -0,0 +1,39 @@
+package mikescript
+
+import (
+	"mikescript/src/ast"
+	"mikescript/src/parser"
+	"mikescript/src/scanner"
+)
+
+// Prints src in the canonical layout, see 'ast.Format'. Sources
+// which do not scan or parse are not formatted.
+func Format(src string) (string, error) {
+
+	s := scanner.MSScanner{}
+	tokens := s.Scan(src)
+
+	if len(s.Errors) > 0 {
+		errs := make([]error, len(s.Errors))
+		for i, err := range s.Errors {
+			errs[i] = err
+		}
+		return "", &StaticError{Stage: "Scanner", Errors: errs}
+	}
+
+	p := parser.MSParser{}
+	p.SetSrc(src)
+	p.SetTokens(tokens)
+
+	program, _ := p.Parse(tokens)
+
+	if len(p.Errors) > 0 {
+		errs := make([]error, len(p.Errors))
+		for i, err := range p.Errors {
+			errs[i] = err
+		}
+		return "", &StaticError{Stage: "Parser", Errors: errs}
+	}
+
+	return ast.Format(program, s.Comments, src), nil
+}
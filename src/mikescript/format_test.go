@@ -0,0 +1,188 @@
+package mikescript
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{
+			input: "if (n <= 1) { 1 >>= print; } else { 2 >>= print; }",
+			expected: "if n <= 1 {\n    1 >>= print;\n} else {\n    2 >>= print;\n}\n",
+		},
+		{
+			input: "function (int x)>>f->int{return x-1;}",
+			expected: "function (int x) >> f -> int {\n    return x - 1;\n}\n",
+		},
+		{
+			input: "a!=b; !(a==b); -x; a + -b;",
+			expected: "a != b;\n!(a == b);\n-x;\na + -b;\n",
+		},
+		{
+			input: "x>>=f; x,y>>g; xs.>>=f; xs.>>f; t*>>=f; t*>>f; =f; .=fs; 0=>x; x->y; 1 -> a[0]; 1 -> p.x;",
+			expected: "x >>= f;\nx, y >> g;\nxs .>>= f;\nxs .>> f;\nt *>>= f;\nt *>> f;\n=f;\n.=fs;\n0 => x;\nx -> y;\n1 -> a[0];\n1 -> p.x;\n",
+		},
+		{
+			input: "for [1 ..3] .-> i { i >>= print; }\nfor [.. 3] .-> i {}",
+			expected: "for [1 .. 3] .-> i {\n    i >>= print;\n}\nfor [.. 3] .-> i {}\n",
+		},
+		{
+			input: "// header\n\n\n1 => x; // one\n\n\n\n// two\n2 => y;\n",
+			expected: "// header\n\n1 => x; // one\n\n// two\n2 => y;\n",
+		},
+		{
+			input: "function () >> f {\n\n    1 => x; // trailing\n    // last\n\n}\n",
+			expected: "function () >> f {\n    1 => x; // trailing\n    // last\n}\n",
+		},
+		{
+			input: "type struct point { float x; float y; }\ntype enum shape { circle(float), empty }",
+			expected: "type struct point {\n    float x;\n    float y;\n}\ntype enum shape {\n    circle(float),\n    empty,\n}\n",
+		},
+		{
+			input: "\"a {x + 1} \\{b\\} \\\"c\\\"\\n\" >>= print; `raw \\n {x}` >>= print;",
+			expected: "\"a {x + 1} \\{b\\} \\\"c\\\"\\n\" >>= print;\n`raw \\n {x}` >>= print;\n",
+		},
+		{
+			input: "(int x) => x * 2 => double; () { 1 >>= print; } => hello; var (->) f; var (int -> nothing) g;",
+			expected: "(int x) => x * 2 => double;\n() { 1 >>= print; } => hello;\nvar (->) f;\nvar (int ->) g;\n",
+		},
+	}
+
+	for _, test := range tests {
+
+		received, err := Format(test.input)
+
+		if err != nil {
+			t.Errorf("Unexpected error formatting '%s': %v", test.input, err)
+			continue
+		}
+
+		if received != test.expected {
+			t.Errorf("Formatting '%s'\nexpected:\n%s\nreceived:\n%s", test.input, test.expected, received)
+		}
+	}
+}
+
+// Comments inside expressions keep their place, formatting again
+// changes nothing and every comment is printed once.
+func TestFormatComments(t *testing.T) {
+
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{
+			input: "[]int{\n  1, // one\n  2, // two\n  3\n} => xs;\n",
+			expected: "[]int{\n    1, // one\n    2, // two\n    3\n} => xs;\n",
+		},
+		{
+			input: "map[string]int{\"a\": 1, // first\n// before b\n\"b\": 2} => m;\n",
+			expected: "map[string]int{\n    \"a\": 1, // first\n    // before b\n    \"b\": 2\n} => m;\n",
+		},
+		{
+			input: "function (int a, // first\n          int b  // second\n) >> add -> int { return a + b; }\n",
+			expected: "function (\n    int a, // first\n    int b // second\n) >> add -> int {\n    return a + b;\n}\n",
+		},
+		{
+			input: "(int a, // first\n int b) => a + b => add;\n",
+			expected: "(\n    int a, // first\n    int b\n) => a + b => add;\n",
+		},
+		{
+			input: "1 + // left\n2 + // right\n3 => s;\n",
+			expected: "1 + 2 + 3 => s; // left\n// right\n",
+		},
+		{
+			input: "(xif | s > 3 => 1 // big\n    otherwise 2) => k; // after\n",
+			expected: "(xif\n    | s > 3 => 1 // big\n    otherwise 2) => k; // after\n",
+		},
+	}
+
+	for _, test := range tests {
+
+		received, err := Format(test.input)
+
+		if err != nil {
+			t.Errorf("Unexpected error formatting '%s': %v", test.input, err)
+			continue
+		}
+
+		if received != test.expected {
+			t.Errorf("Formatting '%s'\nexpected:\n%s\nreceived:\n%s", test.input, test.expected, received)
+		}
+
+		if again, _ := Format(received) ; again != received {
+			t.Errorf("Formatting '%s' twice differs\nfirst:\n%s\nsecond:\n%s", test.input, received, again)
+		}
+
+		for _, line := range strings.Split(test.input, "\n") {
+			if _, comment, ok := strings.Cut(line, "//") ; ok && strings.Count(received, "//" + comment) != 1 {
+				t.Errorf("Formatting '%s' does not print '//%s' once:\n%s", test.input, comment, received)
+			}
+		}
+	}
+}
+
+// Formatted examples give the same output, and formatting them
+// again changes nothing.
+func TestFormatExamples(t *testing.T) {
+
+	paths, err := filepath.Glob(filepath.Join(examples, "*.ms"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+
+			if reason, ok := skipped[filepath.Base(path)] ; ok {
+				t.Skip(reason)
+			}
+
+			src, err := os.ReadFile(path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			formatted, err := Format(string(src))
+
+			if err != nil {
+				t.Fatalf("Unexpected error formatting '%s': %v", path, err)
+			}
+
+			if again, _ := Format(formatted) ; again != formatted {
+				t.Errorf("Formatting '%s' twice differs\nfirst:\n%s\nsecond:\n%s", path, formatted, again)
+			}
+
+			var out bytes.Buffer
+
+			in := NewInterpreter()
+			in.SetStdout(&out)
+			in.SetStderr(&out)
+			in.setModuleDir(filepath.Dir(path))
+
+			if _, err := in.Eval(formatted) ; err != nil {
+				t.Fatalf("Unexpected error running formatted '%s': %v", path, err)
+			}
+
+			expected, err := os.ReadFile(strings.TrimSuffix(path, ".ms") + ".expected")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != string(expected) {
+				t.Errorf("Output of formatted '%s' differs\nexpected:\n%s\nreceived:\n%s", path, expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package mikescript
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"mikescript/src/interp"
+	"mikescript/src/vm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Runs the example programs and compares their output with the
+// '.expected' file next to them. Programs in 'ms/errors' must fail,
+// their error is part of the output. Use 'go test -update' to
+// rewrite the '.expected' files after checking the output. The
+// programs run a second time on the bytecode vm, with the same
+// '.expected' files.
+
+var update = flag.Bool("update", false, "rewrite the .expected files")
+
+const examples = "../ms"
+
+// Programs which do not give the same output every run
+var skipped = map[string]string{
+	"loop_benchmark.ms":	"takes minutes",
+	"quick_sort.ms":		"sorts random values",
+	"selection_sort.ms":	"sorts random values",
+}
+
+// Programs using what the vm does not compile, the compiler must
+// reject them. See 'unsupported' in 'vm/compiler.go'.
+var unsupportedVM = map[string]string{
+	"environment.ms":		"exceptions",
+	"exceptions.ms":		"exceptions",
+	"generics.ms":			"generic functions",
+	"interfaces.ms":		"interfaces",
+	"methods.ms":			"methods",
+	"exit.ms":				"exceptions",
+	"uncaught.ms":			"exceptions",
+}
+
+func runGolden(path string, bytecode bool) (string, error) {
+
+	src, err := os.ReadFile(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+
+	in := NewInterpreter()
+	in.SetStdout(&out)
+	in.SetStderr(&out)
+
+	if bytecode {
+		err = runGoldenVM(in, path, string(src), &out)
+	} else {
+		_, err = in.RunFile(path)
+	}
+
+	// Runtime errors point into the source
+	var rerr *interp.RuntimeError
+	switch {
+	case errors.As(err, &rerr):		out.WriteString(rerr.Report(string(src)) + "\n")
+	case err != nil:				out.WriteString(err.Error() + "\n")
+	}
+
+	return out.String(), err
+}
+
+// Checks the program like 'RunFile', but runs it on the vm
+func runGoldenVM(in *Interpreter, path string, src string, out *bytes.Buffer) error {
+
+	in.setModuleDir(filepath.Dir(path))
+
+	program, vlocals, _, err := in.check(src)
+
+	if err != nil {
+		return err
+	}
+
+	compiler := vm.NewMSCompiler(vlocals)
+	compiler.SetModules(in.modules)
+
+	compiled, err := compiler.Compile(program)
+
+	if err != nil {
+		return err
+	}
+
+	machine := vm.NewVM(compiled)
+	machine.Stdout = out
+	machine.Stderr = out
+
+	_, err = machine.Run()
+
+	return err
+}
+
+func testGolden(t *testing.T, dir string, fails bool, bytecode bool) {
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.ms"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) == 0 {
+		t.Fatalf("No programs found in '%s'", dir)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+
+			if reason, ok := skipped[filepath.Base(path)] ; ok {
+				t.Skip(reason)
+			}
+
+			if feature, ok := unsupportedVM[filepath.Base(path)] ; ok && bytecode {
+
+				_, err := runGolden(path, bytecode)
+
+				if msg := feature + " are not supported on the VM" ; err == nil || !strings.Contains(err.Error(), msg) {
+					t.Errorf("Expected '%s' to fail with '%s', received '%v'", path, msg, err)
+				}
+
+				return
+			}
+
+			received, err := runGolden(path, bytecode)
+
+			if fails && err == nil {
+				t.Errorf("Expected '%s' to fail", path)
+			} else if !fails && err != nil {
+				t.Errorf("Unexpected error running '%s': %v", path, err)
+			}
+
+			golden := strings.TrimSuffix(path, ".ms") + ".expected"
+
+			// The evaluator writes the '.expected' files
+			if *update && !bytecode {
+				if err := os.WriteFile(golden, []byte(received), 0644) ; err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if *update {
+				return
+			}
+
+			expected, err := os.ReadFile(golden)
+
+			if err != nil {
+				t.Fatalf("Missing golden file, run 'go test -update': %v", err)
+			}
+
+			if received != string(expected) {
+				t.Errorf("Output of '%s' differs from '%s'\nexpected:\n%s\nreceived:\n%s", path, golden, expected, received)
+			}
+		})
+	}
+}
+
+func TestExamples(t *testing.T) {
+	testGolden(t, examples, false, false)
+}
+
+func TestErrorExamples(t *testing.T) {
+	testGolden(t, filepath.Join(examples, "errors"), true, false)
+}
+
+func TestExamplesVM(t *testing.T) {
+	testGolden(t, examples, false, true)
+}
+
+func TestErrorExamplesVM(t *testing.T) {
+	testGolden(t, filepath.Join(examples, "errors"), true, true)
+}
@@ -0,0 +1,225 @@
+package mikescript
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mikescript/src/ast"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"mikescript/src/parser"
+	"mikescript/src/resolver"
+	"mikescript/src/scanner"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+Runs MikeScript programs inside a Go program. Programs run by the
+same interpreter share their globals, like the lines of the REPL.
+Values and functions defined in Go are globals as well:
+
+	in := mikescript.NewInterpreter()
+	in.RegisterFunc("double", func(x int) int { return 2 * x })
+	in.SetGlobal("n", 21)
+	res, err := in.Eval("n >>= double;")
+*/
+
+type Interpreter struct {
+	resolver 		resolver.MSResolver
+	typeResolver 	resolver.MSTypeResolver
+	evaluator 		*interp.MSEvaluator
+	modules 		*resolver.MSModuleLoader
+}
+
+func NewInterpreter() *Interpreter {
+
+	in := &Interpreter{
+		typeResolver: resolver.NewMSTypeResolver(nil),
+		evaluator: interp.NewMSEvaluator(),
+	}
+
+	in.setModuleDir(".")
+
+	return in
+}
+
+// Streams used by 'print', 'env' and 'input', the
+// process streams by default.
+
+func (in *Interpreter) SetStdout(w io.Writer) {
+	in.evaluator.Stdout = w
+}
+
+func (in *Interpreter) SetStderr(w io.Writer) {
+	in.evaluator.Stderr = w
+}
+
+func (in *Interpreter) SetStdin(r io.Reader) {
+	in.evaluator.Stdin = r
+}
+
+// Arguments of the programs, the 'args' builtin
+func (in *Interpreter) SetArgs(args []string) {
+	in.evaluator.SetArgs(args)
+}
+
+// Limits of every evaluation, see 'interp/limits.go'. Zero means
+// there is no limit.
+func (in *Interpreter) SetLimits(maxDepth, maxSteps int, timeout time.Duration) {
+	in.evaluator.MaxDepth = maxDepth
+	in.evaluator.MaxSteps = maxSteps
+	in.evaluator.Timeout = timeout
+}
+
+// Called before every statement, see 'interp.Debugger'. nil runs
+// the programs without a debugger.
+func (in *Interpreter) SetDebugger(d interp.Debugger) {
+	in.evaluator.Debugger = d
+}
+
+// Called around function calls and before every statement, see
+// 'interp.Profiler'. nil runs the programs without a profiler.
+func (in *Interpreter) SetProfiler(p interp.Profiler) {
+	in.evaluator.Profiler = p
+}
+
+// Modules are shared between the type resolver and evaluator
+func (in *Interpreter) setModuleDir(dir string) {
+	in.modules = resolver.NewMSModuleLoader(dir)
+	in.typeResolver.SetModules(in.modules)
+	in.evaluator.SetModules(in.modules)
+}
+
+// Runs src, returns the value of the last statement
+func (in *Interpreter) Eval(src string) (interp.MSVal, error) {
+
+	program, vlocals, tlocals, err := in.check(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	in.evaluator.UpdateVLocals(vlocals)
+	in.evaluator.UpdateTLocals(tlocals)
+
+	return in.evaluator.Eval(program)
+}
+
+// Scans, parses and resolves src, a program is only run without
+// errors.
+func (in *Interpreter) check(src string) (*ast.Program, map[*ast.VariableExpNodeS]int, map[*mstype.MSNamedTypeS]int, error) {
+
+	s := scanner.MSScanner{}
+	tokens := s.Scan(src)
+
+	if len(s.Errors) > 0 {
+		errs := make([]error, len(s.Errors))
+		for i, err := range s.Errors {
+			errs[i] = err
+		}
+		return nil, nil, nil, &StaticError{Stage: "Scanner", Errors: errs}
+	}
+
+	p := parser.MSParser{}
+	p.SetSrc(src)
+	p.SetTokens(tokens)
+
+	program, _ := p.Parse(tokens)
+
+	if len(p.Errors) > 0 {
+		errs := make([]error, len(p.Errors))
+		for i, err := range p.Errors {
+			errs[i] = err
+		}
+		return nil, nil, nil, &StaticError{Stage: "Parser", Errors: errs}
+	}
+
+	in.resolver.SetAst(program)
+	in.resolver.Reset()
+	vlocals, tlocals := in.resolver.Resolve()
+
+	in.typeResolver.SetAst(program)
+	in.typeResolver.Reset()
+
+	if typeErrors := in.typeResolver.Resolve() ; len(typeErrors) > 0 {
+		errs := make([]error, len(typeErrors))
+		for i, err := range typeErrors {
+			errs[i] = err
+		}
+		return nil, nil, nil, &StaticError{Stage: "Type", Errors: errs}
+	}
+
+	return program, vlocals, tlocals, nil
+}
+
+// Runs src, the evaluation stops when ctx is done
+func (in *Interpreter) EvalContext(ctx context.Context, src string) (interp.MSVal, error) {
+
+	in.evaluator.SetContext(ctx)
+	defer in.evaluator.SetContext(nil)
+
+	return in.Eval(src)
+}
+
+// Runs the file at path, imports are relative to the file
+func (in *Interpreter) RunFile(path string) (interp.MSVal, error) {
+
+	src, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	in.setModuleDir(filepath.Dir(path))
+
+	return in.Eval(string(src))
+}
+
+// Defines a global, value is converted using 'interp.ToMSVal'
+func (in *Interpreter) SetGlobal(name string, value any) error {
+
+	val, err := interp.ToMSVal(value)
+
+	if err != nil {
+		return err
+	}
+
+	in.typeResolver.DeclareGlobal(name, val.Type())
+	in.evaluator.SetGlobal(name, val)
+
+	return nil
+}
+
+func (in *Interpreter) GetGlobal(name string) (interp.MSVal, bool) {
+	return in.evaluator.GetGlobal(name)
+}
+
+// Defines a global function calling fn, see 'interp.GoFunction'
+func (in *Interpreter) RegisterFunc(name string, fn any) error {
+
+	f, err := interp.NewGoFunction(name, fn)
+
+	if err != nil {
+		return err
+	}
+
+	return in.SetGlobal(name, f)
+}
+
+// Errors of the scanner, parser or type checker. Programs are only
+// evaluated when there are none.
+type StaticError struct {
+	Stage string
+	Errors []error
+}
+
+func (e *StaticError) Error() string {
+	lines := []string{fmt.Sprintf("%s errors:", e.Stage)}
+	for i, err := range e.Errors {
+		lines = append(lines, fmt.Sprintf("[%v]: %v", i, err))
+	}
+	return strings.Join(lines, "\n")
+}
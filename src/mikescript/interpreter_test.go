@@ -0,0 +1,292 @@
+package mikescript
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mikescript/src/interp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const files = `dir + "/notes.txt" => path;
+
+path, "one\n" >>= write_file;
+path, "two\r\nthree\n" >>= append_file;
+
+path >>= read_file => text;
+text == "one\ntwo\r\nthree\n" >>= print;
+path >>= read_lines => lines;
+lines >>= len >>= print;
+lines[1] >>= print;
+
+dir >>= list_dir >>= print;
+path >>= exists >>= print;
+path >>= remove;
+path >>= exists >>= print;
+
+try {
+    path >>= read_file;
+} catch (e) {
+    e.kind >>= print;
+}
+`
+
+func TestFileBuiltins(t *testing.T) {
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+
+	var out bytes.Buffer
+
+	in := NewInterpreter()
+	in.SetStdout(&out)
+	in.SetGlobal("dir", dir)
+
+	if _, err := in.Eval(files) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "true\n3\ntwo\n[a.txt,notes.txt]\ntrue\nfalse\nFileNotFoundError\n"
+
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nreceived:\n%s", expected, out.String())
+	}
+}
+
+func TestLimits(t *testing.T) {
+
+	recursion := "function (int n) >> f -> int { return n + 1 >>= f; } 0 >>= f;"
+	loop := "while true {}"
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		src string
+		maxDepth, maxSteps int
+		timeout time.Duration
+		ctx context.Context
+		target any
+	}{
+		{name: "depth", src: recursion, maxDepth: 100, target: new(*interp.MaxDepthError)},
+		{name: "steps", src: loop, maxSteps: 1000, target: new(*interp.StepLimitError)},
+		{name: "timeout", src: loop, timeout: 10 * time.Millisecond, target: new(*interp.TimeoutError)},
+		{name: "cancellation", src: loop, ctx: canceled, target: new(*interp.CanceledError)},
+	}
+
+	for _, test := range tests {
+
+		in := NewInterpreter()
+		in.SetLimits(test.maxDepth, test.maxSteps, test.timeout)
+
+		ctx := test.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		_, err := in.EvalContext(ctx, test.src)
+
+		if !errors.As(err, test.target) {
+			t.Errorf("Limit '%s': expected %T, received '%v'", test.name, test.target, err)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+
+	tests := []struct {
+		src string
+		code int
+		output string
+	}{
+		{src: "3 >>= exit;", code: 3},
+		{src: "255 >>= exit;", code: 255},
+		{src: "try { 256 >>= exit; } catch (e) { e.kind + \": \" + e.message >>= print; }", code: -1, output: "ValueError: exit code 256 is not between 0 and 255\n"},
+		{src: "try { -1 >>= exit; } catch (e) { e.message >>= print; }", code: -1, output: "exit code -1 is not between 0 and 255\n"},
+	}
+
+	for _, test := range tests {
+
+		var out bytes.Buffer
+
+		in := NewInterpreter()
+		in.SetStdout(&out)
+
+		_, err := in.Eval(test.src)
+
+		var exit *interp.ExitError
+		switch {
+		case test.code >= 0 && (!errors.As(err, &exit) || exit.Code != test.code):
+			t.Errorf("Running '%s': expected exit code %d, received '%v'", test.src, test.code, err)
+		case test.code < 0 && err != nil:
+			t.Errorf("Running '%s': unexpected error %v", test.src, err)
+		}
+
+		if out.String() != test.output {
+			t.Errorf("Running '%s': expected output '%s', received '%s'", test.src, test.output, out.String())
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+
+	var out bytes.Buffer
+
+	in := NewInterpreter()
+	in.SetStdout(&out)
+	in.SetStdin(strings.NewReader("alice\r\nbob"))
+
+	src := `=input => name;
+"hello " + name >>= print;
+=input >>= print;
+try {
+    =input;
+} catch (e) {
+    e.kind >>= print;
+}
+=env;
+`
+
+	if _, err := in.Eval(src) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	received := out.String()
+
+	if expected := "hello alice\nbob\nEOFError\n" ; !strings.HasPrefix(received, expected) {
+		t.Errorf("Expected output starting with:\n%s\nreceived:\n%s", expected, received)
+	}
+
+	// 'env' prints the globals to the same stream
+	if expected := "| name                 | alice" ; !strings.Contains(received, expected) {
+		t.Errorf("Expected 'env' output containing '%s', received:\n%s", expected, received)
+	}
+}
+
+func TestGoGlobals(t *testing.T) {
+
+	var out bytes.Buffer
+
+	in := NewInterpreter()
+	in.SetStdout(&out)
+
+	in.SetGlobal("n", 21)
+	in.RegisterFunc("double", func(x int) int { return 2 * x })
+	in.RegisterFunc("parse", func(s string) (int, error) { return strconv.Atoi(s) })
+
+	src := `n >>= double => m;
+"42" >>= parse >>= print;
+try {
+    "forty-two" >>= parse;
+} catch (e) {
+    e.kind >>= print;
+    e.message >>= print;
+}
+`
+
+	if _, err := in.Eval(src) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "42\nError\nparse: strconv.Atoi: parsing \"forty-two\": invalid syntax\n"
+
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nreceived:\n%s", expected, out.String())
+	}
+
+	m, ok := in.GetGlobal("m")
+
+	if !ok || m != (interp.MSInt{Val: 42}) {
+		t.Errorf("Expected global 'm' to be 42, received '%v'", m)
+	}
+
+	if _, ok := in.GetGlobal("missing") ; ok {
+		t.Errorf("Expected no global 'missing'")
+	}
+
+	if err := in.RegisterFunc("f", 42) ; err == nil {
+		t.Errorf("Expected an error registering a value which is not a function")
+	}
+}
+
+func TestGoPanics(t *testing.T) {
+
+	var out bytes.Buffer
+
+	in := NewInterpreter()
+	in.SetStdout(&out)
+
+	in.RegisterFunc("at", func(xs []int, i int) int { return xs[i] })
+
+	src := `try {
+    []int{1, 2}, 5 >>= at;
+} catch (e) {
+    e.kind >>= print;
+    e.message >>= print;
+}
+`
+
+	if _, err := in.Eval(src) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "Error\nat panicked: runtime error: index out of range [5] with length 2\n"
+
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nreceived:\n%s", expected, out.String())
+	}
+
+	// Not caught, the panic is an error of the program
+	if _, err := in.Eval("[]int{}, 0 >>= at;") ; err == nil || !strings.Contains(err.Error(), "at panicked") {
+		t.Errorf("Expected an error of the panic, received '%v'", err)
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestConversions(t *testing.T) {
+
+	var out bytes.Buffer
+
+	in := NewInterpreter()
+	in.SetStdout(&out)
+
+	in.SetGlobal("origin", point{1, 2})
+	in.SetGlobal("primes", [3]int{2, 3, 5})
+	in.RegisterFunc("swap", func(p point) point { return point{p.Y, p.X} })
+	in.RegisterFunc("sum", func(xs [3]int) int { return xs[0] + xs[1] + xs[2] })
+
+	src := `origin >>= swap => p;
+p >>= print;
+p[0] >>= print;
+primes >>= sum >>= print;
+[3]int{} >>= sum >>= print;
+`
+
+	if _, err := in.Eval(src) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "(2, 1)\n2\n10\n0\n"
+
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nreceived:\n%s", expected, out.String())
+	}
+
+	// The length of a Go array is not part of the MikeScript type
+	if _, err := in.Eval("[2]int{} >>= sum;") ; err == nil {
+		t.Errorf("Expected an error converting an array of the wrong length")
+	}
+
+	if err := in.SetGlobal("hidden", struct{ x int }{1}) ; err == nil {
+		t.Errorf("Expected an error converting a struct with unexported fields")
+	}
+}
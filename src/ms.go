@@ -2,14 +2,26 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"mikescript/src/ast"
+	"mikescript/src/debugger"
 	interp "mikescript/src/interp"
+	"mikescript/src/mikescript"
 	parser "mikescript/src/parser"
+	"mikescript/src/profiler"
+	"mikescript/src/lsp"
 	"mikescript/src/resolver"
 	scanner "mikescript/src/scanner"
+	"mikescript/src/utils"
+	"mikescript/src/vm"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -17,10 +29,13 @@ import (
 type colorLogger struct{
 	c color
 	enable bool
+	w io.Writer		// stdout when nil
 }
 
 func (l *colorLogger) log(s any) {
-	if l.enable {
+	if l.enable && l.w != nil {
+		fmt.Fprintln(l.w, colorText(fmt.Sprintf("%s", s), l.c))
+	} else if l.enable {
 		fmt.Println(colorText(fmt.Sprintf("%s", s), l.c))
 	}
 }
@@ -57,110 +72,218 @@ type MSRunner struct {
 	scanner 	scanner.MSScanner
 	parser 		parser.MSParser
 	resolver 	resolver.MSResolver
+	typeResolver resolver.MSTypeResolver
 	evaluator 	interp.MSEvaluator
-	verbose 	bool
+	modules		*resolver.MSModuleLoader	// shared by the type resolver and the backends
+	bytecode	bool		// run on the bytecode vm instead of the evaluator
+	last		phase		// phase the runner stops after
+	quiet		bool		// do not print the value of the program
+	timings		bool		// print the time every phase takes
+	debug		bool		// print the tokens, the AST and the environment
+	args		[]string	// arguments of the program
 }
 
+// Phases of running a program, 'ms tokens', 'ms ast' and 'ms check'
+// stop after the scanner, parser and resolvers.
+type phase uint8
+const (
+	SCAN phase = iota
+	PARSE
+	RESOLVE
+	EVAL
+)
+
+func newRunner() *MSRunner {
+
+	r := &MSRunner{
+		prompter: 	bufio.NewScanner(os.Stdin),
+		scanner: 	scanner.MSScanner{},
+		parser: 	parser.MSParser{},
+		typeResolver: resolver.NewMSTypeResolver(nil),
+		evaluator: 	*interp.NewMSEvaluator(),
+		last:		EVAL,
+	}
+
+	r.setModuleDir(".")
 
+	return r
+}
+
+// Modules are shared between the type resolver and evaluator, imports
+// are relative to dir.
+func (r *MSRunner) setModuleDir(dir string) {
+	r.modules = resolver.NewMSModuleLoader(dir)
+	r.typeResolver.SetModules(r.modules)
+	r.evaluator.SetModules(r.modules)
+}
+
+// Flags of the commands running programs
+func (r *MSRunner) flags(command string) *flag.FlagSet {
+
+	flags := flag.NewFlagSet("ms " + command, flag.ContinueOnError)
+	flags.BoolVar(&r.quiet, "q", false, "do not print the value of the program")
+	flags.BoolVar(&r.timings, "time", false, "print the time every phase takes")
+	flags.BoolVar(&r.debug, "debug", false, "print the tokens, the syntax tree and the environment")
+	flags.BoolVar(&r.bytecode, "vm", false, "run on the bytecode vm")
+
+	return flags
+}
+
+// Runs input up to the last phase of the runner, returns 1 when one
+// of the phases fails.
 func (r MSRunner) run(input string) int {
 
 	// loggers
-	debug := false
-	scannerlog := colorLogger{c: GRAY, enable: debug}
-	parserlog := colorLogger{c: GRAY, enable: debug}
-	evallog := colorLogger{c: BLUE, enable: true}
-	errorlog := colorLogger{c: RED, enable: true}
-	resolverlog := colorLogger{c: GREEN, enable: debug}
-	timerlog := colorLogger{c: YELLOW, enable: debug}
+	evallog := colorLogger{c: BLUE, enable: !r.quiet}
+	errorlog := colorLogger{c: RED, enable: true, w: os.Stderr}
+	timerlog := colorLogger{c: YELLOW, enable: r.timings, w: os.Stderr}
+
 	//////////////////////////////////////////////////////
-	scannerlog.log("--------------- Scanner ---------------------")
 
 	// call scanner
 	startScan := time.Now()
 	tokens := r.scanner.Scan(input)
-	scanTime := time.Since(startScan)
+	timerlog.log(fmt.Sprintf("Time to scan:           %v", time.Since(startScan)))
 
-	scannerlog.log(fmt.Sprintf("Tokens (%v)", len(tokens)))
-	for i, tk := range tokens {
-		scannerlog.log(fmt.Sprintf(". [ %-3v ]: %-5v %-10v", i, tk.Type, tk.Lexeme))
+	if r.debug || r.last == SCAN {
+		for i, tk := range tokens {
+			fmt.Printf("[ %-3v ]: %-5v %-10v %v:%v\n", i, tk.Type, tk.Lexeme, tk.Line, tk.Col)
+		}
 	}
 
 	if len(r.scanner.Errors) > 0 {
-		errorlog.log(fmt.Sprintf("Scanner errors (%v): \n", len(r.scanner.Errors)))
+		errorlog.log(fmt.Sprintf("Scanner errors (%v):", len(r.scanner.Errors)))
 		for i, err := range r.scanner.Errors {
-			errorlog.log(fmt.Sprintf("[%v]: %v\n", i, err))
+			errorlog.log(fmt.Sprintf("[%v]: %v", i, err))
 		}
-		fmt.Println("")
 		return 1
 	}
 
+	if r.last == SCAN {
+		return 0
+	}
+
 	//////////////////////////////////////////////////////
-	
-	parserlog.log("---------------- Parser ---------------------")
 
 	r.parser.SetSrc(input)
 	r.parser.SetTokens(tokens)
 
 	startParse := time.Now()
-	ast, _ := r.parser.Parse(tokens)
-	parseTime := time.Since(startParse)
+	program, _ := r.parser.Parse(tokens)
+	timerlog.log(fmt.Sprintf("Time to parse:          %v", time.Since(startParse)))
 
-	parserlog.log("AST:")
-	parserlog.log(ast)
+	if r.debug || r.last == PARSE {
+		fmt.Print(ast.Dump(program))
+	}
 
 	if len(r.parser.Errors) > 0 {
 		errorlog.log("Parser errors:")
 		for i, err := range r.parser.Errors {
 			errorlog.log(fmt.Sprintf("[%v]: %v", i, err))
 		}
-		fmt.Println("")
 		return 1
 	}
 
+	if r.last == PARSE {
+		return 0
+	}
+
 	//////////////////////////////////////////////////////
-	resolverlog.log("---------------- Resolver -------------------")
 
 	startResolve := time.Now()
-	r.resolver.SetAst(ast)
+	r.resolver.SetAst(program)
 	r.resolver.Reset()
 	vlocals, tlocals := r.resolver.Resolve()
-	resolverTime := time.Since(startResolve)
+	timerlog.log(fmt.Sprintf("Time to resolve:        %v", time.Since(startResolve)))
 
 	startTypeResolve := time.Now()
-	//r.typeResolver.SetAst(ast)
-	// r.typeResolver.Reset()
-	//r.typeResolver.Resolve()
-	typeResolverTime := time.Since(startTypeResolve)
+	r.typeResolver.SetAst(program)
+	r.typeResolver.Reset()
+	typeErrors := r.typeResolver.Resolve()
+	timerlog.log(fmt.Sprintf("Time to resolve types:  %v", time.Since(startTypeResolve)))
+
+	if len(typeErrors) > 0 {
+		errorlog.log("Type errors:")
+		for i, err := range typeErrors {
+			errorlog.log(fmt.Sprintf("[%v]: %v", i, err))
+		}
+		return 1
+	}
+
+	if r.last == RESOLVE {
+		return 0
+	}
 
 	//////////////////////////////////////////////////////
-	//evallog.log("--------------- Evaluator ---------------------")
+
 	startEval := time.Now()
-	r.evaluator.UpdateVLocals(vlocals)
-	r.evaluator.UpdateTLocals(tlocals)
-	eval, err := r.evaluator.Eval(ast)
-	evalTime := time.Since(startEval)
+	var eval interp.MSVal
+	var err error
 
-	if err != nil {
-		errorlog.log(err)
+	if r.bytecode {
+		eval, err = runBytecode(program, vlocals, r.modules, r.args, r.debug)
+	} else {
+		// Ctrl-C stops the program instead of the REPL
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		r.evaluator.SetContext(ctx)
+		r.evaluator.UpdateVLocals(vlocals)
+		r.evaluator.UpdateTLocals(tlocals)
+		eval, err = r.evaluator.Eval(program)
+		stop()
 	}
-	
-	if debug {
-		evallog.log("Environment:")
+	timerlog.log(fmt.Sprintf("Time to eval:           %v", time.Since(startEval)))
+
+	if r.debug && !r.bytecode {
+		fmt.Println("Environment:")
 		r.evaluator.PrintEnv()
 	}
 
-	timerlog.log(fmt.Sprintf("Time to scan:           %v\n", scanTime))
-	timerlog.log(fmt.Sprintf("Time to parse:          %v\n", parseTime))
-	timerlog.log(fmt.Sprintf("Time to resolve:        %v\n", resolverTime))
-	timerlog.log(fmt.Sprintf("Time to resolve types:  %v\n", typeResolverTime))
-	timerlog.log(fmt.Sprintf("Time to eval:           %v\n", evalTime))
+	// 'exit' ends the program with its status, in the REPL
+	// only the line that called it.
+	var exit *interp.ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+
+	// Runtime errors of both backends point into the source
+	if rerr, ok := err.(*interp.RuntimeError) ; ok {
+		errorlog.log(rerr.Report(input))
+		return 1
+	} else if err != nil {
+		errorlog.log(err)
+		return 1
+	}
 
 	// Print eval result
-	evallog.log(fmt.Sprintf("%v\n", eval))
+	evallog.log(fmt.Sprintf("%v", eval))
 
 	return 0
 }
 
+func runBytecode(program *ast.Program, vlocals map[*ast.VariableExpNodeS]int, modules *resolver.MSModuleLoader, args []string, debug bool) (interp.MSVal, error) {
+
+	compiler := vm.NewMSCompiler(vlocals)
+	compiler.SetModules(modules)
+
+	compiled, err := compiler.Compile(program)
+
+	if err != nil {
+		return interp.MSNothing{}, err
+	}
+
+	if debug {
+		fmt.Println(colorText(vm.Disassemble(compiled.Main), GRAY))
+		for _, m := range compiled.Modules {
+			fmt.Println(colorText(vm.Disassemble(m.Main), GRAY))
+		}
+	}
+
+	machine := vm.NewVM(compiled)
+	machine.Args = args
+
+	return machine.Run()
+}
+
 func (r *MSRunner) isExit(s string) bool {
 	// split string and get first word
 	strs := strings.Split(s, " ")
@@ -269,43 +392,279 @@ func readMSFile(path string) (string, error) {
 	
 }
 
-func main() {
-	// create a new runner
-	runner := MSRunner{
-		prompter: 	bufio.NewScanner(os.Stdin),
-		scanner: 	scanner.MSScanner{},
-		parser: 	parser.MSParser{},
-		evaluator: 	*interp.NewMSEvaluator(),
-		verbose: 	true,
+// Formats the files in place with '-w', otherwise the changes are
+// shown as a diff. Returns 1 when a file is not formatted or can not
+// be formatted.
+func formatFiles(args []string) int {
+
+	write := len(args) > 0 && args[0] == "-w"
+	if write {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		fmt.Println("Usage: ms fmt [-w] <file>...")
+		return 1
+	}
+
+	status := 0
+
+	for _, path := range args {
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+
+		formatted, err := mikescript.Format(string(src))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+
+		switch {
+		case formatted == string(src):
+			continue
+		case write:
+			if err := os.WriteFile(path, []byte(formatted), 0644) ; err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				status = 1
+			}
+		default:
+			fmt.Print(utils.Diff(path, path + " (formatted)", string(src), formatted))
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// Runs the file in the step debugger, commands are read from stdin.
+// Returns 1 when the program fails.
+func debugFile(args []string) int {
+
+	if len(args) == 0 {
+		fmt.Println("Usage: ms debug <file> [args...]")
+		return 1
+	}
+
+	src, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	in := mikescript.NewInterpreter()
+	in.SetDebugger(debugger.NewDebugger(string(src), os.Stdin, os.Stdout))
+	in.SetArgs(args[1:])
+
+	_, err = in.RunFile(args[0])
+
+	var rerr *interp.RuntimeError
+	var exit *interp.ExitError
+	switch {
+	case errors.Is(err, debugger.ErrQuit):
+		return 0
+	case errors.As(err, &exit):
+		return exit.Code
+	case errors.As(err, &rerr):
+		fmt.Fprintln(os.Stderr, colorText(rerr.Report(string(src)), RED))
+		return 1
+	case err != nil:
+		fmt.Fprintln(os.Stderr, colorText(err.Error(), RED))
+		return 1
+	}
+
+	return 0
+}
+
+// Runs the file in the profiler, the report is written to stderr.
+// '-folded out' writes the folded stacks to out as well.
+func profileFile(args []string) int {
+
+	folded := ""
+	if len(args) > 1 && args[0] == "-folded" {
+		folded, args = args[1], args[2:]
+	}
+
+	if len(args) == 0 {
+		fmt.Println("Usage: ms profile [-folded <out>] <file> [args...]")
+		return 1
+	}
+
+	src, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	p := profiler.NewProfiler()
+
+	in := mikescript.NewInterpreter()
+	in.SetProfiler(p)
+	in.SetArgs(args[1:])
+
+	_, err = in.RunFile(args[0])
+	p.Stop()
+
+	status := 0
+
+	var rerr *interp.RuntimeError
+	var exit *interp.ExitError
+	switch {
+	case errors.As(err, &exit):
+		status = exit.Code
+	case errors.As(err, &rerr):
+		fmt.Fprintln(os.Stderr, colorText(rerr.Report(string(src)), RED))
+		status = 1
+	case err != nil:
+		fmt.Fprintln(os.Stderr, colorText(err.Error(), RED))
+		status = 1
 	}
 
-	// Check if we have command line arguments
-	if len(os.Args) > 1 {
+	p.WriteReport(os.Stderr, string(src))
 
-		file, err := os.Open(os.Args[1])
+	if folded != "" {
+
+		file, err := os.Create(folded)
 		if err != nil {
-			fmt.Println("Error reading file: ", err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			return 1
 		}
 		defer file.Close()
 
-		scanner := bufio.NewScanner(file)
-		lines := []string{}
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
+		p.WriteFolded(file)
+	}
+
+	return status
+}
+
+// Runs a program from a file or '-e', args are the command and its
+// flags. The phases after 'last' are skipped.
+func runProgram(command string, args []string, last phase) int {
+
+	runner := newRunner()
+	runner.last = last
+
+	flags := runner.flags(command)
+	code := flags.String("e", "", "run `code` instead of a file")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ms %s [flags] <file> [args...]\n", command)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args) ; err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	src := *code
+	runner.args = flags.Args()
+
+	if src == "" {
+
+		if flags.NArg() == 0 {
+			flags.Usage()
+			return 2
 		}
-		src := strings.Join(lines, "\n")
 
-		fmt.Println("#############################################")
-		fmt.Println(src)
-		fmt.Println("#############################################")
+		b, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, colorText(err.Error(), RED))
+			return 1
+		}
 
-		runner.run(src)
+		// Imports are relative to the file we run, the arguments
+		// after it are those of the program.
+		src = string(b)
+		runner.setModuleDir(filepath.Dir(flags.Arg(0)))
+		runner.args = flags.Args()[1:]
+	}
 
-	} else {
-		// No file provided, run the REPL
-		fmt.Println("MikeScript 1.0 - REPL")
-		fmt.Println("Type 'exit' to quit")
-		runner.mainLoop()
+	runner.evaluator.SetArgs(runner.args)
+
+	return runner.run(src)
+}
+
+func repl(args []string) int {
+
+	runner := newRunner()
+
+	if err := runner.flags("repl").Parse(args) ; err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
 	}
-}
\ No newline at end of file
+
+	fmt.Println("MikeScript 1.0 - REPL")
+	fmt.Println("Type 'exit' to quit")
+	runner.mainLoop()
+
+	return 0
+}
+
+// Serves the language server protocol on stdio
+func serveLsp() int {
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run() ; err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+const usage = `Usage: ms <command> [flags] [arguments]
+
+Commands:
+    run <file> [args...]
+                        run a program, the default when given a file
+    repl                start the REPL, the default without arguments
+    check <file>        scan, parse and resolve a program without running it
+    tokens <file>       print the tokens of a program
+    ast <file>          print the syntax tree of a program
+    fmt [-w] <file>...  format programs, see 'ms fmt'
+    debug <file> [args...]
+                        run a program in the step debugger
+    profile [-folded <out>] <file> [args...]
+                        run a program in the profiler
+    lsp                 serve the language server protocol on stdio
+
+Flags of run, repl, check, tokens and ast:
+    -e <code>           run code instead of a file
+    -q                  do not print the value of the program
+    -time               print the time every phase takes
+    -debug              print the tokens, the syntax tree and the environment
+    -vm                 run on the bytecode vm
+`
+
+func main() {
+
+	args := os.Args[1:]
+
+	if len(args) == 0 {
+		os.Exit(repl(nil))
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "run":		os.Exit(runProgram(command, args, EVAL))
+	case "check":	os.Exit(runProgram(command, args, RESOLVE))
+	case "ast":		os.Exit(runProgram(command, args, PARSE))
+	case "tokens":	os.Exit(runProgram(command, args, SCAN))
+	case "repl":	os.Exit(repl(args))
+	case "fmt":		os.Exit(formatFiles(args))
+	case "debug":	os.Exit(debugFile(args))
+	case "profile":	os.Exit(profileFile(args))
+	case "lsp":		os.Exit(serveLsp())
+	case "help", "-h", "-help", "--help":	fmt.Print(usage)
+	default:
+		// 'ms file.ms' and 'ms -vm file.ms' run the file
+		os.Exit(runProgram("run", os.Args[1:], EVAL))
+	}
+}
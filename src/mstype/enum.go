@@ -0,0 +1,76 @@
+package mstype
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tagged union, a value of an enum type is exactly one of the
+// variants and carries the values of that variant's payload.
+type MSEnumTypeS struct {
+	Name string
+	Variants []MSVariantS
+}
+
+type MSVariantS struct {
+	Name string
+	Types []MSType		// payload, empty for plain variants
+}
+
+func (t *MSEnumTypeS) Eq(o MSType) bool {
+
+	other, ok := o.(*MSEnumTypeS)
+
+	if !ok {
+		return false
+	}
+
+	if other.Name != t.Name || len(other.Variants) != len(t.Variants) {
+		return false
+	}
+
+	// Payloads can refer to the enum itself, so only
+	// the shape of the variants is compared.
+	for i, v := range t.Variants {
+		ov := other.Variants[i]
+		if ov.Name != v.Name || len(ov.Types) != len(v.Types) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t *MSEnumTypeS) String() string {
+	variants := []string{}
+	for _, v := range t.Variants {
+		variants = append(variants, v.String())
+	}
+	return fmt.Sprintf("%v{%v}", t.Name, strings.Join(variants, ", "))
+}
+
+func (t *MSEnumTypeS) Nullable() bool {
+	return true
+}
+
+func (t *MSEnumTypeS) Variant(name string) (MSVariantS, bool) {
+	for _, v := range t.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return MSVariantS{}, false
+}
+
+func (v MSVariantS) String() string {
+
+	if len(v.Types) == 0 {
+		return v.Name
+	}
+
+	types := []string{}
+	for _, t := range v.Types {
+		types = append(types, t.String())
+	}
+	return fmt.Sprintf("%v(%v)", v.Name, strings.Join(types, ", "))
+}
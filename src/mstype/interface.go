@@ -0,0 +1,42 @@
+package mstype
+
+import "fmt"
+
+// Set of methods, a struct satisfies the interface when it has a
+// method of the same type for each of them. Values of an interface
+// type are dispatched to the method of the struct they hold.
+type MSInterfaceTypeS struct {
+	Name string
+	Methods map[string]*MSOperationTypeS	// method types, receiver bound
+}
+
+func (t *MSInterfaceTypeS) Eq(o MSType) bool {
+
+	other, ok := o.(*MSInterfaceTypeS)
+
+	if !ok {
+		return false
+	}
+
+	if other.Name != t.Name || len(other.Methods) != len(t.Methods) {
+		return false
+	}
+
+	// Methods can refer to the interface itself, so only
+	// the names of the methods are compared.
+	for name := range t.Methods {
+		if _, ok := other.Methods[name] ; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t *MSInterfaceTypeS) String() string {
+	return fmt.Sprintf("%v{%v}", t.Name, t.Methods)
+}
+
+func (t *MSInterfaceTypeS) Nullable() bool {
+	return true
+}
@@ -0,0 +1,29 @@
+package mstype
+
+import "fmt"
+
+// Keyed collection, 'map[K]V'. Keys are values of simple types.
+type MSMapTypeS struct {
+	Key MSType
+	Value MSType
+}
+
+func (t *MSMapTypeS) Eq(o MSType) bool {
+	switch other := o.(type) {
+	case *MSMapTypeS:	return t.Key.Eq(other.Key) && t.Value.Eq(other.Value)
+	default:			return false
+	}
+}
+
+func (t *MSMapTypeS) String() string {
+	return fmt.Sprintf("map[%s]%s", t.Key.String(), t.Value.String())
+}
+
+func (t *MSMapTypeS) Nullable() bool {
+	return false
+}
+
+// Can values of this type be used as map keys?
+func IsKeyType(t MSType) bool {
+	return MS_INT.Eq(t) || MS_FLOAT.Eq(t) || MS_STRING.Eq(t) || MS_BOOL.Eq(t)
+}
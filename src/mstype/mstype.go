@@ -13,6 +13,7 @@ const (
 	RT_FLOAT
 	RT_STRING
 	RT_BOOL
+	RT_ERROR
 
 	RT_TUPLE
 	RT_FUNCTION
@@ -30,6 +31,7 @@ func (res ResultType) String() string {
 	case RT_FLOAT:		return "float"
 	case RT_STRING:		return "string"
 	case RT_BOOL:		return "bool"
+	case RT_ERROR:		return "error"
 
 	// composite types
 	case RT_TUPLE:		return "tuple"
@@ -68,6 +70,9 @@ var MS_INT MSType = &MSSimpleTypeS{Rt: RT_INT}
 var MS_FLOAT MSType = &MSSimpleTypeS{Rt: RT_FLOAT}
 var MS_STRING MSType = &MSSimpleTypeS{Rt: RT_STRING}
 
+// Errors thrown using 'throw' or by failing operations
+var MS_ERROR MSType = &MSSimpleTypeS{Rt: RT_ERROR}
+
 // The nothing type contains no elements, there is no
 // possible value this type can produce.
 var MS_NOTHING MSType = &MSSimpleTypeS{Rt: RT_NOTHING}
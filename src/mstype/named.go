@@ -1,23 +1,57 @@
 package mstype
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type MSNamedTypeS struct {
 	Name string
 	Depth int			// scope depth where defined, used to compare named types
+	Args []MSType		// type arguments of a generic struct, 'box<int>'
+	Namespace string	// module the type is declared in, empty for local types
 }
 
 func (t *MSNamedTypeS) Eq(o MSType) bool {
 	switch other := o.(type) {
-	case *MSNamedTypeS:		return other.Name == t.Name && other.Depth == t.Depth
+	case *MSNamedTypeS:		return other.Name == t.Name && other.Namespace == t.Namespace && other.Depth == t.Depth && typesEq(t.Args, other.Args)
 	default:				return false
 	}
 }
 
 func (t *MSNamedTypeS) String() string {
-	return fmt.Sprintf("%v", t.Name)
+
+	name := t.Name
+	if t.Namespace != "" {
+		name = t.Namespace + "." + t.Name
+	}
+
+	if len(t.Args) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%v<%v>", name, typesString(t.Args))
 }
 
 func (t *MSNamedTypeS) Nullable() bool {
 	return false
 }
+
+func typesEq(ts, os []MSType) bool {
+	if len(ts) != len(os) {
+		return false
+	}
+	for i := range ts {
+		if !ts[i].Eq(os[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func typesString(ts []MSType) string {
+	strs := []string{}
+	for _, t := range ts {
+		strs = append(strs, t.String())
+	}
+	return strings.Join(strs, ", ")
+}
@@ -5,6 +5,8 @@ import "fmt"
 type MSStructTypeS struct {
 	Name string
 	Fields map[string]MSType
+	Params []string			// type parameters of a generic struct declaration
+	Args []MSType			// type arguments when instantiated, 'box<int>'
 }
 
 func (t *MSStructTypeS) Eq(o MSType) bool {
@@ -34,9 +36,38 @@ func (t *MSStructTypeS) Eq(o MSType) bool {
 }
 
 func (t *MSStructTypeS) String() string {
+	if len(t.Args) > 0 {
+		return fmt.Sprintf("%v<%v>{%v}", t.Name, typesString(t.Args), t.Fields)
+	}
 	return fmt.Sprintf("%v{%v}", t.Name, t.Fields)
 }
 
+// Creates the struct type for the type arguments, the type
+// parameters in the fields are replaced by the arguments.
+func (t *MSStructTypeS) Instantiate(args []MSType) (*MSStructTypeS, error) {
+
+	if len(args) != len(t.Params) {
+		msg := fmt.Sprintf("Type '%s' expects %d type argument(s), got %d", t.Name, len(t.Params), len(args))
+		return nil, &MSTypeError{Msg: msg}
+	}
+
+	subst := make(map[string]MSType)
+	for i, p := range t.Params {
+		subst[p] = args[i]
+	}
+
+	fields := make(map[string]MSType)
+	for name, ft := range t.Fields {
+		fields[name] = Substitute(ft, subst)
+	}
+
+	return &MSStructTypeS{Name: t.Name, Fields: fields, Args: args}, nil
+}
+
+func (t *MSStructTypeS) Generic() bool {
+	return len(t.Params) > 0
+}
+
 func (t *MSStructTypeS) Nullable() bool {
 	return true
 }
@@ -0,0 +1,160 @@
+package mstype
+
+import "fmt"
+
+// Type parameter of a generic function or struct, 'T' in
+// 'function<T> (T x) >> f -> T {...}'. Type variables are
+// replaced by concrete types when arguments are bound.
+type MSTypeVarS struct {
+	Name string
+}
+
+func (t *MSTypeVarS) Eq(o MSType) bool {
+	switch other := o.(type) {
+	case *MSTypeVarS:	return other.Name == t.Name
+	default:			return false
+	}
+}
+
+func (t *MSTypeVarS) String() string {
+	return t.Name
+}
+
+func (t *MSTypeVarS) Nullable() bool {
+	return false
+}
+
+// --------------------------------------------------------
+// substitution
+// --------------------------------------------------------
+
+// Replaces the type variables in t by the types they are bound
+// to in subst. Unbound type variables are left as they are.
+func Substitute(t MSType, subst map[string]MSType) MSType {
+
+	if len(subst) == 0 {
+		return t
+	}
+
+	switch tt := t.(type) {
+	case *MSTypeVarS:
+
+		if bound, ok := subst[tt.Name] ; ok {
+			return bound
+		}
+
+		return tt
+
+	case *MSArrayType:
+		return &MSArrayType{Type: Substitute(tt.Type, subst)}
+	case *MSMapTypeS:
+		return &MSMapTypeS{Key: Substitute(tt.Key, subst), Value: Substitute(tt.Value, subst)}
+	case *MSCompositeTypeS:
+		return &MSCompositeTypeS{Types: SubstituteList(tt.Types, subst)}
+	case *MSOperationTypeS:
+		return &MSOperationTypeS{Left: SubstituteList(tt.Left, subst), Right: Substitute(tt.Right, subst)}
+	case *MSNamedTypeS:
+
+		// Named types are only substituted when needed, the
+		// evaluator looks them up by pointer.
+		if len(tt.Args) == 0 {
+			return tt
+		}
+
+		return &MSNamedTypeS{Name: tt.Name, Depth: tt.Depth, Args: SubstituteList(tt.Args, subst), Namespace: tt.Namespace}
+
+	case *MSStructTypeS:
+
+		if len(tt.Args) == 0 {
+			return tt
+		}
+
+		fields := make(map[string]MSType)
+		for name, ft := range tt.Fields {
+			fields[name] = Substitute(ft, subst)
+		}
+
+		return &MSStructTypeS{Name: tt.Name, Fields: fields, Args: SubstituteList(tt.Args, subst)}
+	}
+
+	return t
+}
+
+func SubstituteList(ts []MSType, subst map[string]MSType) []MSType {
+	substituted := make([]MSType, len(ts))
+	for i, t := range ts {
+		substituted[i] = Substitute(t, subst)
+	}
+	return substituted
+}
+
+// --------------------------------------------------------
+// unification
+// --------------------------------------------------------
+
+// Binds the type variables in param to the matching parts of arg
+// and adds them to subst. Only fails when a type variable was
+// already bound to a different type, other mismatches are left
+// to the caller which compares the substituted param with arg.
+func Unify(param, arg MSType, subst map[string]MSType) error {
+
+	switch pt := param.(type) {
+	case *MSTypeVarS:
+
+		bound, ok := subst[pt.Name]
+
+		if !ok {
+			subst[pt.Name] = arg
+			return nil
+		}
+
+		if !bound.Eq(arg) {
+			msg := fmt.Sprintf("type parameter '%s' is bound to '%s' and '%s'", pt.Name, bound, arg)
+			return &MSTypeError{Msg: msg}
+		}
+
+		return nil
+
+	case *MSArrayType:
+		if at, ok := arg.(*MSArrayType) ; ok {
+			return Unify(pt.Type, at.Type, subst)
+		}
+	case *MSMapTypeS:
+		if at, ok := arg.(*MSMapTypeS) ; ok {
+			return UnifyList([]MSType{pt.Key, pt.Value}, []MSType{at.Key, at.Value}, subst)
+		}
+	case *MSCompositeTypeS:
+		if at, ok := arg.(*MSCompositeTypeS) ; ok {
+			return UnifyList(pt.Types, at.Types, subst)
+		}
+	case *MSOperationTypeS:
+		if at, ok := arg.(*MSOperationTypeS) ; ok {
+			if err := UnifyList(pt.Left, at.Left, subst) ; err != nil {
+				return err
+			}
+			return Unify(pt.Right, at.Right, subst)
+		}
+	case *MSStructTypeS:
+		// Instances of the same generic struct
+		if at, ok := arg.(*MSStructTypeS) ; ok && at.Name == pt.Name {
+			return UnifyList(pt.Args, at.Args, subst)
+		}
+	}
+
+	return nil
+}
+
+func UnifyList(params, args []MSType, subst map[string]MSType) error {
+
+	if len(params) != len(args) {
+		return nil
+	}
+
+	for i := range params {
+		if err := Unify(params[i], args[i], subst) ; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -64,7 +64,7 @@ func (p *MSParser) parseIndexing(target ast.ExpNodeI) (*ast.ArrayIndexNodeS, err
 		return nil, p.unexpectedToken(op, token.RIGHT_SQUARE)
 	}
 
-	return &ast.ArrayIndexNodeS{Target: target, Index: index}, nil
+	return &ast.ArrayIndexNodeS{Target: target, Index: index, Tk: tok}, nil
 
 }
 
@@ -88,10 +88,11 @@ func (p *MSParser) parseStructFieldAccess(target ast.ExpNodeI) (*ast.FieldAccess
 
 }
 
-func (p *MSParser) parseArrayExpression() (ast.ExpNodeI, error) {
+func (p *MSParser) parseArrayExpression(tk token.Token) (ast.ExpNodeI, error) {
 	// 1) exp? ']' type '{' {expression ','} * '}' --> array constructor
 	// or 
 	// 2) exp? '..' exp? ']'
+	// '[' is already consumed and given
 
 	var n ast.ExpNodeI
 	var err error
@@ -107,15 +108,15 @@ func (p *MSParser) parseArrayExpression() (ast.ExpNodeI, error) {
 
 	// Check for '..' or ']'
 	if ok, _ := p.match(token.DOT_DOT) ; ok {
-		return p.parseRangeConstructor(n)
+		return p.parseRangeConstructor(tk, n)
 	} else if ok, _ := p.match(token.RIGHT_SQUARE) ; ok {
-		return p.parseArrayConstructor(n)
+		return p.parseArrayConstructor(tk, n)
 	}
 
 	return nil , err
 }
 
-func (p *MSParser) parseRangeConstructor(start ast.ExpNodeI) (ast.ExpNodeI, error) {
+func (p *MSParser) parseRangeConstructor(tk token.Token, start ast.ExpNodeI) (ast.ExpNodeI, error) {
 	// parses:  exp? ']'
 
 	var to ast.ExpNodeI = nil
@@ -139,10 +140,10 @@ func (p *MSParser) parseRangeConstructor(start ast.ExpNodeI) (ast.ExpNodeI, erro
 		start = &ast.LiteralExpNodeS{Tk: token.Token{Type: token.NUMBER_INT, Lexeme: "0", Line: 0, Col: 0}}
 	}
 
-	return &ast.RangeConstructorNodeS{From: start, To: to}, err
+	return &ast.RangeConstructorNodeS{From: start, To: to, Tk: tk}, err
 }
 
-func (p *MSParser) parseArrayConstructor(n ast.ExpNodeI) (ast.ExpNodeI, error) {
+func (p *MSParser) parseArrayConstructor(tk token.Token, n ast.ExpNodeI) (ast.ExpNodeI, error) {
 
 	// Need type
 	atype, err := p.parseType()
@@ -159,7 +160,7 @@ func (p *MSParser) parseArrayConstructor(n ast.ExpNodeI) (ast.ExpNodeI, error) {
 	// check for empty constructor
 	if ok, _ := p.match(token.RIGHT_BRACE) ; ok {
 		vals := make([]ast.ExpNodeI, 0)
-		return &ast.ArrayConstructorNodeS{Type: atype, Vals: vals, N: n}, nil
+		return &ast.ArrayConstructorNodeS{Type: atype, Vals: vals, N: n, Tk: tk}, nil
 	}
 
 	if n != nil {
@@ -178,9 +179,9 @@ func (p *MSParser) parseArrayConstructor(n ast.ExpNodeI) (ast.ExpNodeI, error) {
 	exprs := flattenExpNode(tuple)
 
 	// Need '}'
-	if ok, tk := p.match(token.RIGHT_BRACE) ; !ok {
-		return nil, p.unexpectedToken(tk, token.RIGHT_BRACE)
+	if ok, tok := p.match(token.RIGHT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.RIGHT_BRACE)
 	}
 
-	return &ast.ArrayConstructorNodeS{Type: atype, Vals: exprs, N: n}, nil
+	return &ast.ArrayConstructorNodeS{Type: atype, Vals: exprs, N: n, Tk: tk}, nil
 }
\ No newline at end of file
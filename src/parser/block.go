@@ -9,28 +9,39 @@ import (
 
 func (parser *MSParser) parseBlock() (*ast.BlockNodeS, error) {
 	// This function expects that a '{' was already matched before
-	// but WILL consume the closing '}'.
+	// but WILL consume the closing '}'. Failing statements are
+	// skipped, their errors are in parser.Errors.
 
 	stmts := []ast.StmtNodeI{}
+	start := parser.tokens[parser.pos-1]
 	var err error
-	var stmt ast.StmtNodeI
 
-	for !parser.atend() && parser.peek().Type != token.RIGHT_BRACE {
+	parser.depth++
+	defer func() {
+		parser.depth--
+	}()
 
-		stmt, err = parser.parseStatement()
+	for !parser.atend() && !parser.checkType(token.RIGHT_BRACE) && !parser.checkType(token.EOF) {
 
-		if err == nil {
+		start := parser.pos
+		stmt, serr := parser.parseStatement()
+
+		if serr == nil {
 			stmts = append(stmts, stmt)
-		} else {
-			return nil, err
+		}
+
+		if parser.pnc || serr != nil {
+			parser.synchronize(start)
 		}
 	}
 
-	if ok, tok := parser.expect(token.RIGHT_BRACE); !ok {
-		msg := fmt.Sprintf("Expected '}' got '%v'", tok.Type.String())
-		err = parser.error(msg, tok.Line, tok.Col)
+	ok, end := parser.expect(token.RIGHT_BRACE)
+
+	if !ok {
+		msg := fmt.Sprintf("Expected '}' got '%v'", end.Type.String())
+		err = parser.error(msg, end.Line, end.Col)
 	}
 
-	return &ast.BlockNodeS{Statements: stmts}, err
+	return &ast.BlockNodeS{Statements: stmts, Start: start, End: end}, err
 }
 
@@ -68,5 +68,5 @@ func (parser *MSParser) parseReturn(tk token.Token) (*ast.ReturnNodeS, error) {
 
 	}
 
-	return &ast.ReturnNodeS{Node: val}, err
+	return &ast.ReturnNodeS{Node: val, Tk: tk}, err
 }
\ No newline at end of file
@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	token "mikescript/src/token"
+)
+
+func (p *MSParser) parseEnumDeclaration() (*ast.EnumDeclarationNodeS, error) {
+	// parses: IDENTIFIER '{' variant {',' variant}* ','? '}'
+
+	var variants []ast.EnumVariantS
+	var variant ast.EnumVariantS
+	var ename *ast.VariableExpNodeS
+	var err error
+
+	ename, err = p.parseIdentifier()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, tok := p.expect(token.LEFT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.LEFT_BRACE)
+	}
+
+	for {
+
+		if ok, _ := p.lookahead(token.RIGHT_BRACE) ; ok {
+			break
+		}
+
+		variant, err = p.parseEnumVariant()
+
+		if err != nil {
+			return nil, err
+		}
+
+		variants = append(variants, variant)
+
+		// break ok no ','
+		if ok, _ := p.match(token.COMMA) ; !ok {
+			break
+		}
+	}
+
+	// we want closing brace
+	if ok, tok := p.match(token.RIGHT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.RIGHT_BRACE)
+	}
+
+	return &ast.EnumDeclarationNodeS{Name: ename, Variants: variants}, nil
+}
+
+func (p *MSParser) parseEnumVariant() (ast.EnumVariantS, error) {
+	// parses: IDENTIFIER
+	// parses: IDENTIFIER '(' typelist ')'
+
+	vname, err := p.parseIdentifier()
+
+	if err != nil {
+		return ast.EnumVariantS{}, err
+	}
+
+	types := []mstype.MSType{}
+
+	// plain variant, no payload
+	if ok, _ := p.match(token.LEFT_PAREN) ; !ok {
+		return ast.EnumVariantS{Name: vname, Types: types}, nil
+	}
+
+	types, err = p.parseTypeList()
+
+	if err != nil {
+		return ast.EnumVariantS{}, err
+	}
+
+	if ok, tok := p.match(token.RIGHT_PAREN) ; !ok {
+		return ast.EnumVariantS{}, p.unexpectedToken(tok, token.RIGHT_PAREN)
+	}
+
+	return ast.EnumVariantS{Name: vname, Types: types}, nil
+}
+
+func (p *MSParser) parseMatch(tk token.Token) (*ast.MatchNodeS, error) {
+	// parses: {'->' type}? '{' { arm ';' }* '}'
+	// 'match' is already consumed and given
+
+	var rt mstype.MSType
+	var arms []ast.MatchArmS
+	var arm ast.MatchArmS
+	var err error
+
+	// Without a '->' the type resolver infers the result type
+	if ok, _ := p.match(token.MINUS_GREAT) ; ok {
+		rt, err = p.parseType()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, tok := p.expect(token.LEFT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.LEFT_BRACE)
+	}
+
+	for {
+
+		if ok, _ := p.lookahead(token.RIGHT_BRACE) ; ok {
+			break
+		}
+
+		arm, err = p.parseMatchArm()
+
+		if err != nil {
+			return nil, err
+		}
+
+		arms = append(arms, arm)
+
+		// break ok no ';'
+		if ok, _ := p.match(token.SEMICOLON) ; !ok {
+			break
+		}
+	}
+
+	if ok, tok := p.match(token.RIGHT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.RIGHT_BRACE)
+	}
+
+	return &ast.MatchNodeS{Tk: tk, Arms: arms, Rt: rt}, nil
+}
+
+func (p *MSParser) parseMatchArm() (ast.MatchArmS, error) {
+	// parses: IDENTIFIER {'(' IDENTIFIER {',' IDENTIFIER}* ')'}? '=>' expression
+
+	var bindings []*ast.VariableExpNodeS
+	var binding *ast.VariableExpNodeS
+
+	variant, err := p.parseIdentifier()
+
+	if err != nil {
+		return ast.MatchArmS{}, err
+	}
+
+	if ok, _ := p.match(token.LEFT_PAREN) ; ok {
+
+		for {
+			binding, err = p.parseIdentifier()
+
+			if err != nil {
+				return ast.MatchArmS{}, err
+			}
+
+			bindings = append(bindings, binding)
+
+			if ok, _ := p.match(token.COMMA) ; !ok {
+				break
+			}
+		}
+
+		if ok, tok := p.match(token.RIGHT_PAREN) ; !ok {
+			return ast.MatchArmS{}, p.unexpectedToken(tok, token.RIGHT_PAREN)
+		}
+	}
+
+	if ok, tok := p.expect(token.EQ_GREATER) ; !ok {
+		return ast.MatchArmS{}, p.unexpectedToken(tok, token.EQ_GREATER)
+	}
+
+	body, err := p.parseExpression()
+
+	if err != nil {
+		return ast.MatchArmS{}, err
+	}
+
+	return ast.MatchArmS{Variant: variant, Bindings: bindings, Body: body}, nil
+}
@@ -11,3 +11,11 @@ type ParserError struct {
 func (err ParserError) Error() string {
 	return fmt.Sprintf("Parsing Error: %v at line %v col %v", err.msg, err.line, err.col)
 }
+
+func (err ParserError) Message() string {
+	return err.msg
+}
+
+func (err ParserError) Position() (int, int) {
+	return err.line, err.col
+}
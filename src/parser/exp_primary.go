@@ -15,6 +15,11 @@ func (parser *MSParser) parsePrimary() (ast.ExpNodeI, error) {
 	// 4. '(' expr ')'
 	// 5. '[' exp ']' type '{' exp ? {',' exp}* '}'
 	// 6. 'some<' exp '>'
+	// 7. 'match' {'->' type}? '{' ... '}'
+	// 8. 'xif' { '|' exp '=>' exp }+ 'otherwise' exp
+	// 9. 'map' '[' type ']' type '{' { exp ':' exp ',' }* '}'
+	// 10. STRING_HEAD exp { STRING_MID exp }* STRING_TAIL
+	// 11. '(' params ')' {'->' type}? ( block | '=>' exp )
 
 	var err error = nil
 
@@ -30,13 +35,37 @@ func (parser *MSParser) parsePrimary() (ast.ExpNodeI, error) {
 	}
 
 	// 4. '(' expr ')'
+	// 11. '(' params ')' {'->' type}? ( block | '=>' exp )
 	if ok, _ := parser.lookahead(token.LEFT_PAREN); ok {
+		if parser.isLambda() {
+			return parser.parseLambda()
+		}
 		return parser.parseGroupExpression()
 	}
 
 	// 5. '[' exp ']' type '{' exp ? {',' exp}* '}'
-	if ok, _ := parser.match(token.LEFT_SQUARE) ; ok {
-		return parser.parseArrayExpression()
+	if ok, tk := parser.match(token.LEFT_SQUARE) ; ok {
+		return parser.parseArrayExpression(tk)
+	}
+
+	// 7. 'match' {'->' type}? '{' ... '}'
+	if ok, tok := parser.match(token.MATCH) ; ok {
+		return parser.parseMatch(tok)
+	}
+
+	// 8. 'xif' { '|' exp '=>' exp }+ 'otherwise' exp
+	if ok, tok := parser.match(token.XIF) ; ok {
+		return parser.parseXif(tok, false)
+	}
+
+	// 9. 'map' '[' type ']' type '{' { exp ':' exp ',' }* '}'
+	if ok, tok := parser.match(token.MAP) ; ok {
+		return parser.parseMapConstructor(tok)
+	}
+
+	// 10. STRING_HEAD exp { STRING_MID exp }* STRING_TAIL
+	if ok, tok := parser.match(token.STRING_HEAD) ; ok {
+		return parser.parseInterpolation(tok)
 	}
 
 	// If we reach this point, we couldn't match any
@@ -23,7 +23,7 @@ func (parser *MSParser) parseUnary() (ast.ExpNodeI, error) {
 		switch op.Type {
 		case token.EQ: 		return &ast.FuncCallNodeS{Op: op, Fun: right}, nil
 		case token.DOT_EQ:	return &ast.IterableFuncCallNodeS{Op: op, Fun: right}, nil
-		case token.MULT:	return &ast.StarredExpNodeS{Node: right}, nil
+		case token.MULT:	return &ast.StarredExpNodeS{Node: right, Tk: op}, nil
 		default: 			return &ast.UnaryExpNodeS{Op: op, Node: right}, nil
 		}
 	}
@@ -58,7 +58,7 @@ func (parser *MSParser) parseFuncop() (ast.ExpNodeI, error) {
 			lexpressions := flattenExpNode(left)
 
 			// Function application
-			left = &ast.FuncAppNodeS{Args: lexpressions, Fun: right}
+			left = &ast.FuncAppNodeS{Args: lexpressions, Fun: right, Op: op}
 
 			// also wrap with call?
 			if op.Type == token.GREATER_GREATER_EQ {
@@ -67,25 +67,27 @@ func (parser *MSParser) parseFuncop() (ast.ExpNodeI, error) {
 
 		case token.DOT_GREATER_GREATER:
 			// .>>  unpacked function application (parameter binding)
-			left = &ast.IterableFuncAppNodeS{Args: left, Fun: right}
+			left = &ast.IterableFuncAppNodeS{Args: left, Fun: right, Op: op}
 		case token.DOT_GREATER_GREATER_EQ:
 			// .>>= unpacked function application && call. 'e1, e2 .>>= f' is syntactic sugar for '.=(e1 >> f, e2 >> f ... en >> f)'
-			left = &ast.IterableFuncAppAndCallNodeS{Args: left, Fun: right}
+			left = &ast.IterableFuncAppAndCallNodeS{Args: left, Fun: right, Op: op}
 		case token.MULT_GREATER_GREATER:
 			// *>> unpacked function application (parameter binding)
 			// 1. wrap lhs with star
 			// 2. call function application
 			left = &ast.FuncAppNodeS{
-				Args: []ast.ExpNodeI{&ast.StarredExpNodeS{Node: left}},
+				Args: []ast.ExpNodeI{&ast.StarredExpNodeS{Node: left, Tk: op}},
 				Fun:  right,
+				Op:   op,
 			}
 		case token.MULT_GREATER_GREATER_EQ:
 			// *>>= unpacked function application && call. 'e1, e2 *>>= f' is syntactic sugar for '*=(e1 >> f, e2 >> f ... en >> f)'
 			left = &ast.FuncCallNodeS{
 				Op: op,
 				Fun: &ast.FuncAppNodeS{
-					Args: []ast.ExpNodeI{&ast.StarredExpNodeS{Node: left}},
+					Args: []ast.ExpNodeI{&ast.StarredExpNodeS{Node: left, Tk: op}},
 					Fun:  right,
+					Op:   op,
 				},
 			}
 		case token.MINUS_GREAT:
@@ -96,7 +98,7 @@ func (parser *MSParser) parseFuncop() (ast.ExpNodeI, error) {
 			case *ast.VariableExpNodeS:
 				left = &ast.AssignmentNodeS{Identifier: v, Exp: left}
 			case *ast.ArrayIndexNodeS:
-				left = &ast.ArrayAssignmentNodeS{Target: v.Target, Index: v.Index, Value: left}
+				left = &ast.ArrayAssignmentNodeS{Target: v.Target, Index: v.Index, Value: left, Tk: v.Tk}
 			case *ast.FieldAccessNodeS:
 				left = &ast.FieldAssignmentNode{Target: v.Target, Field: v.Field, Value: left}
 			default:
@@ -7,14 +7,24 @@ import (
 	"mikescript/src/token"
 )
 
-func (parser *MSParser) parseFunctionDecl() (*ast.FuncDeclNodeS, error) {
-	// parses: arguments '>>' IDENTIFIER {'->' type}? '{' block
+func (parser *MSParser) parseFunctionDecl(tk token.Token) (*ast.FuncDeclNodeS, error) {
+	// parses: typeparams? arguments '>>' {IDENTIFIER '.'}? IDENTIFIER {'->' type}? '{' block
+	// 0. {'<' IDENTIFIER {',' IDENTIFIER}* '>'}?
 	// 1. arguments
 	// 2. '>>'
-	// 3. IDENTIFER
+	// 3. {IDENTIFIER '.'}? IDENTIFER, 'point.norm' is a method of 'point'
 	// 4. {'->' type}?
 	// 5. '{' block
 
+	// 0. Parse type parameters, they are types in the rest of the declaration
+	tparams, err := parser.parseTypeParams()
+	if err != nil {
+		return &ast.FuncDeclNodeS{}, err
+	}
+
+	parser.enterTypeParams(tparams)
+	defer parser.leaveTypeParams(tparams)
+
 	// 1. Parse arguments
 	args, err := parser.parseFunctionArgs()
 	if (err != nil) {
@@ -34,6 +44,18 @@ func (parser *MSParser) parseFunctionDecl() (*ast.FuncDeclNodeS, error) {
 		return &ast.FuncDeclNodeS{Params: args, Fname: fname}, err
 	}
 
+	// Methods are named after their struct: 'point.norm'
+	var receiver *ast.VariableExpNodeS
+	if ok, _ := parser.match(token.DOT) ; ok {
+
+		receiver = fname
+		fname, err = parser.parseIdentifier()
+
+		if err != nil {
+			return &ast.FuncDeclNodeS{Params: args, Fname: fname}, err
+		}
+	}
+
 	// 4. Parse {'->' type}?
 	var returnType mstype.MSType
 	if ok, _ := parser.match(token.MINUS_GREAT) ; ok {
@@ -69,9 +91,17 @@ func (parser *MSParser) parseFunctionDecl() (*ast.FuncDeclNodeS, error) {
 	nothingLiteral := &ast.LiteralExpNodeS{Tk: nothingToken}
 	block = &ast.BlockNodeS{
 		Statements: append(block.Statements, &ast.ReturnNodeS{Node: nothingLiteral}),
+		Start: block.Start,
+		End: block.End,
+	}
+
+	// Methods are found through their struct anywhere in the
+	// program, not in the scope they are declared in
+	if receiver != nil && parser.depth > 0 {
+		err = parser.error("Methods can only be declared at the top level", receiver.Name.Line, receiver.Name.Col)
 	}
 
-	return &ast.FuncDeclNodeS{Params: args, Fname: fname, Rt: returnType, Body: block}, err
+	return &ast.FuncDeclNodeS{TypeParams: tparams, Params: args, Fname: fname, Receiver: receiver, Rt: returnType, Body: block, Tk: tk}, err
 }
 
 func (parser *MSParser) parseFunctionArgs() ([]ast.FuncParamS, error) {
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+	"slices"
+)
+
+func (p *MSParser) parseTypeParams() ([]*ast.VariableExpNodeS, error) {
+	// Parses: {'<' IDENTIFIER {',' IDENTIFIER}* '>'}?
+
+	params := []*ast.VariableExpNodeS{}
+
+	if ok, _ := p.match(token.LESS) ; !ok {
+		return params, nil
+	}
+
+	for {
+
+		param, err := p.parseIdentifier()
+
+		if err != nil {
+			return params, err
+		}
+
+		// Type parameters are not allowed to shadow each other,
+		// the evaluator finds them by name.
+		if p.isTypeParam(param.VarName()) || slices.Contains(ast.TypeParamNames(params), param.VarName()) {
+			msg := fmt.Sprintf("Type parameter '%s' is already defined", param.VarName())
+			return params, p.error(msg, param.Name.Line, param.Name.Col)
+		}
+
+		params = append(params, param)
+
+		if ok, _ := p.match(token.COMMA) ; !ok {
+			break
+		}
+	}
+
+	if ok, tok := p.matchClosingAngle() ; !ok {
+		return params, p.unexpectedToken(tok, token.GREATER)
+	}
+
+	return params, nil
+}
+
+func (p *MSParser) parseTypeArgs() ([]mstype.MSType, error) {
+	// Parses: '<' typelist '>', the '<' is already matched
+
+	args, err := p.parseTypeList()
+
+	if err != nil {
+		return args, err
+	}
+
+	if ok, tok := p.matchClosingAngle() ; !ok {
+		return args, p.unexpectedToken(tok, token.GREATER)
+	}
+
+	return args, nil
+}
+
+func (p *MSParser) matchClosingAngle() (bool, token.Token) {
+	// Nested type arguments 'box<box<int>>' end in a '>>' token,
+	// which closes two lists. Only the first '>' is consumed.
+
+	tok := p.peek()
+
+	if tok.Type == token.GREATER_GREATER {
+		p.tokens[p.pos] = token.Token{Type: token.GREATER, Lexeme: ">", Line: tok.Line, Col: tok.Col + 1}
+		return true, tok
+	}
+
+	return p.match(token.GREATER)
+}
+
+// --------------------------------------------------------
+// type parameters in scope
+// --------------------------------------------------------
+
+func (p *MSParser) enterTypeParams(params []*ast.VariableExpNodeS) {
+	p.typeParams = append(p.typeParams, ast.TypeParamNames(params)...)
+}
+
+func (p *MSParser) leaveTypeParams(params []*ast.VariableExpNodeS) {
+	p.typeParams = p.typeParams[:len(p.typeParams)-len(params)]
+}
+
+func (p *MSParser) isTypeParam(name string) bool {
+	return slices.Contains(p.typeParams, name)
+}
@@ -7,7 +7,8 @@ import (
 )
 
 
-func (parser *MSParser) parseIf() (*ast.IfNodeS, error) {
+func (parser *MSParser) parseIf(tk token.Token) (*ast.IfNodeS, error) {
+	// 'if' is already consumed and given
 
 	// Parse conditional expression
 	cond, err := parser.parseExpression()
@@ -41,9 +42,9 @@ func (parser *MSParser) parseIf() (*ast.IfNodeS, error) {
 			return &ast.IfNodeS{}, err
 		}
 
-		return &ast.IfNodeS{Condition: cond, ThenStmt: stmt, ElseStmt: elsestmt}, err
+		return &ast.IfNodeS{Condition: cond, ThenStmt: stmt, ElseStmt: elsestmt, Tk: tk}, err
 	}
 
-	return &ast.IfNodeS{Condition: cond, ThenStmt: stmt, ElseStmt: nil}, err
+	return &ast.IfNodeS{Condition: cond, ThenStmt: stmt, ElseStmt: nil, Tk: tk}, err
 
 }
\ No newline at end of file
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/token"
+	"mikescript/src/utils"
+	"path/filepath"
+	"strings"
+)
+
+func (p *MSParser) parseImport(tk token.Token) (*ast.ImportNodeS, error) {
+	// parses: STRING { '=>' IDENTIFIER }? ';'
+	// 'import' is already consumed and given
+
+	// Not inside of functions or loops
+	if len(p.context) > 0 {
+		return nil, p.error("Modules can only be imported at the top level", tk.Line, tk.Col)
+	}
+
+	ok, path := p.match(token.STRING)
+
+	if !ok {
+		return nil, p.unexpectedToken(path, token.STRING)
+	}
+
+	var alias *ast.VariableExpNodeS
+	var err error
+
+	if ok, _ := p.match(token.EQ_GREATER) ; ok {
+		alias, err = p.parseIdentifier()
+	} else {
+		alias, err = p.moduleName(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, tok := p.expect(token.SEMICOLON) ; !ok {
+		return nil, p.unexpectedToken(tok, token.SEMICOLON)
+	}
+
+	return &ast.ImportNodeS{Tk: tk, Path: path, Alias: alias}, nil
+}
+
+func (p *MSParser) moduleName(path token.Token) (*ast.VariableExpNodeS, error) {
+	// Without an alias the namespace is the file name, 'lib/geo.ms' -> 'geo'
+
+	name := strings.TrimSuffix(filepath.Base(path.Lexeme), filepath.Ext(path.Lexeme))
+
+	if !isIdentifier(name) {
+		msg := fmt.Sprintf("Cannot use '%s' as a module name, import it using 'import \"%s\" => name;'", name, path.Lexeme)
+		return nil, p.error(msg, path.Line, path.Col)
+	}
+
+	tk := token.Token{Type: token.IDENTIFIER, Lexeme: name, Line: path.Line, Col: path.Col}
+
+	return &ast.VariableExpNodeS{Name: tk}, nil
+}
+
+func isIdentifier(s string) bool {
+
+	if s == "" || !utils.IsAlpha(s[0]) {
+		return false
+	}
+
+	for i := range len(s) {
+		if !utils.IsAlpha(s[i]) && !utils.IsDigit(s[i]) {
+			return false
+		}
+	}
+
+	_, keyword := token.Keywords[s]
+
+	return !keyword
+}
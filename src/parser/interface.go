@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	token "mikescript/src/token"
+)
+
+func (p *MSParser) parseInterfaceDeclaration() (*ast.InterfaceDeclarationNodeS, error) {
+	// parses: IDENTIFIER '{' { functionType IDENTIFIER ';' }* '}'
+
+	methods := make(map[*ast.VariableExpNodeS]*mstype.MSOperationTypeS)
+
+	iname, err := p.parseIdentifier()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, tok := p.expect(token.LEFT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.LEFT_BRACE)
+	}
+
+	for {
+
+		if ok, _ := p.lookahead(token.RIGHT_BRACE) ; ok {
+			break
+		}
+
+		tk := p.peek()
+		typ, err := p.parseType()
+
+		if err != nil {
+			return nil, err
+		}
+
+		mname, err := p.parseIdentifier()
+
+		if err != nil {
+			return nil, err
+		}
+
+		ft, ok := typ.(*mstype.MSOperationTypeS)
+
+		if !ok {
+			msg := fmt.Sprintf("Method '%s' of interface '%s' needs a function type, got '%s'", mname.VarName(), iname.VarName(), typ)
+			return nil, p.error(msg, tk.Line, tk.Col)
+		}
+
+		methods[mname] = ft
+
+		// break ok no ';'
+		if ok, _ := p.match(token.SEMICOLON) ; !ok {
+			break
+		}
+	}
+
+	// we want closing brace
+	if ok, tok := p.match(token.RIGHT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.RIGHT_BRACE)
+	}
+
+	return &ast.InterfaceDeclarationNodeS{Name: iname, Methods: methods}, nil
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/token"
+)
+
+func (p *MSParser) parseInterpolation(head token.Token) (*ast.InterpolationNodeS, error) {
+	// parses: exp { STRING_MID exp }* STRING_TAIL
+	// STRING_HEAD is already consumed and given. The scanner
+	// splits "a {x} b" into STRING_HEAD x STRING_TAIL.
+
+	parts := p.interpolationPart(nil, head)
+
+	for {
+
+		exp, err := p.parseExpression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, exp)
+
+		if ok, tok := p.match(token.STRING_MID) ; ok {
+			parts = p.interpolationPart(parts, tok)
+			continue
+		}
+
+		if ok, tok := p.match(token.STRING_TAIL) ; ok {
+			parts = p.interpolationPart(parts, tok)
+			break
+		}
+
+		tok := p.peek()
+		msg := fmt.Sprintf("Expected '}' after interpolated expression got '%v'", tok.Lexeme)
+		return nil, p.error(msg, tok.Line, tok.Col)
+	}
+
+	return &ast.InterpolationNodeS{Tk: head, Parts: parts}, nil
+}
+
+func (p *MSParser) interpolationPart(parts []ast.ExpNodeI, tk token.Token) []ast.ExpNodeI {
+
+	// Nothing to add between '}' and '{'
+	if tk.Lexeme == "" {
+		return parts
+	}
+
+	lit := token.Token{Type: token.STRING, Lexeme: tk.Lexeme, Line: tk.Line, Col: tk.Col}
+
+	return append(parts, &ast.LiteralExpNodeS{Tk: lit})
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+)
+
+func (parser *MSParser) isLambda() bool {
+	// A group can never be parsed as parameters followed by
+	// '->', '{' or '=>', so we try parsing the parameters and
+	// go back to where we started.
+
+	pos, pnc, nerrs := parser.pos, parser.pnc, len(parser.Errors)
+	defer func() {
+		parser.pos, parser.pnc, parser.Errors = pos, pnc, parser.Errors[:nerrs]
+	}()
+
+	if _, err := parser.parseFunctionArgs() ; err != nil {
+		return false
+	}
+
+	ok, _ := parser.lookahead(token.MINUS_GREAT, token.LEFT_BRACE, token.EQ_GREATER)
+	return ok
+}
+
+func (parser *MSParser) parseLambda() (ast.ExpNodeI, error) {
+	// parses: arguments {'->' type}? ( '{' block | '=>' lor )
+	// The short form has no function application on the right of
+	// '=>', so 'xs .>>= (int x) => x * 2 => ys;' stops at the second '=>'.
+
+	tk := parser.peek()
+
+	// 1. arguments
+	args, err := parser.parseFunctionArgs()
+	if err != nil {
+		return &ast.FuncExpNodeS{Tk: tk, Params: args}, err
+	}
+
+	// 2. {'->' type}?, inferred for the short form when missing
+	node := &ast.FuncExpNodeS{Tk: tk, Params: args}
+	if ok, _ := parser.match(token.MINUS_GREAT) ; ok {
+		if node.Rt, err = parser.parseType() ; err != nil {
+			return node, err
+		}
+	}
+
+	parser.enterContext(FUNCTION)
+	defer parser.leaveContext()
+
+	// 3. '=>' lor
+	if ok, arrow := parser.match(token.EQ_GREATER) ; ok {
+
+		node.Exp, err = parser.parseLor()
+		node.Body = &ast.BlockNodeS{
+			Statements: []ast.StmtNodeI{&ast.ReturnNodeS{Node: node.Exp, Tk: arrow}},
+		}
+
+		return node, err
+	}
+
+	// 3. '{' block
+	if ok, tok := parser.match(token.LEFT_BRACE) ; !ok {
+		return node, parser.unexpectedToken(tok, token.LEFT_BRACE, token.EQ_GREATER)
+	}
+
+	if node.Rt == nil {
+		node.Rt = mstype.MS_NOTHING
+	}
+
+	block, err := parser.parseBlock()
+	if err != nil {
+		return node, err
+	}
+
+	// Same implicit "return;" as declared functions
+	nothingToken := token.Token{Type: token.NOTHING_TYPE, Lexeme: "nothing"}
+	nothingLiteral := &ast.LiteralExpNodeS{Tk: nothingToken}
+	node.Body = &ast.BlockNodeS{
+		Statements: append(block.Statements, &ast.ReturnNodeS{Node: nothingLiteral}),
+		Start: block.Start,
+		End: block.End,
+	}
+
+	return node, nil
+}
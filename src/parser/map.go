@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"mikescript/src/ast"
+	"mikescript/src/token"
+)
+
+func (p *MSParser) parseMapConstructor(tk token.Token) (*ast.MapConstructorNodeS, error) {
+	// parses: '[' type ']' type '{' { entry ',' }* '}'
+	// 'map' is already consumed and given
+
+	var keys []ast.ExpNodeI
+	var vals []ast.ExpNodeI
+
+	mtype, err := p.parseMapType()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, tok := p.match(token.LEFT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.LEFT_BRACE)
+	}
+
+	for {
+
+		if ok, _ := p.lookahead(token.RIGHT_BRACE) ; ok {
+			break
+		}
+
+		key, val, err := p.parseMapEntry()
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+		vals = append(vals, val)
+
+		// break ok no ','
+		if ok, _ := p.match(token.COMMA) ; !ok {
+			break
+		}
+	}
+
+	if ok, tok := p.match(token.RIGHT_BRACE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.RIGHT_BRACE)
+	}
+
+	return &ast.MapConstructorNodeS{Tk: tk, Type: mtype, Keys: keys, Vals: vals}, nil
+}
+
+func (p *MSParser) parseMapEntry() (ast.ExpNodeI, ast.ExpNodeI, error) {
+	// parses: lor ':' lor
+	// Entries are separated by ',' so they can't be tuples
+
+	key, err := p.parseLor()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ok, tok := p.match(token.COLON) ; !ok {
+		return nil, nil, p.unexpectedToken(tok, token.COLON)
+	}
+
+	val, err := p.parseLor()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, val, nil
+}
@@ -5,6 +5,7 @@ import (
 	ast "mikescript/src/ast"
 	token "mikescript/src/token"
 	"slices"
+	"strings"
 )
 
 ////////////////////////////////////////////////////////////
@@ -16,8 +17,10 @@ type MSParser struct {
 	tokens []token.Token	// token list from tokenizer
 	pos int     			// current position in tokens
 	pnc bool    			// panic flag
+	depth int				// number of enclosing blocks
 	Errors []ParserError	// parser errors
 	context []ParserConext	// nothing, loop, function...
+	typeParams []string		// type parameters of the enclosing generic declarations
 }
 
 ////////////////////////////////////////////////////////////
@@ -93,7 +96,7 @@ func (p *MSParser) inContext(ctx ParserConext) bool {
 	// LOOP context: must be the last context seen
 	// FUNCTION context: must be in the context stack (contains)
 	switch ctx {
-	case LOOP:		return p.context[len(p.context)-1] == LOOP
+	case LOOP:		return len(p.context) > 0 && p.context[len(p.context)-1] == LOOP
 	case FUNCTION:	return slices.Contains(p.context, ctx)
 	default: 		_ = []int{}[0]
 	}
@@ -112,7 +115,11 @@ func (parser *MSParser) error(msg string, line, col int) error {
 }
 
 func (parser *MSParser) unexpectedToken(got token.Token, expected ...token.TokenType) error {
-	msg := fmt.Sprintf("expected '%s' got '%v'", expected, got)
+	names := make([]string, len(expected))
+	for i, t := range expected {
+		names[i] = t.String()
+	}
+	msg := fmt.Sprintf("Expected '%s' got '%v'", strings.Join(names, "' or '"), got.Type.String())
 	return parser.error(msg, got.Line, got.Col)
 }
 
@@ -120,21 +127,53 @@ func (parser *MSParser) panic() {
 	parser.pnc = true
 }
 
-func (parser *MSParser) synchronize() {
-
-	tok := parser.peek()
-
-	// Synchronize the parser by advancing the position
-	for tok.Type != token.SEMICOLON && !parser.atend() && tok.Type != token.EOF {
-		tok = parser.advance()
-	}
-
-	fmt.Println("Synchronized to: ", tok)
+// Keywords starting a statement, parsing resumes at these after an error
+var syncTokens = []token.TokenType{
+	token.FUNCTION,
+	token.TYPE,
+	token.VAR,
+	token.IF,
+	token.XIF,
+	token.WHILE,
+	token.FOR,
+	token.RETURN,
+	token.BREAK,
+	token.CONTINUE,
+	token.IMPORT,
+	token.THROW,
+	token.TRY,
+}
+
+func (parser *MSParser) synchronize(start int) {
+	// Leaves panic mode by skipping tokens up to the next statement:
+	// past a ';' or a stray '}', or up to a statement keyword or the
+	// '}' closing the enclosing block. The statement that failed
+	// started at 'start', we skip at least one token so the parser
+	// can not get stuck.
+
+	parser.pnc = false
+
+	for !parser.atend() {
+
+		tok := parser.peek()
+
+		switch {
+		case tok.Type == token.EOF:
+			return
+		case tok.Type == token.SEMICOLON:
+			parser.advance()
+			return
+		case tok.Type == token.RIGHT_BRACE && parser.depth == 0:
+			parser.advance()
+			return
+		case parser.pos > start && tok.Type == token.RIGHT_BRACE:
+			return
+		case parser.pos > start && slices.Contains(syncTokens, tok.Type):
+			return
+		}
 
-	if tok.Type != token.EOF {
 		parser.advance()
 	}
-	
 }
 
 ////////////////////////////////////////////////////////////
@@ -144,6 +183,13 @@ func (parser *MSParser) synchronize() {
 func (parser *MSParser) Parse(tokens []token.Token) (*ast.Program, error) {
 	// Parses: START -> Program EOF
 
+	parser.tokens = tokens
+	parser.pos = 0
+	parser.pnc = false
+	parser.depth = 0
+	parser.context = nil
+	parser.Errors = nil
+
 	ast, err := parser.parseProgram()
 
 	if err != nil {
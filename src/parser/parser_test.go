@@ -0,0 +1,288 @@
+package parser
+
+import (
+	"mikescript/src/ast"
+	"mikescript/src/scanner"
+	"reflect"
+	"testing"
+)
+
+func parse(input string) (*ast.Program, []ParserError) {
+
+	s := scanner.MSScanner{}
+	tokens := s.Scan(input)
+
+	p := MSParser{}
+	p.SetSrc(input)
+	p.SetTokens(tokens)
+
+	program, _ := p.Parse(tokens)
+
+	return program, p.Errors
+}
+
+func statementTypes(program *ast.Program) []string {
+	types := []string{}
+	for _, stmt := range program.Statements {
+		types = append(types, reflect.TypeOf(stmt).String())
+	}
+	return types
+}
+
+func TestParse(t *testing.T) {
+
+	// test cases
+	tests := []struct {
+		input string
+		statements []string
+	}{
+		{
+			input: "1 + 2;",
+			statements: []string{"*ast.ExStmtNodeS"},
+		},
+		{
+			input: "var int x; 1 => y;",
+			statements: []string{"*ast.VarDeclNodeS", "*ast.ExStmtNodeS"},
+		},
+		{
+			input: "function (int x) >> f -> int { return x; } 1 >>= f;",
+			statements: []string{"*ast.FuncDeclNodeS", "*ast.ExStmtNodeS"},
+		},
+		{
+			input: "while true { break; } for [0 .. 3] .-> i { continue; }",
+			statements: []string{"*ast.WhileNodeS", "*ast.ForNodeS"},
+		},
+		{
+			input: "if true { 1; } else { 2; } { 3; }",
+			statements: []string{"*ast.IfNodeS", "*ast.BlockNodeS"},
+		},
+		{
+			input: "try { throw \"a\"; } catch (e) { 1; } finally { 2; } try { 3; } catch { 4; }",
+			statements: []string{"*ast.TryNodeS", "*ast.TryNodeS"},
+		},
+		{
+			input: "function (point p) >> point.norm -> float { return p.x; } =p.norm;",
+			statements: []string{"*ast.FuncDeclNodeS", "*ast.ExStmtNodeS"},
+		},
+		{
+			input: "type interface shape { (-> float) area; (float -> shape) scale; } var shape s;",
+			statements: []string{"*ast.InterfaceDeclarationNodeS", "*ast.VarDeclNodeS"},
+		},
+	}
+
+	for _, test := range tests {
+
+		program, errs := parse(test.input)
+
+		if len(errs) > 0 {
+			t.Errorf("Parsing '%s', unexpected errors %v", test.input, errs)
+			continue
+		}
+
+		received := statementTypes(program)
+		if !reflect.DeepEqual(received, test.statements) {
+			t.Errorf("Parsing '%s', expected %v, got %v", test.input, test.statements, received)
+		}
+	}
+}
+
+func TestParseLambda(t *testing.T) {
+
+	// Value of the expression statement, a '=>' or '->' is
+	// looked through to the value which is assigned.
+	value := func(input string) ast.ExpNodeI {
+
+		program, errs := parse(input)
+
+		if len(errs) > 0 {
+			t.Fatalf("Parsing '%s', unexpected errors %v", input, errs)
+		}
+
+		switch ex := program.Statements[0].(*ast.ExStmtNodeS).Ex.(type) {
+		case *ast.DeclAssignNodeS:	return ex.Exp
+		case *ast.AssignmentNodeS:	return ex.Exp
+		default:					return ex
+		}
+	}
+
+	// The short form stops before the next function operator
+	short, ok := value("(int x) => x + 1 => f;").(*ast.FuncExpNodeS)
+	if !ok || short.Rt != nil || len(short.Params) != 1 {
+		t.Errorf("Expected short anonymous function with 1 parameter, got %#v", short)
+	}
+
+	block, ok := value("(int x, int y) -> int { return x; } => f;").(*ast.FuncExpNodeS)
+	if !ok || block.Exp != nil || len(block.Params) != 2 {
+		t.Errorf("Expected anonymous function with a block, got %#v", block)
+	}
+
+	if _, ok := value("() { 1; };").(*ast.FuncExpNodeS) ; !ok {
+		t.Errorf("Expected anonymous function without parameters")
+	}
+
+	// Groups are still groups
+	if _, ok := value("(x) -> y;").(*ast.GroupExpNodeS) ; !ok {
+		t.Errorf("Expected '(x)' to be a group")
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+
+	var input string
+	var expected []ParserError
+	var received []ParserError
+
+	///////////////////////////////////////////////
+	input = "1 + ;"
+	expected = []ParserError{
+		{msg: "Expected primary expression got ';'", line: 1, col: 6},
+	}
+
+	_, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+	input = "break;"
+	expected = []ParserError{
+		{msg: "Connot use 'break' outside of loop contexts", line: 1, col: 6},
+	}
+
+	_, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+	input = "1 + 2"
+	expected = []ParserError{
+		{msg: "Expected ';' got 'EOF'", line: 1, col: 6},
+	}
+
+	_, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+	input = "{ 1;"
+	expected = []ParserError{
+		{msg: "Expected '}' got 'EOF'", line: 1, col: 5},
+	}
+
+	_, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+	input = "function () >> f {\n    function (point p) >> point.norm {}\n    1 => x;\n}\n{ function (point p) >> point.size {} }"
+	expected = []ParserError{
+		{msg: "Methods can only be declared at the top level", line: 2, col: 32},
+		{msg: "Methods can only be declared at the top level", line: 5, col: 30},
+	}
+
+	_, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+	input = "try { 1; } 2;"
+	expected = []ParserError{
+		{msg: "Expected 'catch' or 'finally' got 'l_int'", line: 1, col: 13},
+	}
+
+	_, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+}
+
+func TestParserRecovery(t *testing.T) {
+
+	var input string
+	var expected []ParserError
+	var received []ParserError
+	var program *ast.Program
+
+	///////////////////////////////////////////////
+	// Errors in separate statements are all reported
+	input = "1 + ;\n2 => x;\nvar int ;\nx * ;\n3 => y;"
+	expected = []ParserError{
+		{msg: "Expected primary expression got ';'", line: 1, col: 6},
+		{msg: "Expected identifier got ';': ';'", line: 3, col: 10},
+		{msg: "Expected primary expression got ';'", line: 4, col: 6},
+	}
+
+	program, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+	if n := len(program.Statements) ; n != 2 {
+		t.Errorf("Expected 2 statements, got %d", n)
+	}
+
+	///////////////////////////////////////////////
+	// Errors in a block do not end the block
+	input = "function () >> f {\n1 + ;\n2 => x\n}\nwhile true {\n* ;\n}\n4 => y;"
+	expected = []ParserError{
+		{msg: "Expected primary expression got ';'", line: 2, col: 6},
+		{msg: "Expected ';' got '}'", line: 4, col: 2},
+		{msg: "Expected primary expression got ';'", line: 6, col: 4},
+	}
+
+	program, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	types := []string{"*ast.FuncDeclNodeS", "*ast.WhileNodeS", "*ast.ExStmtNodeS"}
+	if received := statementTypes(program) ; !reflect.DeepEqual(received, types) {
+		t.Errorf("Expected %v, got %v", types, received)
+	}
+
+	///////////////////////////////////////////////
+	// Statement keywords end a statement missing its ';'
+	input = "1 => x\nfunction () >> f { }\nwhile x { x -> }"
+	expected = []ParserError{
+		{msg: "Expected ';' got 'function'", line: 2, col: 9},
+		{msg: "Expected primary expression got '}'", line: 3, col: 17},
+	}
+
+	_, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+	// A stray '}' is skipped
+	input = "}\n1 => x;\n}"
+	expected = []ParserError{
+		{msg: "Expected primary expression got '}'", line: 1, col: 2},
+		{msg: "Expected primary expression got '}'", line: 3, col: 2},
+	}
+
+	program, received = parse(input)
+	if !errorsEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+	if n := len(program.Statements) ; n != 1 {
+		t.Errorf("Expected 1 statement, got %d", n)
+	}
+}
+
+func errorsEqual(a, b []ParserError) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -9,23 +9,26 @@ import (
 
 func (parser *MSParser) parseProgram() (*ast.Program, error) {
 	// parses program -> statement *
+	// Failing statements are skipped so all errors are reported,
+	// the first one is returned.
 
 	statements := []ast.StmtNodeI{}
 	var err error
-	var stmt ast.StmtNodeI
 
 	for !parser.atend() && parser.peek().Type != token.EOF {
 
-		stmt, err = parser.parseStatement()
+		start := parser.pos
+		stmt, serr := parser.parseStatement()
 
-		if err == nil {
+		if serr == nil {
 			statements = append(statements, stmt)
+		} else if err == nil {
+			err = serr
 		}
 
-		if parser.pnc {
-			parser.synchronize()
+		if parser.pnc || serr != nil {
+			parser.synchronize(start)
 		}
-		
 	}
 
 	return &ast.Program{Statements: statements}, err
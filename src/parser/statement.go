@@ -8,24 +8,28 @@ import (
 func (parser *MSParser) parseStatement() (ast.StmtNodeI, error){
 
 	// FUNCDECL
-	if ok, _ := parser.match(token.FUNCTION); ok {
-		return parser.parseFunctionDecl()
+	if ok, tk := parser.match(token.FUNCTION); ok {
+		return parser.parseFunctionDecl(tk)
 	}
 	// BLOCK
 	if ok, _ := parser.match(token.LEFT_BRACE); ok {
 		return parser.parseBlock()
 	}
 	// IF
-	if ok, _ := parser.match(token.IF); ok {
-		return parser.parseIf()
+	if ok, tk := parser.match(token.IF); ok {
+		return parser.parseIf(tk)
 	}
 	// WHILE
-	if ok, _ := parser.match(token.WHILE); ok {
-		return parser.parseWhile()
+	if ok, tk := parser.match(token.WHILE); ok {
+		return parser.parseWhile(tk)
+	}
+	// XIF
+	if ok, tk := parser.match(token.XIF); ok {
+		return parser.parseXifStatement(tk)
 	}
 	//FOR
-	if ok, _ := parser.match(token.FOR); ok {
-		return parser.parseFor()
+	if ok, tk := parser.match(token.FOR); ok {
+		return parser.parseFor(tk)
 	}
 	// VARIABLE DECLARATION
 	if ok, _ := parser.match(token.VAR); ok {
@@ -47,6 +51,18 @@ func (parser *MSParser) parseStatement() (ast.StmtNodeI, error){
 	if ok, tk := parser.match(token.RETURN) ; ok {
 		return parser.parseReturn(tk)
 	}
+	// IMPORT
+	if ok, tk := parser.match(token.IMPORT) ; ok {
+		return parser.parseImport(tk)
+	}
+	// THROW
+	if ok, tk := parser.match(token.THROW) ; ok {
+		return parser.parseThrow(tk)
+	}
+	// TRY
+	if ok, tk := parser.match(token.TRY) ; ok {
+		return parser.parseTry(tk)
+	}
 
 	// Nothing matched, so we assume it must be an expression.
 	return parser.parseExpressionStatement()
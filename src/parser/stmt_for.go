@@ -6,7 +6,8 @@ import (
 	"mikescript/src/token"
 )
 
-func (parser *MSParser) parseFor() (*ast.ForNodeS, error) {
+func (parser *MSParser) parseFor(tk token.Token) (*ast.ForNodeS, error) {
+	// 'for' is already consumed and given
 
 	var err error = nil
 
@@ -38,12 +39,14 @@ func (parser *MSParser) parseFor() (*ast.ForNodeS, error) {
 		return nil, err
 	}
 
+	parser.enterContext(LOOP)
 	block, err := parser.parseBlock()
+	parser.leaveContext()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &ast.ForNodeS{Iterable: iterable, LoopVar: loop_var, Body: block}, err
+	return &ast.ForNodeS{Iterable: iterable, LoopVar: loop_var, Body: block, Tk: tk}, err
 
 }
\ No newline at end of file
@@ -17,6 +17,16 @@ func (p *MSParser) parseStructDeclaration() (*ast.StructDeclarationNodeS, error)
 
 	fields = make(map[*ast.VariableExpNodeS]mstype.MSType)
 
+	// generic structs 'type struct<T> name {...}'
+	tparams, err := p.parseTypeParams()
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.enterTypeParams(tparams)
+	defer p.leaveTypeParams(tparams)
+
 	sname, err = p.parseIdentifier()
 
 	if err != nil {
@@ -59,7 +69,7 @@ func (p *MSParser) parseStructDeclaration() (*ast.StructDeclarationNodeS, error)
 		return nil, p.unexpectedToken(tok, token.RIGHT_BRACE)
 	}
 
-	return &ast.StructDeclarationNodeS{Name: sname, Fields: fields}, nil
+	return &ast.StructDeclarationNodeS{Name: sname, TypeParams: tparams, Fields: fields}, nil
 }
 
 func (p *MSParser) parseStructConstructor() (ast.ExpNodeI, error) {
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"mikescript/src/ast"
+	"mikescript/src/token"
+)
+
+func (parser *MSParser) parseThrow(tk token.Token) (*ast.ThrowNodeS, error) {
+	// 'throw' is already consumed and given
+
+	val, err := parser.parseExpression()
+
+	if err != nil {
+		return &ast.ThrowNodeS{Tk: tk}, err
+	}
+
+	if ok, tok := parser.expect(token.SEMICOLON) ; !ok {
+		return &ast.ThrowNodeS{Tk: tk}, parser.unexpectedToken(tok, token.SEMICOLON)
+	}
+
+	return &ast.ThrowNodeS{Tk: tk, Node: val}, nil
+}
+
+func (parser *MSParser) parseTry(tk token.Token) (*ast.TryNodeS, error) {
+	// parses: 'try' block { 'catch' { '(' IDENTIFIER ')' }? block }? { 'finally' block }?
+	// At least one of 'catch' and 'finally' is needed.
+
+	node := &ast.TryNodeS{Tk: tk}
+
+	body, err := parser.parseTryBlock()
+	if err != nil {
+		return node, err
+	}
+	node.Body = body
+
+	if ok, _ := parser.match(token.CATCH) ; ok {
+
+		// { '(' IDENTIFIER ')' }?
+		if ok, _ := parser.match(token.LEFT_PAREN) ; ok {
+
+			if node.Err, err = parser.parseIdentifier() ; err != nil {
+				return node, err
+			}
+
+			if ok, tok := parser.expect(token.RIGHT_PAREN) ; !ok {
+				return node, parser.unexpectedToken(tok, token.RIGHT_PAREN)
+			}
+		}
+
+		if node.Catch, err = parser.parseTryBlock() ; err != nil {
+			return node, err
+		}
+	}
+
+	if ok, _ := parser.match(token.FINALLY) ; ok {
+		if node.Finally, err = parser.parseTryBlock() ; err != nil {
+			return node, err
+		}
+	}
+
+	if node.Catch == nil && node.Finally == nil {
+		return node, parser.unexpectedToken(parser.peek(), token.CATCH, token.FINALLY)
+	}
+
+	return node, nil
+}
+
+func (parser *MSParser) parseTryBlock() (*ast.BlockNodeS, error) {
+
+	if ok, tok := parser.expect(token.LEFT_BRACE) ; !ok {
+		return nil, parser.unexpectedToken(tok, token.LEFT_BRACE)
+	}
+
+	return parser.parseBlock()
+}
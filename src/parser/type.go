@@ -9,11 +9,14 @@ import (
 func (p *MSParser) parseType() (mstype.MSType, error) {
 	// arg: type of declartion;
 	// Need to consider 3 cases:
-	// 1. basic types 'int', 'bool', 'float', 'string'
+	// 1. basic types 'int', 'bool', 'float', 'string', 'error'
 	// 2. Named types like 'point', 'car', ...
 	// 2. composite types (type, type), ()
 	// 3. function types (type, type, type -> type), ( -> type), (->)
 	// 4. array types 'type[]'
+	// 5. type parameters 'T' and generic structs 'box<int>'
+	// 6. map types 'map[type]type'
+	// 7. types of imported modules 'geo.point'
 
 	// Case 1: basic types
 	switch _, tok := p.match(token.SimpleTypeKeywords...) ; tok.Type {
@@ -22,10 +25,11 @@ func (p *MSParser) parseType() (mstype.MSType, error) {
 	case token.BOOLEAN_TYPE:	return mstype.MS_BOOL, nil
 	case token.STRING_TYPE:		return mstype.MS_STRING, nil
 	case token.NOTHING_TYPE:	return mstype.MS_NOTHING, nil
+	case token.ERROR_TYPE:		return mstype.MS_ERROR, nil
 	}
 
 	if ok, tok := p.match(token.IDENTIFIER) ; ok {
-		return &mstype.MSNamedTypeS{Name: tok.Lexeme}, nil
+		return p.parseNamedType(tok)
 	}
 
 	// Array type
@@ -33,6 +37,11 @@ func (p *MSParser) parseType() (mstype.MSType, error) {
 		return p.parseArrayType()
 	}
 
+	// Map type
+	if ok, _ := p.match(token.MAP) ; ok {
+		return p.parseMapType()
+	}
+
 	// Composite or function type
 	if ok, _ := p.match(token.LEFT_PAREN) ; ok{
 		return p.parseCompositeOrFunctionType()
@@ -44,6 +53,40 @@ func (p *MSParser) parseType() (mstype.MSType, error) {
 
 }
 
+func (p *MSParser) parseNamedType(tok token.Token) (mstype.MSType, error) {
+	// Type parameter, named type or a generic struct 'name<typelist>'
+
+	if p.isTypeParam(tok.Lexeme) {
+		return &mstype.MSTypeVarS{Name: tok.Lexeme}, nil
+	}
+
+	// Type declared in a module, 'namespace.name'
+	var namespace string
+
+	if ok, _ := p.match(token.DOT) ; ok {
+
+		ok, name := p.match(token.IDENTIFIER)
+
+		if !ok {
+			return mstype.MS_NOTHING, p.unexpectedToken(name, token.IDENTIFIER)
+		}
+
+		namespace, tok = tok.Lexeme, name
+	}
+
+	if ok, _ := p.match(token.LESS) ; !ok {
+		return &mstype.MSNamedTypeS{Name: tok.Lexeme, Namespace: namespace}, nil
+	}
+
+	args, err := p.parseTypeArgs()
+
+	if err != nil {
+		return mstype.MS_NOTHING, err
+	}
+
+	return &mstype.MSNamedTypeS{Name: tok.Lexeme, Namespace: namespace, Args: args}, nil
+}
+
 func (p *MSParser) parseArrayType() (mstype.MSType, error) {
 
 	// expect a ']'
@@ -61,6 +104,32 @@ func (p *MSParser) parseArrayType() (mstype.MSType, error) {
 
 }
 
+func (p *MSParser) parseMapType() (*mstype.MSMapTypeS, error) {
+	// parses: '[' type ']' type, 'map' is already consumed
+
+	if ok, tok := p.match(token.LEFT_SQUARE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.LEFT_SQUARE)
+	}
+
+	key, err := p.parseType()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, tok := p.match(token.RIGHT_SQUARE) ; !ok {
+		return nil, p.unexpectedToken(tok, token.RIGHT_SQUARE)
+	}
+
+	val, err := p.parseType()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &mstype.MSMapTypeS{Key: key, Value: val}, nil
+}
+
 func (p *MSParser) parseCompositeOrFunctionType() (mstype.MSType, error) {
 	// Check if we have a closing ')' immediately or a '->'
 	// we have an empty typelist. In this case we don't need
@@ -10,12 +10,18 @@ func (p *MSParser) parseTypeDeclaration() (ast.StmtNodeI, error) {
 
 	// Parses: "type" type identifier ";"
 	// Parses: "type" "struct" identifier '{' ... '}'
+	// Parses: "type" "enum" identifier '{' ... '}'
+	// Parses: "type" "interface" identifier '{' ... '}'
 
 	var node ast.StmtNodeI
 	var err error
 
 	if ok, _ := p.match(token.STRUCT) ; ok {
 		node, err = p.parseStructDeclaration()
+	} else if ok, _ := p.match(token.ENUM) ; ok {
+		node, err = p.parseEnumDeclaration()
+	} else if ok, _ := p.match(token.INTERFACE) ; ok {
+		node, err = p.parseInterfaceDeclaration()
 	} else {
 		node, err = p.parseTypedefStatement()
 	}
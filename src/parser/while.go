@@ -7,7 +7,8 @@ import (
 )
 
 
-func (parser *MSParser) parseWhile() (*ast.WhileNodeS, error) {
+func (parser *MSParser) parseWhile(tk token.Token) (*ast.WhileNodeS, error) {
+	// 'while' is already consumed and given
 
 	cond, err := parser.parseExpression()
 
@@ -28,7 +29,7 @@ func (parser *MSParser) parseWhile() (*ast.WhileNodeS, error) {
 		_ = []int{}[0] // force error
 	}
 
-	return &ast.WhileNodeS{Condition: cond, Body: block}, err
+	return &ast.WhileNodeS{Condition: cond, Body: block, Tk: tk}, err
 
 }
 
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/token"
+)
+
+func (p *MSParser) parseXifStatement(tk token.Token) (*ast.XifNodeS, error) {
+	// parses: xif ';'?
+	// The ';' is only needed when the last body is an expression
+
+	node, err := p.parseXif(tk, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bodies := node.Bodies()
+
+	if _, ok := bodies[len(bodies)-1].(*ast.BlockNodeS) ; ok {
+		return node, nil
+	}
+
+	if ok, tok := p.expect(token.SEMICOLON) ; !ok {
+		return nil, p.unexpectedToken(tok, token.SEMICOLON)
+	}
+
+	return node, nil
+}
+
+func (p *MSParser) parseXif(tk token.Token, stmt bool) (*ast.XifNodeS, error) {
+	// parses: { '|' lor '=>' body }+ { 'otherwise' body }?
+	// 'xif' is already consumed and given
+
+	var arms []ast.XifArmS
+	var otherwise ast.ASTNodeI
+
+	for {
+
+		ok, bar := p.match(token.BAR)
+
+		if !ok && len(arms) == 0 {
+			return nil, p.unexpectedToken(bar, token.BAR)
+		}
+
+		if !ok {
+			break
+		}
+
+		// A full expression would take the '=>' as a declaration
+		cond, err := p.parseLor()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok, tok := p.expect(token.EQ_GREATER) ; !ok {
+			return nil, p.unexpectedToken(tok, token.EQ_GREATER)
+		}
+
+		body, err := p.parseXifBody(stmt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		arms = append(arms, ast.XifArmS{Cond: cond, Body: body})
+	}
+
+	if ok, _ := p.match(token.OTHERWISE) ; ok {
+
+		body, err := p.parseXifBody(stmt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		otherwise = body
+	}
+
+	// An expression always needs to produce a value
+	if !stmt && otherwise == nil {
+		msg := fmt.Sprintf("Expected '%v', an xif expression needs a value when no condition holds", token.OTHERWISE)
+		return nil, p.error(msg, tk.Line, tk.Col)
+	}
+
+	return &ast.XifNodeS{Tk: tk, Arms: arms, Otherwise: otherwise}, nil
+}
+
+func (p *MSParser) parseXifBody(stmt bool) (ast.ASTNodeI, error) {
+	// parses: expression
+	// parses: '{' block, only for statements
+
+	if !stmt {
+		return p.parseExpression()
+	}
+
+	if ok, _ := p.match(token.LEFT_BRACE) ; ok {
+		return p.parseBlock()
+	}
+
+	return p.parseExpression()
+}
@@ -0,0 +1,222 @@
+package profiler
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"mikescript/src/ast"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+Instrumenting profiler used by 'ms profile', implements
+'interp.Profiler'. From the first statement until 'Stop' it records:
+	- the calls of every function
+	- the inclusive time of a function, the time spent in its calls
+	  including the functions they call. Recursive calls are only
+	  counted once.
+	- the exclusive time of a function, without the functions it calls
+	- how many times the statements of every line are executed
+The program itself is the function '<program>'. The lines of
+imported modules are counted apart from those of the program, by
+the path of the module.
+*/
+
+type Profiler struct {
+	functions map[string]*function
+	lines map[position]int			// statements executed by line
+	stacks map[string]time.Duration	// exclusive time by call stack
+	frames []frame					// calls in progress, the program first
+	now func() time.Time
+}
+
+type function struct {
+	name string
+	calls int
+	inclusive time.Duration
+	exclusive time.Duration
+	active int						// calls in progress
+}
+
+type position struct {
+	file string						// path of the module, "" for the program
+	line int
+}
+
+type frame struct {
+	fun *function
+	stack string					// names of the calls leading here, joined by ';'
+	start time.Time
+	children time.Duration			// time spent in the calls of this frame
+}
+
+const program = "<program>"
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		functions: make(map[string]*function),
+		lines: make(map[position]int),
+		stacks: make(map[string]time.Duration),
+		now: time.Now,
+	}
+}
+
+// Stops timing the program, calls still in progress when it failed
+// end here as well.
+func (p *Profiler) Stop() {
+	for len(p.frames) > 0 {
+		p.Leave()
+	}
+}
+
+// --------------------------------------------------------
+// Implements interp.Profiler
+// --------------------------------------------------------
+
+func (p *Profiler) Statement(file string, node ast.StmtNodeI) {
+
+	// The program starts with its first statement
+	if len(p.frames) == 0 {
+		p.Enter(program)
+	}
+
+	if line := ast.StmtToken(node).Line ; line > 0 {
+		p.lines[position{file, line}]++
+	}
+}
+
+func (p *Profiler) Enter(name string) {
+
+	fun, ok := p.functions[name]
+	if !ok {
+		fun = &function{name: name}
+		p.functions[name] = fun
+	}
+
+	fun.calls++
+	fun.active++
+
+	stack := name
+	if len(p.frames) > 0 {
+		stack = p.frames[len(p.frames)-1].stack + ";" + name
+	}
+
+	p.frames = append(p.frames, frame{fun: fun, stack: stack, start: p.now()})
+}
+
+func (p *Profiler) Leave() {
+
+	if len(p.frames) == 0 {
+		return
+	}
+
+	f := p.frames[len(p.frames)-1]
+	p.frames = p.frames[:len(p.frames)-1]
+
+	elapsed := p.now().Sub(f.start)
+
+	// Only the outermost of the recursive calls counts
+	f.fun.active--
+	if f.fun.active == 0 {
+		f.fun.inclusive += elapsed
+	}
+
+	f.fun.exclusive += elapsed - f.children
+	p.stacks[f.stack] += elapsed - f.children
+
+	if len(p.frames) > 0 {
+		p.frames[len(p.frames)-1].children += elapsed
+	}
+}
+
+// --------------------------------------------------------
+// Reports
+// --------------------------------------------------------
+
+// Functions by exclusive time and lines by the statements executed,
+// the program first and then every module. src is the profiled
+// source, the sources of the modules are read from their paths.
+func (p *Profiler) WriteReport(w io.Writer, src string) {
+
+	funs := make([]*function, 0, len(p.functions))
+	for _, f := range p.functions {
+		funs = append(funs, f)
+	}
+
+	slices.SortFunc(funs, func(a, b *function) int {
+		if a.exclusive != b.exclusive {
+			return cmp.Compare(b.exclusive, a.exclusive)
+		}
+		return strings.Compare(a.name, b.name)
+	})
+
+	fmt.Fprintf(w, "%10s %14s %14s  %s\n", "calls", "inclusive", "exclusive", "function")
+	for _, f := range funs {
+		fmt.Fprintf(w, "%10d %14v %14v  %s\n", f.calls, f.inclusive, f.exclusive, f.name)
+	}
+
+	files := map[string][]int{}
+	for pos := range p.lines {
+		files[pos.file] = append(files[pos.file], pos.line)
+	}
+
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		paths = append(paths, file)
+	}
+	slices.Sort(paths)
+
+	for _, file := range paths {
+		p.writeLines(w, file, files[file], src)
+	}
+}
+
+func (p *Profiler) writeLines(w io.Writer, file string, lines []int, src string) {
+
+	slices.SortFunc(lines, func(a, b int) int {
+		ha, hb := p.lines[position{file, a}], p.lines[position{file, b}]
+		if ha != hb {
+			return hb - ha
+		}
+		return a - b
+	})
+
+	// Without the source of a module only the hits are printed
+	name := program
+	if file != "" {
+		name = file
+		data, _ := os.ReadFile(file)
+		src = string(data)
+	}
+
+	source := strings.Split(src, "\n")
+
+	fmt.Fprintf(w, "\n%10s %6s  %s\n", "hits", "line", name)
+	for _, line := range lines {
+
+		text := ""
+		if line <= len(source) {
+			text = strings.TrimSpace(source[line-1])
+		}
+
+		fmt.Fprintf(w, "%10d %6d | %s\n", p.lines[position{file, line}], line, text)
+	}
+}
+
+// Folded stacks for flame graph tools, one 'outer;inner weight' line
+// per call stack. The weight is the exclusive time in nanoseconds.
+func (p *Profiler) WriteFolded(w io.Writer) {
+
+	stacks := make([]string, 0, len(p.stacks))
+	for stack := range p.stacks {
+		stacks = append(stacks, stack)
+	}
+	slices.Sort(stacks)
+
+	for _, stack := range stacks {
+		fmt.Fprintf(w, "%s %d\n", stack, p.stacks[stack].Nanoseconds())
+	}
+}
@@ -0,0 +1,153 @@
+package profiler
+
+import (
+	"bytes"
+	"maps"
+	"mikescript/src/mikescript"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const src = `function (int n) >> fib -> int {
+    if n <= 1 {
+        return 1;
+    }
+    return (n - 1 >>= fib) + (n - 2 >>= fib);
+}
+function () >> main {
+    3 >>= fib => x;
+}
+=main;`
+
+// Profiles src, every reading of the clock takes a millisecond
+func profile(t *testing.T) *Profiler {
+
+	p := NewProfiler()
+
+	clock := time.Time{}
+	p.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+
+	in := mikescript.NewInterpreter()
+	in.SetProfiler(p)
+
+	if _, err := in.Eval(src) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p.Stop()
+
+	return p
+}
+
+func TestProfilerCounts(t *testing.T) {
+
+	p := profile(t)
+
+	calls := map[string]int{"<program>": 1, "main": 1, "fib": 5}
+	for name, n := range calls {
+		if p.functions[name].calls != n {
+			t.Errorf("Expected %d calls of '%s', received %d", n, name, p.functions[name].calls)
+		}
+	}
+
+	lines := map[int]int{2: 5, 3: 3, 5: 2, 8: 1, 10: 1}
+	for line, n := range lines {
+		if hits := p.lines[position{"", line}] ; hits != n {
+			t.Errorf("Expected %d hits of line %d, received %d", n, line, hits)
+		}
+	}
+}
+
+// The lines of a module are counted and reported apart from those
+// of the program
+func TestProfilerModules(t *testing.T) {
+
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.ms")
+	main := filepath.Join(dir, "main.ms")
+
+	files := map[string]string{
+		lib: "function (int n) >> twice -> int {\n    return 2 * n;\n}\n",
+		main: "import \"lib.ms\";\n1 >>= lib.twice;\n2 >>= lib.twice;\n",
+	}
+
+	for path, src := range files {
+		if err := os.WriteFile(path, []byte(src), 0644) ; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := NewProfiler()
+
+	in := mikescript.NewInterpreter()
+	in.SetProfiler(p)
+
+	if _, err := in.RunFile(main) ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p.Stop()
+
+	lines := map[position]int{{"", 1}: 1, {"", 2}: 1, {"", 3}: 1, {lib, 2}: 2}
+
+	if !maps.Equal(p.lines, lines) {
+		t.Errorf("Expected hits %v, received %v", lines, p.lines)
+	}
+
+	var out bytes.Buffer
+	p.WriteReport(&out, files[main])
+
+	for _, expected := range []string{"line  <program>\n", "1      3 | 2 >>= lib.twice;\n", "line  " + lib + "\n", "2      2 | return 2 * n;\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected '%s' in the report:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestProfilerTimes(t *testing.T) {
+
+	p := profile(t)
+
+	// The clock is read twice by every call, once when it starts
+	// and once when it ends.
+	tests := []struct {
+		name string
+		inclusive time.Duration
+		exclusive time.Duration
+	}{
+		{"<program>", 13 * time.Millisecond, 2 * time.Millisecond},
+		{"main", 11 * time.Millisecond, 2 * time.Millisecond},
+		{"fib", 9 * time.Millisecond, 9 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+
+		f := p.functions[test.name]
+
+		if f.inclusive != test.inclusive || f.exclusive != test.exclusive {
+			t.Errorf("Expected '%s' to take %v (%v exclusive), received %v (%v exclusive)", test.name, test.inclusive, test.exclusive, f.inclusive, f.exclusive)
+		}
+	}
+
+	var out bytes.Buffer
+	p.WriteFolded(&out)
+
+	expected := strings.Join([]string{
+		"<program> 2000000",
+		"<program>;main 2000000",
+		"<program>;main;fib 3000000",
+		"<program>;main;fib;fib 4000000",
+		"<program>;main;fib;fib;fib 2000000",
+		"",
+	}, "\n")
+
+	if out.String() != expected {
+		t.Errorf("Expected folded stacks:\n%s\nreceived:\n%s", expected, out.String())
+	}
+}
@@ -1,5 +1,6 @@
 package resolver
 
+import "fmt"
 
 type ResolveError struct {
 	msg string
@@ -7,4 +8,30 @@ type ResolveError struct {
 
 func (e ResolveError) Error() string {
 	return "Resolving erroe: " + e.msg
-}
\ No newline at end of file
+}
+
+type TypeError struct {
+	msg  string
+	line int
+	col  int
+}
+
+func (e TypeError) Error() string {
+	return fmt.Sprintf("Type error: %v at line %v col %v", e.msg, e.line, e.col)
+}
+
+func (e TypeError) Message() string {
+	return e.msg
+}
+
+func (e TypeError) Position() (int, int) {
+	return e.line, e.col
+}
+
+type ImportError struct {
+	msg string
+}
+
+func (e *ImportError) Error() string {
+	return e.msg
+}
@@ -0,0 +1,309 @@
+package resolver
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/parser"
+	"mikescript/src/scanner"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A source file loaded using 'import'. Modules are scanned, parsed,
+// resolved and type checked once, importing the same file again
+// gives the same module.
+type MSModule struct {
+	Path string								// absolute path of the source file
+	Ast *ast.Program
+	VLocals map[*ast.VariableExpNodeS]int
+	TLocals map[*mstype.MSNamedTypeS]int
+	globals TypeScope						// top-level declarations
+}
+
+func (m *MSModule) Name() string {
+	return strings.TrimSuffix(filepath.Base(m.Path), filepath.Ext(m.Path))
+}
+
+type MSModuleLoader struct {
+	dir string						// imports of the main program are relative to dir
+	modules map[string]*MSModule	// loaded modules by path
+	loading []string				// modules being loaded, used to detect cycles
+}
+
+func NewMSModuleLoader(dir string) *MSModuleLoader {
+	return &MSModuleLoader{
+		dir: dir,
+		modules: make(map[string]*MSModule),
+	}
+}
+
+// Loads the module at path. Relative paths are relative to the
+// module doing the import, or to the loader directory for the
+// main program.
+func (l *MSModuleLoader) Load(path string) (*MSModule, error) {
+
+	file, err := l.resolvePath(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m, ok := l.modules[file] ; ok {
+		return m, nil
+	}
+
+	if i := slices.Index(l.loading, file) ; i >= 0 {
+		cycle := append(slices.Clone(l.loading[i:]), file)
+		for j := range cycle {
+			cycle[j] = filepath.Base(cycle[j])
+		}
+		return nil, &ImportError{fmt.Sprintf("Import cycle '%s'", strings.Join(cycle, "' -> '"))}
+	}
+
+	src, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, &ImportError{fmt.Sprintf("Could not import '%s': %v", path, err)}
+	}
+
+	l.loading = append(l.loading, file)
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+	}()
+
+	m, err := l.compile(file, string(src))
+
+	// Failed imports of the module are reported as they are
+	if ierr, ok := err.(*ImportError) ; ok {
+		return nil, ierr
+	}
+
+	if err != nil {
+		return nil, &ImportError{fmt.Sprintf("Could not import '%s': %v", path, err)}
+	}
+
+	l.modules[file] = m
+
+	return m, nil
+}
+
+func (l *MSModuleLoader) resolvePath(path string) (string, error) {
+
+	if !filepath.IsAbs(path) {
+
+		dir := l.dir
+		if len(l.loading) > 0 {
+			dir = filepath.Dir(l.loading[len(l.loading)-1])
+		}
+
+		path = filepath.Join(dir, path)
+	}
+
+	return filepath.Abs(path)
+}
+
+func (l *MSModuleLoader) compile(file, src string) (*MSModule, error) {
+
+	s := scanner.MSScanner{}
+	tokens := s.Scan(src)
+
+	if len(s.Errors) > 0 {
+		return nil, fmt.Errorf("%v", s.Errors[0])
+	}
+
+	p := parser.MSParser{}
+	p.SetSrc(src)
+	p.SetTokens(tokens)
+
+	program, _ := p.Parse(tokens)
+
+	if len(p.Errors) > 0 {
+		return nil, p.Errors[0]
+	}
+
+	vr := NewMSResolver(program)
+	vr.Reset()
+	vlocals, tlocals := vr.Resolve()
+
+	// Modules imported by this module are loaded while type checking
+	tr := NewMSTypeResolver(program)
+	tr.SetModules(l)
+
+	if errs := tr.Resolve() ; len(errs) > 0 {
+
+		if tr.importErr != nil {
+			return nil, tr.importErr
+		}
+
+		return nil, errs[0]
+	}
+
+	m := &MSModule{
+		Path: file,
+		Ast: program,
+		VLocals: vlocals,
+		TLocals: tlocals,
+		globals: tr.scopes[0],
+	}
+
+	return m, nil
+}
+
+// --------------------------------------------------------
+// namespaces
+// --------------------------------------------------------
+
+// Type of the name a module is imported under. Its members are the
+// top-level declarations of the module.
+type msModuleTypeS struct {
+	name string			// namespace
+	module *MSModule
+}
+
+func (t *msModuleTypeS) Eq(o mstype.MSType) bool {
+	other, ok := o.(*msModuleTypeS)
+	return ok && other.module == t.module
+}
+
+func (t *msModuleTypeS) String() string {
+	return fmt.Sprintf("module %s", t.module.Name())
+}
+
+func (t *msModuleTypeS) Nullable() bool {
+	return false
+}
+
+func (t *msModuleTypeS) member(name string) (mstype.MSType, bool) {
+	mt, ok := t.module.globals.vars[name]
+	if !ok {
+		return nil, false
+	}
+	return t.qualify(mt), true
+}
+
+func (t *msModuleTypeS) memberType(name string) (mstype.MSType, bool) {
+	mt, ok := t.module.globals.types[name]
+	if !ok {
+		return nil, false
+	}
+	return t.qualify(mt), true
+}
+
+func (t *msModuleTypeS) qualify(mt mstype.MSType) mstype.MSType {
+	// Types declared in the module are named using the namespace,
+	// 'point' in module 'geo' becomes 'geo.point' outside of it.
+
+	qualifyList := func(ts []mstype.MSType) []mstype.MSType {
+		qualified := make([]mstype.MSType, len(ts))
+		for i, et := range ts {
+			qualified[i] = t.qualify(et)
+		}
+		return qualified
+	}
+
+	switch tt := mt.(type) {
+	case *mstype.MSNamedTypeS:
+
+		if _, ok := t.module.globals.types[tt.Name] ; !ok || tt.Namespace != "" {
+			return tt
+		}
+
+		return &mstype.MSNamedTypeS{Name: tt.Name, Namespace: t.name, Args: qualifyList(tt.Args)}
+
+	case *mstype.MSArrayType:
+		return &mstype.MSArrayType{Type: t.qualify(tt.Type)}
+	case *mstype.MSMapTypeS:
+		return &mstype.MSMapTypeS{Key: t.qualify(tt.Key), Value: t.qualify(tt.Value)}
+	case *mstype.MSCompositeTypeS:
+		return &mstype.MSCompositeTypeS{Types: qualifyList(tt.Types)}
+	case *mstype.MSOperationTypeS:
+		return &mstype.MSOperationTypeS{Left: qualifyList(tt.Left), Right: t.qualify(tt.Right)}
+	case *mstype.MSStructTypeS:
+
+		fields := make(map[string]mstype.MSType)
+		for name, ft := range tt.Fields {
+			fields[name] = t.qualify(ft)
+		}
+
+		return &mstype.MSStructTypeS{Name: tt.Name, Fields: fields, Params: tt.Params, Args: qualifyList(tt.Args)}
+
+	case *mstype.MSEnumTypeS:
+
+		variants := make([]mstype.MSVariantS, len(tt.Variants))
+		for i, v := range tt.Variants {
+			variants[i] = mstype.MSVariantS{Name: v.Name, Types: qualifyList(v.Types)}
+		}
+
+		return &mstype.MSEnumTypeS{Name: tt.Name, Variants: variants}
+
+	case *mstype.MSInterfaceTypeS:
+
+		methods := make(map[string]*mstype.MSOperationTypeS)
+		for name, m := range tt.Methods {
+			methods[name] = t.qualify(m).(*mstype.MSOperationTypeS)
+		}
+
+		return &mstype.MSInterfaceTypeS{Name: tt.Name, Methods: methods}
+	}
+
+	return mt
+}
+
+// --------------------------------------------------------
+// import
+// --------------------------------------------------------
+
+// Modules are loaded relative to the working directory unless
+// a loader is shared with the evaluator using SetModules.
+func (r *MSTypeResolver) SetModules(l *MSModuleLoader) {
+	r.modules = l
+}
+
+func (r *MSTypeResolver) resolveImport(n *ast.ImportNodeS) {
+
+	if r.modules == nil {
+		r.modules = NewMSModuleLoader(".")
+	}
+
+	m, err := r.modules.Load(n.Path.Lexeme)
+
+	if err != nil {
+
+		if r.importErr == nil {
+			r.importErr = err
+		}
+
+		r.error(n.Path, err.Error())
+		r.declareVar(n.Alias.VarName(), unknown, n.Alias.Name)
+		return
+	}
+
+	// The evaluator finds the module using its path
+	n.File = m.Path
+
+	r.declareVar(n.Alias.VarName(), &msModuleTypeS{name: n.Alias.VarName(), module: m}, n.Alias.Name)
+}
+
+func (r *MSTypeResolver) lookupNamedType(nt *mstype.MSNamedTypeS) (mstype.MSType, bool) {
+
+	if nt.Namespace == "" {
+		return r.lookupType(nt.Name)
+	}
+
+	ns, ok := r.lookupVar(nt.Namespace)
+
+	if !ok {
+		return nil, false
+	}
+
+	mt, ok := ns.(*msModuleTypeS)
+
+	if !ok {
+		return nil, false
+	}
+
+	return mt.memberType(nt.Name)
+}
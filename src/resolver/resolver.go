@@ -28,6 +28,9 @@ func (r *MSResolver) Reset() {
 	r.scopes = make([]scope, 0, 10)
 	r.vlocals = make(map[*ast.VariableExpNodeS]int)
 	r.tlocals = make(map[*mstype.MSNamedTypeS]int)
+	r.names = []map[string]*ast.VariableExpNodeS{make(map[string]*ast.VariableExpNodeS)}
+	r.decls = make(map[*ast.VariableExpNodeS]*ast.VariableExpNodeS)
+	r.unresolved = nil
 }
 
 type MSResolver struct {
@@ -35,6 +38,9 @@ type MSResolver struct {
 	scopes []scope
 	vlocals map[*ast.VariableExpNodeS]int
 	tlocals map[*mstype.MSNamedTypeS]int
+	names []map[string]*ast.VariableExpNodeS		// declaring identifiers, names[0] holds the globals
+	decls map[*ast.VariableExpNodeS]*ast.VariableExpNodeS	// identifier -> identifier declaring it
+	unresolved []*ast.VariableExpNodeS				// uses of names not declared (yet)
 }
 
 func (r *MSResolver) currentScope() *scope {
@@ -47,16 +53,23 @@ func (r *MSResolver) currentScope() *scope {
 func (r *MSResolver) enterScope() {
 	//println("Entering scope", len(r.scopes))
 	r.scopes = append(r.scopes, newScope())
+	r.names = append(r.names, make(map[string]*ast.VariableExpNodeS))
 }
 
 func (r *MSResolver) leaveScope() {
 	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.names = r.names[:len(r.names)-1]
 }
 
-func (r *MSResolver) declare(name string) {
+func (r *MSResolver) declare(v *ast.VariableExpNodeS) {
 	// If there is a current scope, we set the
 	// name to false (declared but not yet init)
 
+	name := v.VarName()
+
+	r.names[len(r.names)-1][name] = v
+	r.decls[v] = v
+
 	//fmt.Printf(".   Setting: scopes[%d][%s] --> false\n", len(r.scopes) - 1, name)
 	if current := r.currentScope() ; current != nil {
 
@@ -81,6 +94,8 @@ func (r *MSResolver) define(name string) {
 
 func (r *MSResolver) resolveLocalVariable(v *ast.VariableExpNodeS, name string) {
 
+	r.resolveDeclaration(v, name)
+
 	depth, found, _ := r.findName(name)
 
 	// Found nowhere in scopes
@@ -117,6 +132,19 @@ func (r *MSResolver) resolveLocalType(t *mstype.MSNamedTypeS, name string) {
 	t.Depth = depth
 }
 
+func (r *MSResolver) resolveDeclaration(v *ast.VariableExpNodeS, name string) {
+
+	for i := len(r.names) - 1 ; i >= 0 ; i-- {
+		if d, ok := r.names[i][name] ; ok {
+			r.decls[v] = d
+			return
+		}
+	}
+
+	// Functions can be used before they are declared
+	r.unresolved = append(r.unresolved, v)
+}
+
 func (r *MSResolver) findName(name string) (int, bool, bool) {
 	/* Walk back scope stack to look for name */
 
@@ -142,9 +170,42 @@ func (r *MSResolver) findName(name string) (int, bool, bool) {
 
 func (r *MSResolver) Resolve() (map[*ast.VariableExpNodeS]int, map[*mstype.MSNamedTypeS]int) {
 	r.resolveStatement(r.Ast)
+
+	for _, v := range r.unresolved {
+		if d, ok := r.names[0][v.VarName()] ; ok {
+			r.decls[v] = d
+		}
+	}
+
 	return r.vlocals, r.tlocals
 }
 
+// Resolves exp as if it was used in scopes, the names declared in
+// each scope from the outermost to the innermost. Used to evaluate
+// expressions in a running program, like the watches of a debugger.
+func (r *MSResolver) ResolveExpression(exp ast.ExpNodeI, scopes [][]string) map[*ast.VariableExpNodeS]int {
+
+	r.Reset()
+
+	for _, names := range scopes {
+		r.enterScope()
+		for _, name := range names {
+			r.define(name)
+		}
+	}
+
+	r.resolveExpression(exp)
+
+	return r.vlocals
+}
+
+// Identifier declaring each identifier of the last resolved
+// program, declarations map to themselves. Builtins and names
+// which are never declared are missing.
+func (r *MSResolver) Declarations() map[*ast.VariableExpNodeS]*ast.VariableExpNodeS {
+	return r.decls
+}
+
 func (r *MSResolver) resolveStatement(stm ast.StmtNodeI) {
 	// fmt.Printf("%p // %#v\n", stm, stm)
 	switch st := stm.(type) {
@@ -159,6 +220,12 @@ func (r *MSResolver) resolveStatement(stm ast.StmtNodeI) {
 	case *ast.FuncDeclNodeS:			r.resolveFuncDeclaration(st)
 	case *ast.TypeDefStatementS: 		r.resolveTypeDeclaration(st)
 	case *ast.StructDeclarationNodeS:	r.resolveStructDeclaration(st)
+	case *ast.EnumDeclarationNodeS:		r.resolveEnumDeclaration(st)
+	case *ast.InterfaceDeclarationNodeS:	r.resolveInterfaceDeclaration(st)
+	case *ast.XifNodeS:					r.resolveXif(st)
+	case *ast.ImportNodeS:				r.resolveImport(st)
+	case *ast.ThrowNodeS:				r.resolveExpression(st.Node)
+	case *ast.TryNodeS:					r.resolveTryNode(st)
 	case *ast.BreakNodeS:				return 	// nothing to resolve
 	case *ast.ContinueNodeS:			return 	// nothing to resolve
 	default:							fmt.Printf("Resolving: %v\n", st); _ = []int{}[0]
@@ -190,6 +257,11 @@ func (r *MSResolver) resolveExpression(n ast.ExpNodeI) {
 	case *ast.IterableFuncAppAndCallNodeS:	r.resolveIterableFuncAppAndCall(ex)
 	case *ast.RangeConstructorNodeS:		r.resolveRangeConstructor(ex)
 	case *ast.StarredExpNodeS:				r.resolveExpression(ex.Node)
+	case *ast.MatchNodeS:					r.resolveMatch(ex)
+	case *ast.XifNodeS:						r.resolveXif(ex)
+	case *ast.MapConstructorNodeS:			r.resolveMapConstructor(ex)
+	case *ast.InterpolationNodeS:			r.resolveExpressions(ex.Parts)
+	case *ast.FuncExpNodeS:					r.resolveFunction(ex.Params, ex.Rt, ex.Body)
 	default:								fmt.Printf("%v\n", ex) ; _ = []int{}[0]
 	}
 }
@@ -223,9 +295,11 @@ func (r *MSResolver) resolveType(n mstype.MSType) {
 	case *mstype.MSSimpleTypeS:		return
 	case *mstype.MSCompositeTypeS:	r.resolveTypes(t.Types)
 	case *mstype.MSArrayType:		r.resolveType(t.Type)
+	case *mstype.MSMapTypeS:		r.resolveType(t.Key) ; r.resolveType(t.Value)
 	case *mstype.MSOperationTypeS:	r.resolveOperationType(t)
 	case *mstype.MSStructTypeS:		r.resolveStructType(t)
 	case *mstype.MSNamedTypeS:		r.resolveNamedType(t)
+	case *mstype.MSTypeVarS:		return	// bound when calling, see MSFunction
 	default:						_ = []int{}[0]
 	}
 }
@@ -235,16 +309,40 @@ func (r *MSResolver) resolveType(n mstype.MSType) {
 // --------------------------------------------------------
 
 func (r *MSResolver) resolveStructDeclaration(sd *ast.StructDeclarationNodeS) {
-	r.declare(sd.Name.VarName())
+	r.declare(sd.Name)
 	for _, field := range sd.Fields {
 		r.resolveType(field)
 	}
 	r.define(sd.Name.VarName())
 }
 
+func (r *MSResolver) resolveInterfaceDeclaration(id *ast.InterfaceDeclarationNodeS) {
+	// Note: methods may refer to the interface itself
+	r.declare(id.Name)
+	r.define(id.Name.VarName())
+	for _, m := range id.Methods {
+		r.resolveType(m)
+	}
+}
+
+func (r *MSResolver) resolveEnumDeclaration(ed *ast.EnumDeclarationNodeS) {
+	// Note: payloads may refer to the enum itself
+	r.declare(ed.Name)
+	r.define(ed.Name.VarName())
+	for _, v := range ed.Variants {
+		r.resolveTypes(v.Types)
+	}
+
+	// Every variant is a value (or constructor) in the current scope
+	for _, v := range ed.Variants {
+		r.declare(v.Name)
+		r.define(v.Name.VarName())
+	}
+}
+
 func (r *MSResolver) resolveTypeDeclaration(td *ast.TypeDefStatementS) {
 	// Note: declare before resolve to detect recursive type defs
-	r.declare(td.Tname.VarName())
+	r.declare(td.Tname)
 	r.resolveType(td.Type)
 	r.define(td.Tname.VarName())
 }
@@ -262,35 +360,70 @@ func (r *MSResolver) resolveBlockNode(n *ast.BlockNodeS) {
 	r.leaveScope()
 }
 
+func (r *MSResolver) resolveImport(n *ast.ImportNodeS) {
+	// The module itself is resolved when it is loaded
+	r.declare(n.Alias)
+	r.define(n.Alias.VarName())
+}
+
 func (r *MSResolver) resolveVariableDeclaration(n *ast.VarDeclNodeS) {
-	r.declare(n.VarName())
+	r.declare(n.Identifier)
 	r.define(n.VarName())
 	r.resolveType(n.Vartype)
 }
 
 func (r *MSResolver) resolveFuncDeclaration(n *ast.FuncDeclNodeS) {
 	
-	// Declare and define fname in current scope
-	r.declare(n.Fname.VarName())
-	r.define(n.Fname.VarName())
+	// Declare and define fname in current scope, methods
+	// are found through their struct instead
+	if !n.IsMethod() {
+		r.declare(n.Fname)
+		r.define(n.Fname.VarName())
+	}
+
+	r.resolveFunction(n.Params, n.Rt, n.Body)
+}
+
+func (r *MSResolver) resolveFunction(params []ast.FuncParamS, rt mstype.MSType, body *ast.BlockNodeS) {
 
 	// Resolve function types
-	for _, t := range n.Params {
+	for _, t := range params {
 		r.resolveType(t.Type)
 	}
-	r.resolveType(n.Rt)
+	if rt != nil {
+		r.resolveType(rt)
+	}
 
 	// push scope before declaring params
 	r.enterScope()
-	for _, p := range n.Params {
-		r.declare(p.VarName())
+	for _, p := range params {
+		r.declare(p.Iden)
 		r.define(p.VarName())
 	}
-	r.resolveStatements(n.Body.Statements)
+	r.resolveStatements(body.Statements)
 	r.leaveScope()
 }
 
 
+func (r *MSResolver) resolveTryNode(n *ast.TryNodeS) {
+
+	r.resolveBlockNode(n.Body)
+
+	if n.Catch != nil {
+		r.enterScope()
+		if n.Err != nil {
+			r.declare(n.Err)
+			r.define(n.Err.VarName())
+		}
+		r.resolveStatements(n.Catch.Statements)
+		r.leaveScope()
+	}
+
+	if n.Finally != nil {
+		r.resolveBlockNode(n.Finally)
+	}
+}
+
 func (r *MSResolver) resolveIfNode(n *ast.IfNodeS) {
 	r.resolveExpression(n.Condition)
 	r.resolveStatement(n.ThenStmt)
@@ -307,7 +440,7 @@ func (r *MSResolver) resolveWhileNode(n *ast.WhileNodeS) {
 func (r *MSResolver) resolveForNode(n *ast.ForNodeS) {
 	r.resolveExpression(n.Iterable)
 	r.enterScope()
-	r.declare(n.LoopVar.VarName())
+	r.declare(n.LoopVar)
 	r.define(n.LoopVar.VarName())
 	r.resolveStatements(n.Body.Statements)
 	r.leaveScope()
@@ -334,6 +467,32 @@ func (r *MSResolver) resolveStructConstructor(n *ast.StructConstructorNodeS) {
 	}
 }
 
+func (r *MSResolver) resolveMatch(n *ast.MatchNodeS) {
+
+	if n.Rt != nil {
+		r.resolveType(n.Rt)
+	}
+
+	for _, arm := range n.Arms {
+
+		if !arm.IsWildcard() {
+			r.resolveLocalVariable(arm.Variant, arm.Variant.VarName())
+		}
+
+		// Every arm gets its own scope for the payload
+		r.enterScope()
+		for _, b := range arm.Bindings {
+			if b.VarName() == "_" {
+				continue	// ignored value
+			}
+			r.declare(b)
+			r.define(b.VarName())
+		}
+		r.resolveExpression(arm.Body)
+		r.leaveScope()
+	}
+}
+
 func (r *MSResolver) resolveArrayAssignment(n *ast.ArrayAssignmentNodeS) {
 	r.resolveExpression(n.Index)
 	r.resolveExpression(n.Target)
@@ -382,7 +541,7 @@ func (r *MSResolver) resolveLogicalExpression(b *ast.LogicalExpNodeS) {
 
 func (r *MSResolver) resolveDeclAssignExpression(da *ast.DeclAssignNodeS) {
 	r.resolveExpression(da.Exp)
-	r.declare(da.Identifier.VarName())
+	r.declare(da.Identifier)
 	r.define(da.Identifier.VarName())
 }
 
@@ -409,5 +568,35 @@ func (r *MSResolver) resolveStructType(st *mstype.MSStructTypeS) {
 }
 
 func (r *MSResolver) resolveNamedType(nt *mstype.MSNamedTypeS) {
-	r.resolveLocalType(nt, nt.Name)
-}
\ No newline at end of file
+
+	// Types of modules are found through the namespace
+	if nt.Namespace != "" {
+		r.resolveLocalType(nt, nt.Namespace)
+	} else {
+		r.resolveLocalType(nt, nt.Name)
+	}
+
+	r.resolveTypes(nt.Args)
+}
+
+func (r *MSResolver) resolveMapConstructor(n *ast.MapConstructorNodeS) {
+	r.resolveType(n.Type)
+	r.resolveExpressions(n.Keys)
+	r.resolveExpressions(n.Vals)
+}
+
+func (r *MSResolver) resolveXif(n *ast.XifNodeS) {
+
+	for _, arm := range n.Arms {
+		r.resolveExpression(arm.Cond)
+	}
+
+	// Like blocks, expression bodies get their own scope. Variables
+	// they declare would otherwise only exist when the arm runs.
+	for _, body := range n.Bodies() {
+		switch b := body.(type) {
+		case *ast.BlockNodeS:	r.resolveBlockNode(b)
+		case ast.ExpNodeI:		r.enterScope() ; r.resolveExpression(b) ; r.leaveScope()
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package resolver
+
+import (
+	"fmt"
+	"mikescript/src/mstype"
+)
+
+///////////////////////////////////////////////////////////////
+// Types of the builtins defined in 'NewMSEvaluator'
+///////////////////////////////////////////////////////////////
+
+func (r *MSTypeResolver) declareBuiltins() {
+	r.DeclareGlobal("print", builtinPrint)
+	r.DeclareGlobal("env", &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: mstype.MS_NOTHING})
+	r.DeclareGlobal("rand", &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: mstype.MS_FLOAT})
+	r.DeclareGlobal("len", builtinLen)
+	r.DeclareGlobal("err", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING, mstype.MS_STRING}, Right: mstype.MS_ERROR})
+	r.DeclareGlobal("args", &mstype.MSArrayType{Type: mstype.MS_STRING})
+	r.DeclareGlobal("getenv", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING}, Right: mstype.MS_STRING})
+	r.DeclareGlobal("setenv", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING, mstype.MS_STRING}, Right: mstype.MS_NOTHING})
+	r.DeclareGlobal("exit", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_INT}, Right: mstype.MS_NOTHING})
+	r.DeclareGlobal("input", &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: mstype.MS_STRING})
+	r.DeclareGlobal("read_file", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING}, Right: mstype.MS_STRING})
+	r.DeclareGlobal("read_lines", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING}, Right: &mstype.MSArrayType{Type: mstype.MS_STRING}})
+	r.DeclareGlobal("write_file", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING, mstype.MS_STRING}, Right: mstype.MS_NOTHING})
+	r.DeclareGlobal("append_file", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING, mstype.MS_STRING}, Right: mstype.MS_NOTHING})
+	r.DeclareGlobal("list_dir", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING}, Right: &mstype.MSArrayType{Type: mstype.MS_STRING}})
+	r.DeclareGlobal("exists", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING}, Right: mstype.MS_BOOL})
+	r.DeclareGlobal("remove", &mstype.MSOperationTypeS{Left: []mstype.MSType{mstype.MS_STRING}, Right: mstype.MS_NOTHING})
+	r.DeclareGlobal("has", builtinHas)
+	r.DeclareGlobal("delete", builtinDelete)
+}
+
+// Some builtins accept arguments which can't be described using an
+// operation type ('print' takes anything, 'len' any iterable). These
+// get a type which checks binding and calling itself.
+type msBuiltinTypeS struct {
+	name string
+	rtype mstype.MSType		// type of the builtin value at runtime
+	bind func(r *MSTypeResolver, args []mstype.MSType) (mstype.MSType, error)
+	call func(r *MSTypeResolver) (mstype.MSType, error)
+}
+
+func (t *msBuiltinTypeS) Eq(o mstype.MSType) bool {
+	return t == o
+}
+
+func (t *msBuiltinTypeS) String() string {
+	return fmt.Sprintf("%s %s", t.name, t.rtype)
+}
+
+func (t *msBuiltinTypeS) Nullable() bool {
+	return false
+}
+
+// --------------------------------------------------------
+// print
+// --------------------------------------------------------
+
+var builtinPrint *msBuiltinTypeS
+
+func init() {
+	builtinPrint = &msBuiltinTypeS{
+		name: "print",
+		rtype: &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: mstype.MS_NOTHING},
+		bind: func(r *MSTypeResolver, args []mstype.MSType) (mstype.MSType, error) {
+			return builtinPrint, nil
+		},
+		call: func(r *MSTypeResolver) (mstype.MSType, error) {
+			return mstype.MS_NOTHING, nil
+		},
+	}
+}
+
+// --------------------------------------------------------
+// len
+// --------------------------------------------------------
+
+var builtinLen *msBuiltinTypeS = &msBuiltinTypeS{
+	name: "len",
+	rtype: &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: mstype.MS_INT},
+	bind: func(r *MSTypeResolver, args []mstype.MSType) (mstype.MSType, error) {
+
+		if len(args) != 1 {
+			return nil, fmt.Errorf("'len' expects exactly 1 argument, received %d", len(args))
+		}
+
+		if _, ok := r.elemType(args[0]) ; !ok {
+			return nil, fmt.Errorf("'len' expected argument of iterable type, got '%s'", args[0])
+		}
+
+		return &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: mstype.MS_INT}, nil
+	},
+	call: func(r *MSTypeResolver) (mstype.MSType, error) {
+		return nil, fmt.Errorf("'len' expects exactly 1 argument, received 0")
+	},
+}
+
+// --------------------------------------------------------
+// has, delete
+// --------------------------------------------------------
+
+var builtinHas = mapBuiltin("has", mstype.MS_BOOL)
+var builtinDelete = mapBuiltin("delete", mstype.MS_NOTHING)
+
+// Takes a map and a key of the map, the type of the key is known once
+// the map is bound.
+func mapBuiltin(name string, rt mstype.MSType) *msBuiltinTypeS {
+	return &msBuiltinTypeS{
+		name: name,
+		rtype: &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: rt},
+		bind: func(r *MSTypeResolver, args []mstype.MSType) (mstype.MSType, error) {
+
+			if len(args) == 0 || len(args) > 2 {
+				return nil, fmt.Errorf("'%s' expects a map and a key, received %d arguments", name, len(args))
+			}
+
+			mt, ok := args[0].(*mstype.MSMapTypeS)
+
+			if !ok {
+				return nil, fmt.Errorf("'%s' expected argument of map type, got '%s'", name, args[0])
+			}
+
+			if len(args) == 1 {
+				return &mstype.MSOperationTypeS{Left: []mstype.MSType{mt.Key}, Right: rt}, nil
+			}
+
+			if !mt.Key.Eq(args[1]) {
+				return nil, fmt.Errorf("'%s' expected key of type '%s', got '%s'", name, mt.Key, args[1])
+			}
+
+			return &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: rt}, nil
+		},
+		call: func(r *MSTypeResolver) (mstype.MSType, error) {
+			return nil, fmt.Errorf("'%s' expects a map and a key, received no arguments", name)
+		},
+	}
+}
@@ -0,0 +1,284 @@
+package resolver
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"strings"
+)
+
+// --------------------------------------------------------
+// enum declarations
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveEnumDeclaration(ed *ast.EnumDeclarationNodeS) {
+
+	// Already declared while hoisting
+	seen := make(map[string]bool)
+
+	for _, v := range ed.Variants {
+
+		if seen[v.Name.VarName()] {
+			msg := fmt.Sprintf("Variant '%s' is already defined in enum '%s'", v.Name.VarName(), ed.Name.VarName())
+			r.error(v.Name.Name, msg)
+		}
+		seen[v.Name.VarName()] = true
+
+		r.checkTypes(v.Types, v.Name.Name)
+	}
+}
+
+func (r *MSTypeResolver) declareVariants(ed *ast.EnumDeclarationNodeS) {
+	// Plain variants are values of the enum, variants with a
+	// payload are constructors taking the payload values.
+
+	et := ed.GetEnumType()
+
+	for _, v := range ed.Variants {
+
+		var vt mstype.MSType = et
+
+		if len(v.Types) > 0 {
+			vt = &mstype.MSOperationTypeS{Left: v.Types, Right: et}
+		}
+
+		r.declareVar(v.Name.VarName(), vt, v.Name.Name)
+	}
+}
+
+func (r *MSTypeResolver) variantEnum(v *ast.VariableExpNodeS) (*mstype.MSEnumTypeS, bool) {
+	// Finds the enum 'v' is a variant of
+
+	t, ok := r.lookupVar(v.VarName())
+
+	if !ok {
+		return nil, false
+	}
+
+	if ot, ok := r.underlying(t).(*mstype.MSOperationTypeS) ; ok {
+		t = ot.Right
+	}
+
+	et, ok := r.underlying(t).(*mstype.MSEnumTypeS)
+
+	if !ok {
+		return nil, false
+	}
+
+	_, ok = et.Variant(v.VarName())
+
+	return et, ok
+}
+
+// --------------------------------------------------------
+// match
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveMatch(n *ast.MatchNodeS) mstype.MSType {
+
+	var enum *mstype.MSEnumTypeS
+	var wildcard bool
+	var variants int
+
+	matched := make(map[string]bool)
+
+	// Resolve the patterns before the arms, all variants
+	// need to come from the same enum.
+	for _, arm := range n.Arms {
+
+		if wildcard {
+			r.error(arm.Variant.Name, "Unreachable match arm, previous arm '_' matches every variant")
+		}
+
+		if arm.IsWildcard() {
+			wildcard = true
+			continue
+		}
+
+		variants++
+		et, ok := r.variantEnum(arm.Variant)
+
+		switch {
+		case !ok:
+			r.error(arm.Variant.Name, fmt.Sprintf("'%s' is not a variant of an enum", arm.Variant.VarName()))
+		case enum != nil && enum.Name != et.Name:
+			msg := fmt.Sprintf("Variant '%s' is not a variant of enum '%s'", arm.Variant.VarName(), enum.Name)
+			r.error(arm.Variant.Name, msg)
+		case matched[arm.Variant.VarName()]:
+			r.error(arm.Variant.Name, fmt.Sprintf("Variant '%s' is matched more than once", arm.Variant.VarName()))
+		default:
+			enum = et
+			matched[arm.Variant.VarName()] = true
+		}
+	}
+
+	// The enum is only known through the variants
+	if variants == 0 {
+		r.error(n.Tk, "Match expression needs at least one variant pattern")
+	}
+
+	// exhaustiveness
+	if enum != nil && !wildcard {
+
+		missing := []string{}
+		for _, v := range enum.Variants {
+			if !matched[v.Name] {
+				missing = append(missing, v.Name)
+			}
+		}
+
+		if len(missing) > 0 {
+			msg := fmt.Sprintf("Match on '%s' is not exhaustive, missing variant(s) '%s'", enum.Name, strings.Join(missing, "', '"))
+			r.error(n.Tk, msg)
+		}
+	}
+
+	// arms
+	types := make([]mstype.MSType, len(n.Arms))
+	for i, arm := range n.Arms {
+		types[i] = r.resolveMatchArm(enum, arm)
+	}
+
+	rt := r.matchResultType(n, types)
+
+	if enum == nil {
+		return &mstype.MSOperationTypeS{Left: []mstype.MSType{unknown}, Right: rt}
+	}
+
+	return &mstype.MSOperationTypeS{Left: []mstype.MSType{enum}, Right: rt}
+}
+
+func (r *MSTypeResolver) resolveMatchArm(enum *mstype.MSEnumTypeS, arm ast.MatchArmS) mstype.MSType {
+
+	var payload []mstype.MSType
+	var known bool
+
+	if enum != nil {
+		var v mstype.MSVariantS
+		v, known = enum.Variant(arm.Variant.VarName())
+		payload = v.Types
+	}
+
+	if known && len(arm.Bindings) != len(payload) {
+		msg := fmt.Sprintf("Variant '%s' has %d value(s), but the pattern binds %d", arm.Variant.VarName(), len(payload), len(arm.Bindings))
+		r.error(arm.Variant.Name, msg)
+	}
+
+	if arm.IsWildcard() && len(arm.Bindings) > 0 {
+		r.error(arm.Variant.Name, "Pattern '_' cannot bind values")
+	}
+
+	r.enterScope()
+	defer r.leaveScope()
+
+	for i, b := range arm.Bindings {
+
+		if b.VarName() == "_" {
+			continue	// ignored value
+		}
+
+		var bt mstype.MSType = unknown
+		if i < len(payload) {
+			bt = payload[i]
+		}
+
+		r.declareVar(b.VarName(), bt, b.Name)
+	}
+
+	return r.resolveExpression(arm.Body)
+}
+
+func (r *MSTypeResolver) matchResultType(n *ast.MatchNodeS, types []mstype.MSType) mstype.MSType {
+
+	// Declared result type, all arms need to produce it
+	if n.Rt != nil {
+
+		r.checkType(n.Rt, n.Tk)
+
+		for i, t := range types {
+			if !r.assignable(n.Rt, t) {
+				msg := fmt.Sprintf("Match arm has type '%s', expected type '%s'%s", t, n.Rt, r.conformance(n.Rt, t))
+				r.error(n.Arms[i].Variant.Name, msg)
+			}
+		}
+
+		return n.Rt
+	}
+
+	// Inferred result type, taken from the first arm we know
+	// the type of. The other arms need to agree.
+	var rt mstype.MSType = unknown
+
+	for i, t := range types {
+
+		if isUnknown(rt) {
+			rt = t
+			continue
+		}
+
+		if !r.compatible(rt, t) {
+			msg := fmt.Sprintf("Match arm has type '%s', but previous arms have type '%s'", t, rt)
+			r.error(n.Arms[i].Variant.Name, msg)
+		}
+	}
+
+	// The evaluator needs the result type for its own type checks
+	concrete, ok := r.concrete(rt, 0)
+
+	if !ok {
+		r.error(n.Tk, "Could not infer the result type of match, declare it using 'match -> type {...}'")
+		return unknown
+	}
+
+	n.Rt = concrete
+
+	return rt
+}
+
+func (r *MSTypeResolver) concrete(t mstype.MSType, depth int) (mstype.MSType, bool) {
+	// Turns an inferred type into a type the evaluator can use outside of
+	// the scope it was written in: named types are replaced by what they
+	// refer to. Fails for types which are only known at runtime.
+
+	if depth > maxTypeDepth {
+		return nil, false
+	}
+
+	switch tt := r.underlying(t).(type) {
+	case *msUnknownTypeS:
+		return nil, false
+	case *msBuiltinTypeS:
+		return tt.rtype, true
+	case *mstype.MSArrayType:
+		et, ok := r.concrete(tt.Type, depth + 1)
+		return &mstype.MSArrayType{Type: et}, ok
+	case *mstype.MSMapTypeS:
+		kv, ok := r.concreteList([]mstype.MSType{tt.Key, tt.Value}, depth + 1)
+		if !ok {
+			return nil, false
+		}
+		return &mstype.MSMapTypeS{Key: kv[0], Value: kv[1]}, true
+	case *mstype.MSCompositeTypeS:
+		ts, ok := r.concreteList(tt.Types, depth + 1)
+		return &mstype.MSCompositeTypeS{Types: ts}, ok
+	case *mstype.MSOperationTypeS:
+		left, ok := r.concreteList(tt.Left, depth + 1)
+		right, rok := r.concrete(tt.Right, depth + 1)
+		return &mstype.MSOperationTypeS{Left: left, Right: right}, ok && rok
+	default:
+		// simple types, structs and enums
+		return tt, true
+	}
+}
+
+func (r *MSTypeResolver) concreteList(ts []mstype.MSType, depth int) ([]mstype.MSType, bool) {
+	concrete := make([]mstype.MSType, len(ts))
+	for i, t := range ts {
+		ct, ok := r.concrete(t, depth)
+		if !ok {
+			return nil, false
+		}
+		concrete[i] = ct
+	}
+	return concrete, true
+}
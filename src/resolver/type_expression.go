@@ -0,0 +1,744 @@
+package resolver
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+	"strconv"
+)
+
+func (r *MSTypeResolver) resolveExpression(n ast.ExpNodeI) mstype.MSType {
+	switch ex := n.(type){
+	case *ast.AssignmentNodeS:				return r.resolveAssignmentExpression(ex)
+	case *ast.DeclAssignNodeS:				return r.resolveDeclAssignExpression(ex)
+	case *ast.FuncAppNodeS:					return r.resolveFuncAppExpression(ex)
+	case *ast.FuncCallNodeS:				return r.resolveFuncCallExpression(ex)
+	case *ast.BinaryExpNodeS:				return r.resolveBinaryExpression(ex)
+	case *ast.LogicalExpNodeS:				return r.resolveLogicalExpression(ex)
+	case *ast.UnaryExpNodeS:				return r.resolveUnaryExpression(ex)
+	case *ast.TupleNodeS:					return r.resolveTuple(ex)
+	case *ast.VariableExpNodeS:				return r.resolveVariableExpression(ex)
+	case *ast.GroupExpNodeS:				return r.resolveExpression(ex.Node)
+	case *ast.LiteralExpNodeS:				return r.resolveLiteralExpression(ex)
+	case *ast.ArrayConstructorNodeS:		return r.resolveArrayConstructor(ex)
+	case *ast.ArrayIndexNodeS:				return r.resolveArrayIndex(ex)
+	case *ast.ArrayAssignmentNodeS:			return r.resolveArrayAssignment(ex)
+	case *ast.RangeConstructorNodeS:		return r.resolveRangeConstructor(ex)
+	case *ast.FieldAccessNodeS:				return r.resolveFieldAccess(ex)
+	case *ast.FieldAssignmentNode:			return r.resolveFieldAssignment(ex)
+	case *ast.StructConstructorNodeS:		return r.resolveStructConstructor(ex)
+	case *ast.IterableFuncCallNodeS:		return r.resolveIterableFuncCall(ex)
+	case *ast.IterableFuncAppNodeS:			return r.resolveIterableFuncApplication(ex)
+	case *ast.IterableFuncAppAndCallNodeS:	return r.resolveIterableFuncAppAndCall(ex)
+	case *ast.StarredExpNodeS:				return r.resolveExpression(ex.Node)
+	case *ast.MatchNodeS:					return r.resolveMatch(ex)
+	case *ast.XifNodeS:						return r.resolveXifExpression(ex)
+	case *ast.MapConstructorNodeS:			return r.resolveMapConstructor(ex)
+	case *ast.InterpolationNodeS:			return r.resolveInterpolation(ex)
+	case *ast.FuncExpNodeS:					return r.resolveFuncExpression(ex)
+	default:								fmt.Printf("%v\n", ex) ; _ = []int{}[0]
+	}
+	return unknown
+}
+
+// --------------------------------------------------------
+// variables
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveVariableExpression(v *ast.VariableExpNodeS) mstype.MSType {
+
+	t, ok := r.lookupVar(v.VarName())
+
+	if !ok {
+		r.error(v.Name, fmt.Sprintf("Variable '%s' is not defined", v.VarName()))
+		return unknown
+	}
+
+	return t
+}
+
+func (r *MSTypeResolver) resolveAssignmentExpression(a *ast.AssignmentNodeS) mstype.MSType {
+
+	val := r.resolveExpression(a.Exp)
+	target := r.resolveVariableExpression(a.Identifier)
+
+	if !r.assignable(target, val) {
+		msg := fmt.Sprintf("Variable '%s' is of type '%s' and cannot be assigned a value of type '%s'%s", a.Identifier.VarName(), target, val, r.conformance(target, val))
+		r.error(a.Identifier.Name, msg)
+	}
+
+	return target
+}
+
+func (r *MSTypeResolver) resolveDeclAssignExpression(da *ast.DeclAssignNodeS) mstype.MSType {
+	val := r.resolveExpression(da.Exp)
+	r.declareVar(da.Identifier.VarName(), val, da.Identifier.Name)
+	return val
+}
+
+// --------------------------------------------------------
+// operators
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveLiteralExpression(n *ast.LiteralExpNodeS) mstype.MSType {
+	switch n.Tk.Type {
+	case token.NUMBER_INT:		return mstype.MS_INT
+	case token.NUMBER_FLOAT:	return mstype.MS_FLOAT
+	case token.STRING:			return mstype.MS_STRING
+	case token.TRUE:			return mstype.MS_BOOL
+	case token.FALSE:			return mstype.MS_BOOL
+	case token.NOTHING_TYPE:	return mstype.MS_NOTHING
+	default:					return unknown
+	}
+}
+
+func (r *MSTypeResolver) resolveInterpolation(n *ast.InterpolationNodeS) mstype.MSType {
+
+	// Any value can be interpolated
+	for _, part := range n.Parts {
+		r.resolveExpression(part)
+	}
+
+	return mstype.MS_STRING
+}
+
+func (r *MSTypeResolver) resolveBinaryExpression(b *ast.BinaryExpNodeS) mstype.MSType {
+
+	lt := r.resolveExpression(b.Left)
+	rt := r.resolveExpression(b.Right)
+
+	l, rr := r.underlying(lt), r.underlying(rt)
+
+	if isUnknown(l) || isUnknown(rr) {
+		if isComparison(b.Op.Type) {
+			return mstype.MS_BOOL
+		}
+		return unknown
+	}
+
+	// Mirrors the operator implementations of the evaluator
+	switch b.Op.Type {
+	case token.PLUS:
+		if isSimple(l, mstype.RT_STRING) && isSimple(rr, mstype.RT_STRING) {
+			return mstype.MS_STRING
+		}
+		if isNumeric(l) && isNumeric(rr) {
+			return numericResult(l, rr)
+		}
+	case token.MULT:
+		if isSimple(l, mstype.RT_STRING) && isSimple(rr, mstype.RT_INT) {
+			return mstype.MS_STRING
+		}
+		if isNumeric(l) && isNumeric(rr) {
+			return numericResult(l, rr)
+		}
+	case token.SLASH:
+		if isNumeric(l) && isNumeric(rr) {
+			return mstype.MS_FLOAT
+		}
+	case token.PERCENT:
+		if isSimple(l, mstype.RT_INT) && isSimple(rr, mstype.RT_INT) {
+			return mstype.MS_INT
+		}
+	case token.GREATER, token.LESS, token.GREATER_EQ, token.LESS_EQ:
+		if isNumeric(l) && isNumeric(rr) {
+			return mstype.MS_BOOL
+		}
+	case token.EQ_EQ:
+		// Values of different types are never equal, but only
+		// simple values can be compared.
+		if isSimple(l, mstype.RT_INT, mstype.RT_FLOAT, mstype.RT_STRING, mstype.RT_BOOL, mstype.RT_NOTHING) {
+			return mstype.MS_BOOL
+		}
+	}
+
+	msg := fmt.Sprintf("Operator '%v' is not defined for types '%v' and '%v'", b.Op.Lexeme, lt, rt)
+	r.error(b.Op, msg)
+
+	return unknown
+}
+
+func (r *MSTypeResolver) resolveLogicalExpression(b *ast.LogicalExpNodeS) mstype.MSType {
+
+	lt := r.resolveExpression(b.Left)
+	rt := r.resolveExpression(b.Right)
+
+	if !r.compatible(mstype.MS_BOOL, lt) || !r.compatible(mstype.MS_BOOL, rt) {
+		msg := fmt.Sprintf("Logical operator '%v' is not defined for types '%v' and '%v'", b.Op.Lexeme, lt, rt)
+		r.error(b.Op, msg)
+	}
+
+	return mstype.MS_BOOL
+}
+
+func (r *MSTypeResolver) resolveUnaryExpression(u *ast.UnaryExpNodeS) mstype.MSType {
+
+	t := r.resolveExpression(u.Node)
+	ut := r.underlying(t)
+
+	if isUnknown(ut) {
+		return unknown
+	}
+
+	switch u.Op.Type {
+	case token.MINUS:
+		if isSimple(ut, mstype.RT_INT, mstype.RT_FLOAT) {
+			return t
+		}
+	case token.EXCLAMATION:
+		if isSimple(ut, mstype.RT_BOOL) {
+			return t
+		}
+	}
+
+	r.error(u.Op, fmt.Sprintf("Operator '%v' is not defined for type '%v'", u.Op.Lexeme, t))
+
+	return unknown
+}
+
+func isComparison(tt token.TokenType) bool {
+	switch tt {
+	case token.GREATER, token.LESS, token.GREATER_EQ, token.LESS_EQ, token.EQ_EQ:
+		return true
+	}
+	return false
+}
+
+func isSimple(t mstype.MSType, rts ...mstype.ResultType) bool {
+	st, ok := t.(*mstype.MSSimpleTypeS)
+	if !ok {
+		return false
+	}
+	for _, rt := range rts {
+		if st.Rt == rt {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumeric(t mstype.MSType) bool {
+	return isSimple(t, mstype.RT_INT, mstype.RT_FLOAT, mstype.RT_BOOL)
+}
+
+func numericResult(l, r mstype.MSType) mstype.MSType {
+	// int and bool arithmetic gives an int, anything with a float a float
+	if isSimple(l, mstype.RT_FLOAT) || isSimple(r, mstype.RT_FLOAT) {
+		return mstype.MS_FLOAT
+	}
+	return mstype.MS_INT
+}
+
+// --------------------------------------------------------
+// tuples
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveTuple(n *ast.TupleNodeS) mstype.MSType {
+
+	types, known := r.resolveExpressionsStarSensitive(n.Expressions)
+
+	if !known {
+		return unknown
+	}
+
+	return &mstype.MSCompositeTypeS{Types: types}
+}
+
+func (r *MSTypeResolver) resolveExpressionsStarSensitive(exprs []ast.ExpNodeI) ([]mstype.MSType, bool) {
+	// Returns false when the amount of values is only known at runtime,
+	// which is the case when unpacking an array.
+
+	types := []mstype.MSType{}
+	known := true
+
+	for _, expr := range exprs {
+
+		starred, ok := expr.(*ast.StarredExpNodeS)
+
+		if !ok {
+			types = append(types, r.resolveExpression(expr))
+			continue
+		}
+
+		t := r.resolveExpression(starred.Node)
+
+		switch ut := r.underlying(t).(type) {
+		case *mstype.MSCompositeTypeS:	types = append(types, ut.Types...)
+		case *mstype.MSArrayType:		known = false
+		case *msUnknownTypeS:			known = false
+		default:
+			r.error(ast.ExpToken(starred), fmt.Sprintf("Tried unpacking %s, which is not iterable", t))
+			known = false
+		}
+	}
+
+	return types, known
+}
+
+// --------------------------------------------------------
+// functions
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveFuncAppExpression(fa *ast.FuncAppNodeS) mstype.MSType {
+	fn := r.resolveExpression(fa.Fun)
+	args, known := r.resolveExpressionsStarSensitive(fa.Args)
+	return r.bindTypes(fn, args, known, ast.ExpToken(fa.Fun))
+}
+
+func (r *MSTypeResolver) resolveFuncExpression(n *ast.FuncExpNodeS) mstype.MSType {
+
+	for _, p := range n.Params {
+		r.checkType(p.Type, p.Iden.Name)
+	}
+	if n.Rt != nil {
+		r.checkType(n.Rt, n.Tk)
+	}
+
+	r.enterScope()
+	defer r.leaveScope()
+
+	for _, p := range n.Params {
+		r.declareVar(p.VarName(), p.Type, p.Iden.Name)
+	}
+
+	if n.Exp != nil {
+		return r.resolveShortFuncExpression(n)
+	}
+
+	r.returns = append(r.returns, n.Rt)
+	r.resolveStatements(n.Body.Statements)
+	r.returns = r.returns[:len(r.returns)-1]
+
+	if !isNothing(r.underlying(n.Rt)) && !alwaysReturns(n.Body.Statements) {
+		msg := fmt.Sprintf("Anonymous function must return a value of type '%s'", n.Rt)
+		r.error(n.Tk, msg)
+	}
+
+	return n.GetFuncType()
+}
+
+func (r *MSTypeResolver) resolveShortFuncExpression(n *ast.FuncExpNodeS) mstype.MSType {
+
+	t := r.resolveExpression(n.Exp)
+
+	if n.Rt != nil {
+		if !r.compatible(n.Rt, t) {
+			msg := fmt.Sprintf("Tried returning value of type '%s', expected type '%s'%s", t, n.Rt, r.conformance(n.Rt, t))
+			r.error(ast.ExpToken(n.Exp), msg)
+		}
+		return n.GetFuncType()
+	}
+
+	// The evaluator needs the return type for its own type checks
+	concrete, ok := r.concrete(t, 0)
+
+	// Unknown types are already reported
+	if !ok && !isUnknown(t) {
+		r.error(n.Tk, "Could not infer the return type of the function, declare it using '(...) -> type => ...'")
+	}
+
+	if !ok {
+		return unknown
+	}
+
+	n.Rt = concrete
+
+	return n.GetFuncType()
+}
+
+func (r *MSTypeResolver) resolveFuncCallExpression(fc *ast.FuncCallNodeS) mstype.MSType {
+	fn := r.resolveExpression(fc.Fun)
+	return r.callType(fn, fc.Op)
+}
+
+func (r *MSTypeResolver) resolveIterableFuncApplication(n *ast.IterableFuncAppNodeS) mstype.MSType {
+	fn := r.resolveExpression(n.Fun)
+	args := r.resolveExpression(n.Args)
+	tk := ast.ExpToken(n.Fun)
+
+	return r.mapIterable(args, tk, func(arg mstype.MSType) mstype.MSType {
+		return r.bindTypes(fn, []mstype.MSType{arg}, true, tk)
+	})
+}
+
+func (r *MSTypeResolver) resolveIterableFuncAppAndCall(n *ast.IterableFuncAppAndCallNodeS) mstype.MSType {
+	fn := r.resolveExpression(n.Fun)
+	args := r.resolveExpression(n.Args)
+	tk := ast.ExpToken(n.Fun)
+
+	return r.mapIterable(args, tk, func(arg mstype.MSType) mstype.MSType {
+		return r.callType(r.bindTypes(fn, []mstype.MSType{arg}, true, tk), tk)
+	})
+}
+
+func (r *MSTypeResolver) resolveIterableFuncCall(n *ast.IterableFuncCallNodeS) mstype.MSType {
+	fns := r.resolveExpression(n.Fun)
+
+	// Not iterable means a regular call
+	if _, ok := r.elemType(fns) ; !ok {
+		return r.callType(fns, n.Op)
+	}
+
+	return r.mapIterable(fns, n.Op, func(fn mstype.MSType) mstype.MSType {
+		return r.callType(fn, n.Op)
+	})
+}
+
+func (r *MSTypeResolver) bindTypes(fn mstype.MSType, args []mstype.MSType, known bool, tk token.Token) mstype.MSType {
+	// Type of 'args >> fn'
+
+	switch ft := r.underlying(fn).(type) {
+	case *msUnknownTypeS:
+		return unknown
+	case *msBuiltinTypeS:
+
+		if !known {
+			return unknown
+		}
+
+		t, err := ft.bind(r, args)
+
+		if err != nil {
+			r.error(tk, err.Error())
+			return unknown
+		}
+
+		return t
+
+	case *mstype.MSOperationTypeS:
+
+		// Can only check the parameters when we know what gets bound
+		if !known {
+			return unknown
+		}
+
+		if len(args) > len(ft.Left) {
+			msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", fn, len(ft.Left), len(args))
+			r.error(tk, msg)
+			return unknown
+		}
+
+		// Type parameters of generic functions are bound by the arguments
+		subst := make(map[string]mstype.MSType)
+
+		for i, arg := range args {
+
+			if err := r.unify(ft.Left[i], arg, subst, 0) ; err != nil {
+				msg := fmt.Sprintf("Cannot bind value of type '%s' to parameter %d of type '%s', %s", arg, i, ft.Left[i], err)
+				r.error(tk, msg)
+				continue
+			}
+
+			if expected := mstype.Substitute(ft.Left[i], subst) ; !r.compatible(expected, arg) {
+				msg := fmt.Sprintf("Cannot bind value of type '%s' to parameter %d of type '%s'%s", arg, i, expected, r.conformance(expected, arg))
+				r.error(tk, msg)
+			}
+		}
+
+		left := mstype.SubstituteList(ft.Left[len(args):], subst)
+
+		return &mstype.MSOperationTypeS{Left: left, Right: mstype.Substitute(ft.Right, subst)}
+	}
+
+	r.error(tk, fmt.Sprintf("Function application is not implemented for type '%s'", fn))
+
+	return unknown
+}
+
+func (r *MSTypeResolver) callType(fn mstype.MSType, tk token.Token) mstype.MSType {
+	// Type of '=fn'
+
+	switch ft := r.underlying(fn).(type) {
+	case *msUnknownTypeS:
+		return unknown
+	case *msBuiltinTypeS:
+
+		t, err := ft.call(r)
+
+		if err != nil {
+			r.error(tk, err.Error())
+			return unknown
+		}
+
+		return t
+
+	case *mstype.MSOperationTypeS:
+
+		if len(ft.Left) > 0 {
+			msg := fmt.Sprintf("Cannot call function of type '%s', %d parameter(s) are not bound", fn, len(ft.Left))
+			r.error(tk, msg)
+		}
+
+		return ft.Right
+	}
+
+	r.error(tk, fmt.Sprintf("Function call is not implemented for type '%s'", fn))
+
+	return unknown
+}
+
+func (r *MSTypeResolver) mapIterable(it mstype.MSType, tk token.Token, f func(mstype.MSType) mstype.MSType) mstype.MSType {
+	// Type of the iterable produced by applying f to every element of 'it'
+
+	switch t := r.underlying(it).(type) {
+	case *msUnknownTypeS:
+		f(unknown)
+		return unknown
+	case *mstype.MSArrayType:
+		return &mstype.MSArrayType{Type: f(t.Type)}
+	case *mstype.MSCompositeTypeS:
+		types := make([]mstype.MSType, len(t.Types))
+		for i, et := range t.Types {
+			types[i] = f(et)
+		}
+		return &mstype.MSCompositeTypeS{Types: types}
+	case *mstype.MSMapTypeS:
+		// Entries are mapped into an array
+		return &mstype.MSArrayType{Type: f(&mstype.MSCompositeTypeS{Types: []mstype.MSType{t.Key, t.Value}})}
+	}
+
+	r.error(tk, fmt.Sprintf("Function application arguments are not iterable, got type '%s'", it))
+
+	return unknown
+}
+
+// --------------------------------------------------------
+// arrays
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveArrayConstructor(n *ast.ArrayConstructorNodeS) mstype.MSType {
+
+	tk := ast.ExpToken(n)
+
+	r.checkType(n.Type, tk)
+
+	if n.N != nil {
+		if size := r.resolveExpression(n.N) ; !r.compatible(mstype.MS_INT, size) {
+			msg := fmt.Sprintf("Array size is of type '%s', expected type '%s'", size, mstype.MS_INT)
+			r.error(tk, msg)
+		}
+	}
+
+	for _, v := range n.Vals {
+		if vt := r.resolveExpression(v) ; !r.compatible(n.Type, vt) {
+			msg := fmt.Sprintf("Array value has type '%s' but expected '%s'%s", vt, n.Type, r.conformance(n.Type, vt))
+			r.error(ast.ExpToken(v), msg)
+		}
+	}
+
+	return &mstype.MSArrayType{Type: n.Type}
+}
+
+func (r *MSTypeResolver) resolveMapConstructor(n *ast.MapConstructorNodeS) mstype.MSType {
+
+	r.checkType(n.Type, n.Tk)
+
+	for i := range n.Keys {
+
+		if kt := r.resolveExpression(n.Keys[i]) ; !r.compatible(n.Type.Key, kt) {
+			msg := fmt.Sprintf("Map key has type '%s' but expected '%s'", kt, n.Type.Key)
+			r.error(ast.ExpToken(n.Keys[i]), msg)
+		}
+
+		if vt := r.resolveExpression(n.Vals[i]) ; !r.compatible(n.Type.Value, vt) {
+			msg := fmt.Sprintf("Map value has type '%s' but expected '%s'%s", vt, n.Type.Value, r.conformance(n.Type.Value, vt))
+			r.error(ast.ExpToken(n.Vals[i]), msg)
+		}
+	}
+
+	return n.Type
+}
+
+func (r *MSTypeResolver) resolveRangeConstructor(n *ast.RangeConstructorNodeS) mstype.MSType {
+
+	from := r.resolveExpression(n.From)
+	to := r.resolveExpression(n.To)
+
+	if !r.compatible(mstype.MS_INT, from) || !r.compatible(mstype.MS_INT, to) {
+		msg := fmt.Sprintf("Range constructor values must be of type 'int', got '%s' and '%s'", from, to)
+		r.error(ast.ExpToken(n), msg)
+	}
+
+	return &mstype.MSArrayType{Type: mstype.MS_INT}
+}
+
+func (r *MSTypeResolver) resolveArrayIndex(n *ast.ArrayIndexNodeS) mstype.MSType {
+	target := r.resolveExpression(n.Target)
+	return r.indexType(target, n.Index, ast.ExpToken(n.Target))
+}
+
+func (r *MSTypeResolver) resolveArrayAssignment(n *ast.ArrayAssignmentNodeS) mstype.MSType {
+
+	target := r.resolveExpression(n.Target)
+	elem := r.indexType(target, n.Index, ast.ExpToken(n.Target))
+	val := r.resolveExpression(n.Value)
+
+	if !r.assignable(elem, val) {
+		msg := fmt.Sprintf("Cannot assign value of type '%s', expected type '%s'%s", val, elem, r.conformance(elem, val))
+		r.error(ast.ExpToken(n.Target), msg)
+	}
+
+	return elem
+}
+
+func (r *MSTypeResolver) indexType(target mstype.MSType, index ast.ExpNodeI, tk token.Token) mstype.MSType {
+	// Type of 'target[index]'
+
+	it := r.resolveExpression(index)
+
+	// Maps are indexed using their keys
+	if mt, ok := r.underlying(target).(*mstype.MSMapTypeS) ; ok {
+
+		if !r.compatible(mt.Key, it) {
+			msg := fmt.Sprintf("Cannot use value of type '%s' as a key, expected type '%s'", it, mt.Key)
+			r.error(ast.ExpToken(index), msg)
+		}
+
+		return mt.Value
+	}
+
+	if !r.compatible(mstype.MS_INT, it) {
+		msg := fmt.Sprintf("Cannot use value of type '%s' as an index, expected type '%s'", it, mstype.MS_INT)
+		r.error(ast.ExpToken(index), msg)
+	}
+
+	switch t := r.underlying(target).(type) {
+	case *msUnknownTypeS:		return unknown
+	case *mstype.MSArrayType:	return t.Type
+	case *mstype.MSCompositeTypeS:
+
+		// The element type of a tuple is only known for constant indices
+		lit, ok := index.(*ast.LiteralExpNodeS)
+
+		if !ok || lit.Tk.Type != token.NUMBER_INT {
+			return unknown
+		}
+
+		i, _ := strconv.Atoi(lit.Tk.Lexeme)
+
+		if i >= len(t.Types) {
+			msg := fmt.Sprintf("Tuple index out of bounds: '%d', expected value in '[%d, %d]'", i, 0, len(t.Types) - 1)
+			r.error(ast.ExpToken(index), msg)
+			return unknown
+		}
+
+		return t.Types[i]
+	}
+
+	r.error(tk, fmt.Sprintf("Value of type '%s' is not indexable", target))
+
+	return unknown
+}
+
+// --------------------------------------------------------
+// structs
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveFieldAccess(n *ast.FieldAccessNodeS) mstype.MSType {
+	target := r.resolveExpression(n.Target)
+	return r.fieldType(target, n.Field)
+}
+
+func (r *MSTypeResolver) resolveFieldAssignment(n *ast.FieldAssignmentNode) mstype.MSType {
+
+	target := r.resolveExpression(n.Target)
+	field := r.fieldType(target, n.Field)
+	val := r.resolveExpression(n.Value)
+
+	if mt, ok := target.(*msModuleTypeS) ; ok {
+		msg := fmt.Sprintf("Cannot assign to '%s', members of module '%s' are read only", n.Field.VarName(), mt.module.Name())
+		r.error(n.Field.Name, msg)
+		return field
+	}
+
+	if isSimple(r.underlying(target), mstype.RT_ERROR) {
+		msg := fmt.Sprintf("Cannot assign to '%s', fields of errors are read only", n.Field.VarName())
+		r.error(n.Field.Name, msg)
+		return field
+	}
+
+	if r.isMethod(target, n.Field.VarName()) {
+		msg := fmt.Sprintf("Cannot assign to '%s', methods are read only", n.Field.VarName())
+		r.error(n.Field.Name, msg)
+		return field
+	}
+
+	if !r.assignable(field, val) {
+		msg := fmt.Sprintf("Field '%s' expects type '%s', got '%s'%s", n.Field.VarName(), field, val, r.conformance(field, val))
+		r.error(n.Field.Name, msg)
+	}
+
+	return field
+}
+
+func (r *MSTypeResolver) resolveStructConstructor(n *ast.StructConstructorNodeS) mstype.MSType {
+	for _, exp := range n.Fields {
+		r.resolveExpression(exp)
+	}
+	return n.Name
+}
+
+func (r *MSTypeResolver) fieldType(target mstype.MSType, field *ast.VariableExpNodeS) mstype.MSType {
+
+	switch t := r.underlying(target).(type) {
+	case *msUnknownTypeS:
+		return unknown
+	case *msModuleTypeS:
+
+		mt, ok := t.member(field.VarName())
+
+		if !ok {
+			msg := fmt.Sprintf("Module '%s' has no member '%s'", t.module.Name(), field.VarName())
+			r.error(field.Name, msg)
+			return unknown
+		}
+
+		return mt
+
+	case *mstype.MSSimpleTypeS:
+
+		if t.Rt != mstype.RT_ERROR {
+			break
+		}
+
+		if name := field.VarName() ; name != "kind" && name != "message" {
+			r.error(field.Name, fmt.Sprintf("Error has no field '%s', expected 'kind' or 'message'", name))
+			return unknown
+		}
+
+		return mstype.MS_STRING
+
+	case *mstype.MSStructTypeS:
+
+		ft, ok := t.Fields[field.VarName()]
+
+		if !ok {
+			ft, ok = r.method(t, field.VarName())
+		}
+
+		if !ok {
+			msg := fmt.Sprintf("Struct '%s' has no field or method '%s'", t.Name, field.VarName())
+			r.error(field.Name, msg)
+			return unknown
+		}
+
+		return ft
+
+	case *mstype.MSInterfaceTypeS:
+
+		mt, ok := t.Methods[field.VarName()]
+
+		if !ok {
+			msg := fmt.Sprintf("Interface '%s' has no method '%s'", t.Name, field.VarName())
+			r.error(field.Name, msg)
+			return unknown
+		}
+
+		return mt
+	}
+
+	r.error(field.Name, fmt.Sprintf("Value of type '%s' has no fields", target))
+
+	return unknown
+}
@@ -0,0 +1,128 @@
+package resolver
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+)
+
+// --------------------------------------------------------
+// generic functions
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) checkTypeParams(n *ast.FuncDeclNodeS) {
+	// Type parameters are inferred from the bound arguments, so
+	// every type parameter needs to appear in a parameter type.
+
+	for _, tp := range n.TypeParams {
+
+		used := false
+		for _, p := range n.Params {
+			used = used || containsTypeVar(p.Type, tp.VarName())
+		}
+
+		if !used {
+			msg := fmt.Sprintf("Type parameter '%s' of '%s' is not used by any parameter", tp.VarName(), n.Fname.VarName())
+			r.error(tp.Name, msg)
+		}
+	}
+}
+
+func containsTypeVar(t mstype.MSType, name string) bool {
+	switch tt := t.(type) {
+	case *mstype.MSTypeVarS:		return tt.Name == name
+	case *mstype.MSArrayType:		return containsTypeVar(tt.Type, name)
+	case *mstype.MSMapTypeS:		return containsTypeVar(tt.Key, name) || containsTypeVar(tt.Value, name)
+	case *mstype.MSCompositeTypeS:	return containsTypeVarList(tt.Types, name)
+	case *mstype.MSNamedTypeS:		return containsTypeVarList(tt.Args, name)
+	case *mstype.MSOperationTypeS:	return containsTypeVarList(tt.Left, name) || containsTypeVar(tt.Right, name)
+	default:						return false
+	}
+}
+
+func containsTypeVarList(ts []mstype.MSType, name string) bool {
+	for _, t := range ts {
+		if containsTypeVar(t, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// --------------------------------------------------------
+// unification
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) unify(param, arg mstype.MSType, subst map[string]mstype.MSType, depth int) error {
+	// Static version of 'mstype.Unify', binds the type parameters in
+	// param to the matching parts of arg. Only conflicting bindings
+	// are reported, the caller checks the substituted param.
+
+	if depth > maxTypeDepth {
+		return nil
+	}
+
+	if tv, ok := param.(*mstype.MSTypeVarS) ; ok {
+
+		// Nothing to learn from values we don't know the type of
+		if isUnknown(r.underlying(arg)) {
+			return nil
+		}
+
+		bound, ok := subst[tv.Name]
+
+		if !ok {
+			subst[tv.Name] = arg
+			return nil
+		}
+
+		if !r.compatible(bound, arg) {
+			return fmt.Errorf("type parameter '%s' is bound to '%s' and '%s'", tv.Name, bound, arg)
+		}
+
+		return nil
+	}
+
+	switch pt := r.underlying(param).(type) {
+	case *mstype.MSArrayType:
+		if at, ok := r.underlying(arg).(*mstype.MSArrayType) ; ok {
+			return r.unify(pt.Type, at.Type, subst, depth + 1)
+		}
+	case *mstype.MSMapTypeS:
+		if at, ok := r.underlying(arg).(*mstype.MSMapTypeS) ; ok {
+			return r.unifyLists([]mstype.MSType{pt.Key, pt.Value}, []mstype.MSType{at.Key, at.Value}, subst, depth + 1)
+		}
+	case *mstype.MSCompositeTypeS:
+		if at, ok := r.underlying(arg).(*mstype.MSCompositeTypeS) ; ok {
+			return r.unifyLists(pt.Types, at.Types, subst, depth + 1)
+		}
+	case *mstype.MSOperationTypeS:
+		if at, ok := r.underlying(arg).(*mstype.MSOperationTypeS) ; ok {
+			if err := r.unifyLists(pt.Left, at.Left, subst, depth + 1) ; err != nil {
+				return err
+			}
+			return r.unify(pt.Right, at.Right, subst, depth + 1)
+		}
+	case *mstype.MSStructTypeS:
+		if at, ok := r.underlying(arg).(*mstype.MSStructTypeS) ; ok && at.Name == pt.Name {
+			return r.unifyLists(pt.Args, at.Args, subst, depth + 1)
+		}
+	}
+
+	return nil
+}
+
+func (r *MSTypeResolver) unifyLists(params, args []mstype.MSType, subst map[string]mstype.MSType, depth int) error {
+
+	if len(params) != len(args) {
+		return nil
+	}
+
+	for i := range params {
+		if err := r.unify(params[i], args[i], subst, depth) ; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package resolver
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"slices"
+)
+
+// --------------------------------------------------------
+// methods
+// --------------------------------------------------------
+
+// Methods are functions declared against a struct type, their first
+// parameter is the receiver: 'function (point p) >> point.norm {...}'.
+// Accessing 'p.norm' binds p, so the type of the access is the type
+// of the method without its first parameter.
+
+func (r *MSTypeResolver) declareMethod(n *ast.FuncDeclNodeS) {
+
+	// The receiver is the first parameter
+	var nt *mstype.MSNamedTypeS
+	if len(n.Params) > 0 {
+		nt, _ = n.Params[0].Type.(*mstype.MSNamedTypeS)
+	}
+
+	if nt == nil || nt.Name != n.Receiver.VarName() || nt.Namespace != "" {
+		msg := fmt.Sprintf("Method '%s.%s' needs a first parameter of type '%s'", n.Receiver.VarName(), n.Fname.VarName(), n.Receiver.VarName())
+		r.error(n.Receiver.Name, msg)
+		return
+	}
+
+	def, found := r.lookupNamedType(nt)
+
+	if !found {
+		return	// reported when checking the parameter types
+	}
+
+	st, ok := def.(*mstype.MSStructTypeS)
+
+	if !ok {
+		msg := fmt.Sprintf("Cannot declare method '%s' on '%s', it is not a struct", n.Fname.VarName(), n.Receiver.VarName())
+		r.error(n.Receiver.Name, msg)
+		return
+	}
+
+	if st.Generic() {
+		msg := fmt.Sprintf("Cannot declare method '%s' on generic struct '%s'", n.Fname.VarName(), st.Name)
+		r.error(n.Receiver.Name, msg)
+		return
+	}
+
+	if _, ok := st.Fields[n.Fname.VarName()] ; ok {
+		msg := fmt.Sprintf("Struct '%s' already has a field '%s'", st.Name, n.Fname.VarName())
+		r.error(n.Fname.Name, msg)
+		return
+	}
+
+	if _, ok := r.method(st, n.Fname.VarName()) ; ok {
+		msg := fmt.Sprintf("Struct '%s' already has a method '%s'", st.Name, n.Fname.VarName())
+		r.error(n.Fname.Name, msg)
+		return
+	}
+
+	if r.methods[st] == nil {
+		r.methods[st] = make(map[string]mstype.MSType)
+	}
+
+	r.methods[st][n.Fname.VarName()] = n.GetMethodType()
+}
+
+// Type of the method with its receiver bound
+func (r *MSTypeResolver) method(st *mstype.MSStructTypeS, name string) (mstype.MSType, bool) {
+	t, ok := r.methods[st][name]
+	return t, ok
+}
+
+// Methods declared so far, see 'snapshotGlobals'
+func (r *MSTypeResolver) snapshotMethods() map[*mstype.MSStructTypeS]map[string]bool {
+	snapshot := make(map[*mstype.MSStructTypeS]map[string]bool)
+	for st, ms := range r.methods {
+		snapshot[st] = make(map[string]bool)
+		for name := range ms {
+			snapshot[st][name] = true
+		}
+	}
+	return snapshot
+}
+
+func (r *MSTypeResolver) restoreMethods(snapshot map[*mstype.MSStructTypeS]map[string]bool) {
+	// Restore in place, like the globals
+	for st, ms := range r.methods {
+		for name := range ms {
+			if !snapshot[st][name] {
+				delete(ms, name)
+			}
+		}
+	}
+}
+
+// Is name a method of values of type t, rather than a field
+func (r *MSTypeResolver) isMethod(t mstype.MSType, name string) bool {
+	switch tt := r.underlying(t).(type) {
+	case *mstype.MSInterfaceTypeS:
+		_, ok := tt.Methods[name]
+		return ok
+	case *mstype.MSStructTypeS:
+		_, isField := tt.Fields[name]
+		_, ok := r.method(tt, name)
+		return ok && !isField
+	}
+	return false
+}
+
+// --------------------------------------------------------
+// interfaces
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveInterfaceDeclaration(id *ast.InterfaceDeclarationNodeS) {
+
+	// Already declared while hoisting
+	seen := make(map[string]bool)
+
+	for name, m := range id.Methods {
+
+		if seen[name.VarName()] {
+			msg := fmt.Sprintf("Method '%s' is already defined in interface '%s'", name.VarName(), id.Name.VarName())
+			r.error(name.Name, msg)
+		}
+		seen[name.VarName()] = true
+
+		r.checkType(m, name.Name)
+	}
+}
+
+// Conformance is structural, t implements the interface when it
+// has every method of the interface, with the same type.
+func (r *MSTypeResolver) implements(t mstype.MSType, it *mstype.MSInterfaceTypeS) bool {
+
+	var method func(name string) (mstype.MSType, bool)
+
+	switch tt := t.(type) {
+	case *mstype.MSStructTypeS:
+		method = func(name string) (mstype.MSType, bool) {
+			return r.method(tt, name)
+		}
+	case *mstype.MSInterfaceTypeS:
+
+		if tt.Name == it.Name {
+			return true
+		}
+
+		method = func(name string) (mstype.MSType, bool) {
+			m, ok := tt.Methods[name]
+			return m, ok
+		}
+	default:
+		return false
+	}
+
+	for name, expected := range it.Methods {
+		if got, ok := method(name) ; !ok || !r.compatibleDepth(expected, got, 1) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Why got does not implement the interface expected, the first method
+// it misses or has with another type. Appended to the errors of
+// failed conversions, "" when expected is not an interface.
+func (r *MSTypeResolver) conformance(expected, got mstype.MSType) string {
+
+	it, ok := r.underlying(expected).(*mstype.MSInterfaceTypeS)
+
+	if !ok {
+		return ""
+	}
+
+	var method func(name string) (mstype.MSType, bool)
+
+	switch gt := r.underlying(got).(type) {
+	case *mstype.MSStructTypeS:
+		method = func(name string) (mstype.MSType, bool) {
+			return r.method(gt, name)
+		}
+	case *mstype.MSInterfaceTypeS:
+		method = func(name string) (mstype.MSType, bool) {
+			m, ok := gt.Methods[name]
+			return m, ok
+		}
+	default:
+		return fmt.Sprintf(", '%s' has no methods", got)
+	}
+
+	names := make([]string, 0, len(it.Methods))
+	for name := range it.Methods {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+
+		expected := it.Methods[name]
+		m, ok := method(name)
+
+		if !ok {
+			return fmt.Sprintf(", '%s' has no method '%s' of type '%s'", got, name, expected)
+		}
+
+		if !r.compatibleDepth(expected, m, 1) {
+			return fmt.Sprintf(", method '%s' of '%s' has type '%s' instead of '%s'", name, got, m, expected)
+		}
+	}
+
+	return ""
+}
@@ -1,279 +1,713 @@
 package resolver
 
-// import (
-// 	"fmt"
-// 	"mikescript/src/ast"
-// 	"mikescript/src/mstype"
-// )
-
-// type TypeScope map[string]mstype.MSType
-
-// func newTypeScope() TypeScope {
-// 	return make(TypeScope)
-// }
-
-// func NewMSTypeResolver(ast *ast.Program) MSTypeResolver{
-// 	return MSTypeResolver{
-// 		Ast: ast,
-// 		TypeScopes: make([]TypeScope, 4),
-// 	}
-// }
-
-// func (r *MSTypeResolver) SetAst(ast *ast.Program) {
-// 	r.Ast = ast
-// }
-
-// func (r *MSTypeResolver) Reset() {
-// 	r.TypeScopes = make([]TypeScope, 0, 10)
-// }
-
-// type MSTypeResolver struct {
-// 	Ast *ast.Program
-// 	TypeScopes []TypeScope
-// }
-
-// func (r *MSTypeResolver) currentTypeScope() *TypeScope {
-// 	if len(r.TypeScopes) == 0 {
-// 		return nil
-// 	}
-// 	return &r.TypeScopes[len(r.TypeScopes)-1]
-// }
-
-// func (r *MSTypeResolver) enterTypeScope() {
-// 	r.TypeScopes = append(r.TypeScopes, newTypeScope())
-// }
-
-// func (r *MSTypeResolver) leaveTypeScope() {
-// 	r.TypeScopes = r.TypeScopes[:len(r.TypeScopes)-1]
-// }
-
-// func (r *MSTypeResolver) declare(name string, typ mstype.MSType) {
-// 	fmt.Printf(".   TYPE: ++ Declared '%s' --> %p '%+v'\n", name, typ, typ)
-// 	(*r.currentTypeScope())[name] = typ
-// }
-
-// func (r *MSTypeResolver) resolveLocalType(nt *mstype.MSNamedTypeS) {
-
-// 	// Look for the first occurence of the name in scope stack
-
-// 	var l int = len(r.TypeScopes) - 1
-// 	var i int = l
-// 	var name string = nt.Name
-
-// 	for ; i >= 0 ; i-- {
-
-// 		if typ, ok := r.TypeScopes[i][name] ; ok {
-// 			fmt.Printf(".   TYPE: -- resolved %p '%+v' to %p == '%v'\n", nt, nt, typ, typ)
-// 			nt.Ref = typ
-// 			return
-// 		}
-// 	}
-
-// 	fmt.Printf("ERROR: UNABLE TO RESOLVE NAMED TYPE '%s'\n", nt)
-
-// }
-
-// // --------------------------------------------------------
-// // resolve
-// // --------------------------------------------------------
-
-// func (r *MSTypeResolver) Resolve() {
-// 	r.enterTypeScope()
-// 	r.resolveStatement(r.Ast)
-// 	r.leaveTypeScope()
-// }
-
-// func (r *MSTypeResolver) resolveStatement(stm ast.StmtNodeI) {
-// 	// fmt.Printf("%p // %#v\n", stm, stm)
-// 	switch st := stm.(type) {
-// 	case *ast.Program:					r.resolveStatements(st.Statements)
-// 	case *ast.BlockNodeS: 				r.resolveBlockNode(st)
-// 	case *ast.VarDeclNodeS:				r.resolveVariableDeclaration(st)
-// 	case *ast.ExStmtNodeS:				r.resolveExpression(st.Ex)
-// 	case *ast.IfNodeS:					r.resolveIfNode(st)
-// 	case *ast.WhileNodeS:				r.resolveWhileNode(st)
-// 	case *ast.ReturnNodeS:				r.resolveExpression(st.Node)
-// 	case *ast.FuncDeclNodeS:			r.resolveFuncDeclaration(st)
-// 	case *ast.TypeDefStatementS: 		r.resolveTypeDeclaration(st)
-// 	case *ast.StructDeclarationNodeS:	r.resolveStructDeclaration(st)
-// 	case *ast.BreakNodeS:				return 	// nothing to resolve
-// 	default:							_ = []int{}[0]
-// 	}
-// }
-
-// func (r *MSTypeResolver) resolveExpression(n ast.ExpNodeI) {
-// 	// fmt.Printf("%p // %#v\n", n, n)
-// 	switch ex := n.(type){
-// 	case *ast.AssignmentNodeS:			r.resolveAssignmentExpression(ex)
-// 	case *ast.DeclAssignNodeS:			r.resolveDeclAssignExpression(ex)
-// 	case *ast.FuncAppNodeS:				r.resolveFuncAppExpression(ex)
-// 	case *ast.FuncCallNodeS:			r.resolveExpression(ex.Fun)
-// 	case *ast.BinaryExpNodeS:			r.resolveBinaryExpression(ex)
-// 	case *ast.LogicalExpNodeS:			r.resolveLogicalExpression(ex)
-// 	case *ast.UnaryExpNodeS:			r.resolveExpression(ex.Node)
-// 	case *ast.TupleNodeS:				r.resolveExpressions(ex.Expressions)
-// 	case *ast.VariableExpNodeS:			r.resolveVariableExpression(ex)
-// 	case *ast.GroupExpNodeS:			r.resolveExpression(ex.Node)
-// 	case *ast.ArrayConstructorNodeS:	r.resolveArrayConstructor(ex)
-// 	case *ast.ArrayIndexNodeS:			r.resolveArrayIndex(ex)
-// 	case *ast.ArrayAssignmentNodeS:		r.resolveArrayAssignment(ex)
-// 	case *ast.FieldAccessNodeS:			r.resolveExpression(ex.Target)
-// 	case *ast.StructConstructorNodeS:	r.resolveStructConstructor(ex)
-// 	case *ast.FieldAssignmentNode:		r.resolveFieldAssignment(ex)
-// 	case *ast.LiteralExpNodeS:			return 	// nothing to resolve
-// 	default:							fmt.Printf("%v\n", ex) ; _ = []int{}[0]
-// 	}
-// }
-
-// func (r *MSTypeResolver) resolveType(n mstype.MSType) {
-// 	// fmt.Printf("%p // %#v\n", n, n)
-// 	switch t := n.(type){
-// 	case *mstype.MSSimpleTypeS:		return
-// 	case *mstype.MSCompositeTypeS:	r.resolveTypes(t.Types)
-// 	case *mstype.MSArrayType:		r.resolveType(t.Type)
-// 	case *mstype.MSOperationTypeS:	r.resolveOperationType(t)
-// 	case *mstype.MSStructTypeS:		r.resolveStructType(t)
-// 	case *mstype.MSNamedTypeS:		r.resolveNamedType(t)
-// 	default:						_ = []int{}[0]
-// 	}
-// }
-
-// // --------------------------------------------------------
-// // statements
-// // --------------------------------------------------------
-
-// func (r *MSTypeResolver) resolveStructDeclaration(sd *ast.StructDeclarationNodeS) {
-
-// 	// Convert StructDecl to type
-// 	fields := make(map[string]mstype.MSType)
-// 	for name, field := range sd.Fields {
-// 		fields[name.VarName()] = field
-// 	}
-// 	sdt := &mstype.MSStructTypeS{Name: sd.Name.VarName(), Fields: fields}
-
-// 	// declare the struct as a type
-// 	r.declare(sd.Name.VarName(), sdt)
-
-// 	// resolve all types in struct
-// 	for _, field := range sd.Fields {
-// 		r.resolveType(field)
-// 	}
-// }
-
-// func (r *MSTypeResolver) resolveTypeDeclaration(td *ast.TypeDefStatementS) {
-// 	r.declare(td.Tname.VarName(), td.Type)
-// 	r.resolveType(td.Type)
-// }
-
-// func (r *MSTypeResolver) resolveStatements(stmts []ast.StmtNodeI) {
-// 	for _, stmt := range stmts{
-// 		r.resolveStatement(stmt)
-// 	}
-// }
-
-// func (r *MSTypeResolver) resolveBlockNode(n *ast.BlockNodeS) {
-// 	r.enterTypeScope()
-// 	r.resolveStatements(n.Statements)
-// 	r.leaveTypeScope()
-// }
-
-// func (r *MSTypeResolver) resolveVariableDeclaration(n *ast.VarDeclNodeS) {
-// 	r.resolveType(n.Vartype)
-// }
-
-// func (r *MSTypeResolver) resolveFuncDeclaration(n *ast.FuncDeclNodeS) {
-
-// 	// Resolve argument types, they are
-// 	// evaluated outside the function
-// 	for _, p := range n.Params {
-// 		r.resolveType(p.Type)
-// 	}
-
-// 	r.enterTypeScope()
-// 	r.resolveStatements(n.Body.Statements)
-// 	r.leaveTypeScope()
-// }
-
-// func (r *MSTypeResolver) resolveIfNode(n *ast.IfNodeS) {
-// 	r.resolveExpression(n.Condition)
-// 	r.resolveStatement(n.ThenStmt)
-// 	if n.ElseStmt != nil {
-// 		r.resolveStatement(n.ElseStmt)
-// 	}
-// }
-
-// func (r *MSTypeResolver) resolveWhileNode(n *ast.WhileNodeS) {
-// 	r.resolveExpression(n.Condition)
-// 	r.resolveStatement(n.Body)
-// }
-
-// func (r *MSTypeResolver) resolveExpressions(es []ast.ExpNodeI) {
-// 	for _, e := range es {
-// 		r.resolveExpression(e)
-// 	}
-// }
-
-// // --------------------------------------------------------
-// // expressions
-// // --------------------------------------------------------
-
-// func (r *MSTypeResolver) resolveFieldAssignment(n *ast.FieldAssignmentNode) {
-// 	r.resolveExpression(n.Target)
-// 	r.resolveExpression(n.Value)
-// }
-
-// func (r *MSTypeResolver) resolveStructConstructor(n *ast.StructConstructorNodeS) {
-// 	r.resolveType(n.Name)
-// 	for _, exp := range n.Fields {
-// 		r.resolveExpression(exp)
-// 	}
-// }
-
-// func (r *MSTypeResolver) resolveArrayAssignment(n *ast.ArrayAssignmentNodeS) {
-// 	r.resolveExpression(n.Index)
-// 	r.resolveExpression(n.Target)
-// 	r.resolveExpression(n.Value)
-// }
-
-// func (r *MSTypeResolver) resolveArrayConstructor(n *ast.ArrayConstructorNodeS) {
-// 	r.resolveType(n.Type)
-// 	if n.N != nil {
-// 		r.resolveExpression(n.N)
-// 	}
-// 	r.resolveExpressions(n.Vals)
-// }
-
-// func (r *MSTypeResolver) resolveArrayIndex(n *ast.ArrayIndexNodeS) {
-// 	r.resolveExpression(n.Target)
-// 	r.resolveExpression(n.Index)
-// }
-
-// func (r *MSTypeResolver) resolveVariableExpression(v *ast.VariableExpNodeS) {
-// }
-
-// func (r *MSTypeResolver) resolveAssignmentExpression(a *ast.AssignmentNodeS) {
-// 	r.resolveExpression(a.Exp)
-// }
-
-// func (r *MSTypeResolver) resolveBinaryExpression(b *ast.BinaryExpNodeS) {
-// 	r.resolveExpression(b.Left)
-// 	r.resolveExpression(b.Right)
-// }
-
-// func (r *MSTypeResolver) resolveLogicalExpression(b *ast.LogicalExpNodeS) {
-// 	r.resolveExpression(b.Left)
-// 	r.resolveExpression(b.Right)
-// }
-
-// func (r *MSTypeResolver) resolveDeclAssignExpression(da *ast.DeclAssignNodeS) {
-// 	r.resolveExpression(da.Exp)
-// }
-
-// func (r *MSTypeResolver) resolveFuncAppExpression(fa *ast.FuncAppNodeS) {
-// 	r.resolveExpression(fa.Fun)
-// 	r.resolveExpressions(fa.Args)
-// }
-
-// // --------------------------------------------------------
-// // types
-// // --------------------------------------------------------
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+)
+
+// Static type checker, runs after the variable resolver and before
+// the evaluator. Every expression gets a type inferred, every binding,
+// assignment and return is checked against the declared types. All
+// errors are collected so they can be reported in one go.
+
+type TypeScope struct {
+	vars map[string]mstype.MSType		// variable name -> static type
+	types map[string]mstype.MSType		// type name -> definition
+}
+
+func newTypeScope() TypeScope {
+	return TypeScope{
+		vars: make(map[string]mstype.MSType),
+		types: make(map[string]mstype.MSType),
+	}
+}
+
+func NewMSTypeResolver(ast *ast.Program) MSTypeResolver {
+	r := MSTypeResolver{Ast: ast}
+	r.Reset()
+	return r
+}
+
+type MSTypeResolver struct {
+	Ast *ast.Program
+	Errors []TypeError
+	scopes []TypeScope				// scopes[0] is the global scope
+	returns []mstype.MSType			// return types of the enclosing functions
+	methods map[*mstype.MSStructTypeS]map[string]mstype.MSType	// methods of the structs, receiver bound
+	modules *MSModuleLoader			// loads the modules used in 'import'
+	importErr error					// first module which could not be imported
+}
+
+func (r *MSTypeResolver) SetAst(ast *ast.Program) {
+	r.Ast = ast
+}
+
+func (r *MSTypeResolver) Reset() {
+
+	// The global scope is kept between runs, the REPL
+	// checks every line separately.
+	if len(r.scopes) == 0 {
+		r.scopes = []TypeScope{newTypeScope()}
+		r.methods = make(map[*mstype.MSStructTypeS]map[string]mstype.MSType)
+		r.declareBuiltins()
+	}
+
+	r.scopes = r.scopes[:1]
+	r.returns = make([]mstype.MSType, 0)
+	r.Errors = make([]TypeError, 0)
+	r.importErr = nil
+}
+
+// Makes a value defined outside of MikeScript known to the checker.
+func (r *MSTypeResolver) DeclareGlobal(name string, t mstype.MSType) {
+	if len(r.scopes) == 0 {
+		r.Reset()
+	}
+	r.scopes[0].vars[name] = t
+}
+
+// Globals and global types known after the last 'Resolve',
+// including the builtins.
+func (r *MSTypeResolver) Globals() (vars map[string]mstype.MSType, types map[string]mstype.MSType) {
+	if len(r.scopes) == 0 {
+		r.Reset()
+	}
+	snapshot := r.snapshotGlobals()
+	return snapshot.vars, snapshot.types
+}
+
+// --------------------------------------------------------
+// scopes
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) currentScope() *TypeScope {
+	return &r.scopes[len(r.scopes)-1]
+}
+
+func (r *MSTypeResolver) enterScope() {
+	r.scopes = append(r.scopes, newTypeScope())
+}
+
+func (r *MSTypeResolver) leaveScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *MSTypeResolver) declareVar(name string, t mstype.MSType, tk token.Token) {
+
+	current := r.currentScope()
+
+	if old, ok := current.vars[name] ; ok {
+		r.error(tk, fmt.Sprintf("Variable '%s' is already defined as '%s'", name, old))
+		return
+	}
+
+	current.vars[name] = t
+}
+
+func (r *MSTypeResolver) declareType(name string, t mstype.MSType, tk token.Token) {
+
+	current := r.currentScope()
+
+	if old, ok := current.types[name] ; ok {
+		r.error(tk, fmt.Sprintf("Type '%s' is already defined as '%s'", name, old))
+		return
+	}
+
+	current.types[name] = t
+}
+
+func (r *MSTypeResolver) lookupVar(name string) (mstype.MSType, bool) {
+	for i := len(r.scopes) - 1 ; i >= 0 ; i-- {
+		if t, ok := r.scopes[i].vars[name] ; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (r *MSTypeResolver) lookupType(name string) (mstype.MSType, bool) {
+	for i := len(r.scopes) - 1 ; i >= 0 ; i-- {
+		if t, ok := r.scopes[i].types[name] ; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (r *MSTypeResolver) error(tk token.Token, msg string) {
+	r.Errors = append(r.Errors, TypeError{msg: msg, line: tk.Line, col: tk.Col})
+}
+
+// --------------------------------------------------------
+// resolve
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) Resolve() []TypeError {
+
+	// A program which does not type check is never run,
+	// so its global declarations should not stick around.
+	globals := r.snapshotGlobals()
+	methods := r.snapshotMethods()
+
+	r.resolveStatement(r.Ast)
+
+	if len(r.Errors) > 0 {
+		r.restoreGlobals(globals)
+		r.restoreMethods(methods)
+	}
+
+	return r.Errors
+}
+
+func (r *MSTypeResolver) snapshotGlobals() TypeScope {
+	snapshot := newTypeScope()
+	for k, v := range r.scopes[0].vars {
+		snapshot.vars[k] = v
+	}
+	for k, v := range r.scopes[0].types {
+		snapshot.types[k] = v
+	}
+	return snapshot
+}
+
+func (r *MSTypeResolver) restoreGlobals(snapshot TypeScope) {
+	// Restore in place, the maps are shared with copies of the resolver
+	glb := r.scopes[0]
+	for k := range glb.vars {
+		if _, ok := snapshot.vars[k] ; !ok {
+			delete(glb.vars, k)
+		}
+	}
+	for k := range glb.types {
+		if _, ok := snapshot.types[k] ; !ok {
+			delete(glb.types, k)
+		}
+	}
+}
+
+func (r *MSTypeResolver) resolveStatement(stm ast.StmtNodeI) {
+	switch st := stm.(type) {
+	case *ast.Program:					r.resolveStatements(st.Statements)
+	case *ast.BlockNodeS: 				r.resolveBlockNode(st)
+	case *ast.VarDeclNodeS:				r.resolveVariableDeclaration(st)
+	case *ast.ExStmtNodeS:				r.resolveExpression(st.Ex)
+	case *ast.IfNodeS:					r.resolveIfNode(st)
+	case *ast.WhileNodeS:				r.resolveWhileNode(st)
+	case *ast.ForNodeS:					r.resolveForNode(st)
+	case *ast.ReturnNodeS:				r.resolveReturnNode(st)
+	case *ast.FuncDeclNodeS:			r.resolveFuncDeclaration(st)
+	case *ast.TypeDefStatementS: 		r.resolveTypeDeclaration(st)
+	case *ast.StructDeclarationNodeS:	r.resolveStructDeclaration(st)
+	case *ast.EnumDeclarationNodeS:		r.resolveEnumDeclaration(st)
+	case *ast.InterfaceDeclarationNodeS:	r.resolveInterfaceDeclaration(st)
+	case *ast.XifNodeS:					r.resolveXifStatement(st)
+	case *ast.ImportNodeS:				r.resolveImport(st)
+	case *ast.ThrowNodeS:				r.resolveThrowNode(st)
+	case *ast.TryNodeS:					r.resolveTryNode(st)
+	case *ast.BreakNodeS:				return 	// nothing to check
+	case *ast.ContinueNodeS:			return 	// nothing to check
+	default:							fmt.Printf("Type resolving: %v\n", st); _ = []int{}[0]
+	}
+}
+
+// --------------------------------------------------------
+// statements
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveStatements(stmts []ast.StmtNodeI) {
+	r.hoistDeclarations(stmts)
+	for _, stmt := range stmts {
+		r.resolveStatement(stmt)
+	}
+}
+
+func (r *MSTypeResolver) hoistDeclarations(stmts []ast.StmtNodeI) {
+	// Functions can call functions which are declared further down
+	// in the same scope, so all signatures are declared up front.
+	// Types come first since signatures may refer to them.
+
+	for _, stmt := range stmts {
+		switch st := stmt.(type) {
+		case *ast.TypeDefStatementS:		r.declareType(st.Tname.VarName(), st.Type, st.Tname.Name)
+		case *ast.StructDeclarationNodeS:	r.declareType(st.Name.VarName(), st.GetStructType(), st.Name.Name)
+		case *ast.EnumDeclarationNodeS:		r.declareType(st.Name.VarName(), st.GetEnumType(), st.Name.Name)
+		case *ast.InterfaceDeclarationNodeS:	r.declareType(st.Name.VarName(), st.GetInterfaceType(), st.Name.Name)
+		}
+	}
+
+	for _, stmt := range stmts {
+		switch st := stmt.(type) {
+		case *ast.FuncDeclNodeS:
+			if st.IsMethod() {
+				r.declareMethod(st)
+			} else {
+				r.declareVar(st.Fname.VarName(), st.GetFuncType(), st.Fname.Name)
+			}
+		case *ast.EnumDeclarationNodeS:	r.declareVariants(st)
+		}
+	}
+}
+
+func (r *MSTypeResolver) resolveBlockNode(n *ast.BlockNodeS) {
+	r.enterScope()
+	r.resolveStatements(n.Statements)
+	r.leaveScope()
+}
+
+func (r *MSTypeResolver) resolveVariableDeclaration(n *ast.VarDeclNodeS) {
+	r.checkType(n.Vartype, n.Identifier.Name)
+	r.declareVar(n.VarName(), n.Vartype, n.Identifier.Name)
+}
+
+func (r *MSTypeResolver) resolveTypeDeclaration(td *ast.TypeDefStatementS) {
+	// Already declared while hoisting
+	r.checkType(td.Type, td.Tname.Name)
+}
+
+func (r *MSTypeResolver) resolveStructDeclaration(sd *ast.StructDeclarationNodeS) {
+	// Already declared while hoisting
+	for _, field := range sd.Fields {
+		r.checkType(field, sd.Name.Name)
+	}
+}
+
+func (r *MSTypeResolver) resolveFuncDeclaration(n *ast.FuncDeclNodeS) {
+
+	// The signature is already declared while hoisting
+	for _, p := range n.Params {
+		r.checkType(p.Type, p.Iden.Name)
+	}
+	r.checkType(n.Rt, n.Fname.Name)
+	r.checkTypeParams(n)
+
+	r.enterScope()
+	r.returns = append(r.returns, n.Rt)
+
+	for _, p := range n.Params {
+		r.declareVar(p.VarName(), p.Type, p.Iden.Name)
+	}
+	r.resolveStatements(n.Body.Statements)
+
+	if !isNothing(r.underlying(n.Rt)) && !alwaysReturns(n.Body.Statements) {
+		msg := fmt.Sprintf("Function '%s' must return a value of type '%s'", n.Fname.VarName(), n.Rt)
+		r.error(n.Fname.Name, msg)
+	}
+
+	r.returns = r.returns[:len(r.returns)-1]
+	r.leaveScope()
+}
+
+func (r *MSTypeResolver) resolveReturnNode(n *ast.ReturnNodeS) {
+
+	// The implicit 'return nothing;' is only reachable when
+	// there is no explicit return, see 'alwaysReturns'.
+	if n.Implicit() || len(r.returns) == 0 {
+		return
+	}
+
+	expected := r.returns[len(r.returns)-1]
+	got := r.resolveExpression(n.Node)
+
+	if !r.compatible(expected, got) {
+		msg := fmt.Sprintf("Tried returning value of type '%s', expected type '%s'%s", got, expected, r.conformance(expected, got))
+		r.error(n.Tk, msg)
+	}
+}
+
+func (r *MSTypeResolver) resolveThrowNode(n *ast.ThrowNodeS) {
+
+	t := r.resolveExpression(n.Node)
+
+	if !r.compatible(mstype.MS_STRING, t) && !r.compatible(mstype.MS_ERROR, t) {
+		msg := fmt.Sprintf("Cannot throw value of type '%s', expected '%s' or '%s'", t, mstype.MS_STRING, mstype.MS_ERROR)
+		r.error(ast.ExpToken(n.Node), msg)
+	}
+}
+
+func (r *MSTypeResolver) resolveTryNode(n *ast.TryNodeS) {
+
+	r.resolveBlockNode(n.Body)
+
+	// The caught error is in the same scope as the body of catch
+	if n.Catch != nil {
+		r.enterScope()
+		if n.Err != nil {
+			r.declareVar(n.Err.VarName(), mstype.MS_ERROR, n.Err.Name)
+		}
+		r.resolveStatements(n.Catch.Statements)
+		r.leaveScope()
+	}
+
+	if n.Finally != nil {
+		r.resolveBlockNode(n.Finally)
+	}
+}
+
+func (r *MSTypeResolver) resolveIfNode(n *ast.IfNodeS) {
+	r.expectCondition(n.Condition)
+	r.resolveStatement(n.ThenStmt)
+	if n.ElseStmt != nil {
+		r.resolveStatement(n.ElseStmt)
+	}
+}
+
+func (r *MSTypeResolver) resolveWhileNode(n *ast.WhileNodeS) {
+	r.expectCondition(n.Condition)
+	r.resolveStatement(n.Body)
+}
+
+func (r *MSTypeResolver) resolveForNode(n *ast.ForNodeS) {
+
+	it := r.resolveExpression(n.Iterable)
+	elem, ok := r.elemType(it)
+
+	if !ok {
+		msg := fmt.Sprintf("Value of type '%s' is not iterable", it)
+		r.error(ast.ExpToken(n.Iterable), msg)
+		elem = unknown
+	}
+
+	r.enterScope()
+	r.declareVar(n.LoopVar.VarName(), elem, n.LoopVar.Name)
+	r.resolveStatements(n.Body.Statements)
+	r.leaveScope()
+}
+
+func (r *MSTypeResolver) expectCondition(cond ast.ExpNodeI) {
+	t := r.resolveExpression(cond)
+	if !r.compatible(mstype.MS_BOOL, t) {
+		msg := fmt.Sprintf("Condition must be of type '%s', got '%s'", mstype.MS_BOOL, t)
+		r.error(ast.ExpToken(cond), msg)
+	}
+}
+
+func alwaysReturns(stmts []ast.StmtNodeI) bool {
+	// Does executing the statements always end in an explicit return
+	// or a throw? A loop which never exits does not end at all.
+	for _, stmt := range stmts {
+		switch st := stmt.(type) {
+		case *ast.ReturnNodeS:
+			if !st.Implicit() {
+				return true
+			}
+		case *ast.BlockNodeS:
+			if alwaysReturns(st.Statements) {
+				return true
+			}
+		case *ast.IfNodeS:
+			if st.ElseStmt == nil {
+				continue
+			}
+			then := alwaysReturns([]ast.StmtNodeI{st.ThenStmt})
+			other := alwaysReturns([]ast.StmtNodeI{st.ElseStmt})
+			if then && other {
+				return true
+			}
+		case *ast.XifNodeS:
+			if xifAlwaysReturns(st) {
+				return true
+			}
+		case *ast.ThrowNodeS:
+			return true
+		case *ast.TryNodeS:
+			if tryAlwaysReturns(st) {
+				return true
+			}
+		case *ast.WhileNodeS:
+			if isTrue(st.Condition) && !breaks(st.Body.Statements) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isTrue(exp ast.ExpNodeI) bool {
+	lit, ok := exp.(*ast.LiteralExpNodeS)
+	return ok && lit.Tk.Type == token.TRUE
+}
+
+func breaks(stmts []ast.StmtNodeI) bool {
+	// Can the statements break out of the loop they are in? Breaks
+	// of nested loops and functions stay inside of those.
+	for _, stmt := range stmts {
+		switch st := stmt.(type) {
+		case *ast.BreakNodeS:
+			return true
+		case *ast.BlockNodeS:
+			if breaks(st.Statements) {
+				return true
+			}
+		case *ast.IfNodeS:
+			if breaks([]ast.StmtNodeI{st.ThenStmt}) || (st.ElseStmt != nil && breaks([]ast.StmtNodeI{st.ElseStmt})) {
+				return true
+			}
+		case *ast.XifNodeS:
+			for _, body := range st.Bodies() {
+				if block, ok := body.(*ast.BlockNodeS) ; ok && breaks(block.Statements) {
+					return true
+				}
+			}
+		case *ast.TryNodeS:
+			for _, block := range []*ast.BlockNodeS{st.Body, st.Catch, st.Finally} {
+				if block != nil && breaks(block.Statements) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func tryAlwaysReturns(n *ast.TryNodeS) bool {
+	// Either the body returns and so does catch when there is
+	// one, or finally returns whatever happened before.
+	if n.Finally != nil && alwaysReturns(n.Finally.Statements) {
+		return true
+	}
+	return alwaysReturns(n.Body.Statements) && (n.Catch == nil || alwaysReturns(n.Catch.Statements))
+}
+
+// --------------------------------------------------------
+// types
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) checkType(t mstype.MSType, tk token.Token) {
+	// Reports named types which are not defined
+	switch tt := t.(type) {
+	case *mstype.MSSimpleTypeS:		return
+	case *mstype.MSArrayType:		r.checkType(tt.Type, tk)
+	case *mstype.MSMapTypeS:
+
+		r.checkType(tt.Key, tk)
+		r.checkType(tt.Value, tk)
+
+		if key := r.underlying(tt.Key) ; !isUnknown(key) && !mstype.IsKeyType(key) {
+			msg := fmt.Sprintf("Cannot use type '%s' as a map key, keys must be of type int, float, string or bool", tt.Key)
+			r.error(tk, msg)
+		}
+
+	case *mstype.MSCompositeTypeS:	r.checkTypes(tt.Types, tk)
+	case *mstype.MSOperationTypeS:	r.checkTypes(tt.Left, tk) ; r.checkType(tt.Right, tk)
+	case *mstype.MSStructTypeS:
+		for _, ft := range tt.Fields {
+			r.checkType(ft, tk)
+		}
+	case *mstype.MSEnumTypeS:
+		for _, v := range tt.Variants {
+			r.checkTypes(v.Types, tk)
+		}
+	case *mstype.MSInterfaceTypeS:
+		for _, m := range tt.Methods {
+			r.checkType(m, tk)
+		}
+	case *mstype.MSNamedTypeS:
+
+		def, ok := r.lookupNamedType(tt)
+
+		if !ok {
+			r.error(tk, fmt.Sprintf("Could not resolve type '%s'", tt))
+			return
+		}
+
+		r.checkTypes(tt.Args, tk)
+
+		// Generic structs need exactly one argument per type parameter
+		var params int
+		if st, ok := def.(*mstype.MSStructTypeS) ; ok {
+			params = len(st.Params)
+		}
+
+		if params != len(tt.Args) {
+			msg := fmt.Sprintf("Type '%s' expects %d type argument(s), got %d", tt.Name, params, len(tt.Args))
+			r.error(tk, msg)
+		}
+	}
+}
+
+func (r *MSTypeResolver) checkTypes(ts []mstype.MSType, tk token.Token) {
+	for _, t := range ts {
+		r.checkType(t, tk)
+	}
+}
+
+// Limits how many named types we follow, protects
+// against definitions like 'type a b; type b a;'
+const maxTypeDepth int = 64
+
+func (r *MSTypeResolver) underlying(t mstype.MSType) mstype.MSType {
+	for i := 0 ; i < maxTypeDepth ; i++ {
+
+		// The type parameters of a generic function can be any type
+		// inside its body, the values are checked when evaluating.
+		if _, ok := t.(*mstype.MSTypeVarS) ; ok {
+			return unknown
+		}
+
+		nt, ok := t.(*mstype.MSNamedTypeS)
+
+		if !ok {
+			return t
+		}
+
+		def, found := r.lookupNamedType(nt)
+
+		if !found {
+			return unknown
+		}
+
+		if st, ok := def.(*mstype.MSStructTypeS) ; ok && st.Generic() {
+			return r.instantiate(st, nt.Args)
+		}
+
+		t = def
+	}
+	return unknown
+}
+
+func (r *MSTypeResolver) instantiate(st *mstype.MSStructTypeS, args []mstype.MSType) mstype.MSType {
+	// Wrong number of type arguments is reported by checkType
+	inst, err := st.Instantiate(args)
+
+	if err != nil {
+		return unknown
+	}
+
+	return inst
+}
+
+func (r *MSTypeResolver) compatible(expected, got mstype.MSType) bool {
+	return r.compatibleDepth(expected, got, 0)
+}
+
+func (r *MSTypeResolver) compatibleDepth(expected, got mstype.MSType, depth int) bool {
+
+	if depth > maxTypeDepth {
+		return false
+	}
+
+	e, g := r.underlying(expected), r.underlying(got)
+
+	if isUnknown(e) || isUnknown(g) {
+		return true
+	}
+
+	// Builtins are passed around using the type they report at runtime
+	if b, ok := e.(*msBuiltinTypeS) ; ok {
+		e = b.rtype
+	}
+	if b, ok := g.(*msBuiltinTypeS) ; ok {
+		g = b.rtype
+	}
+
+	switch et := e.(type) {
+	case *mstype.MSArrayType:
+		gt, ok := g.(*mstype.MSArrayType)
+		return ok && r.compatibleDepth(et.Type, gt.Type, depth + 1)
+	case *mstype.MSMapTypeS:
+		gt, ok := g.(*mstype.MSMapTypeS)
+		return ok && r.compatibleDepth(et.Key, gt.Key, depth + 1) && r.compatibleDepth(et.Value, gt.Value, depth + 1)
+	case *mstype.MSCompositeTypeS:
+		gt, ok := g.(*mstype.MSCompositeTypeS)
+		return ok && r.compatibleLists(et.Types, gt.Types, depth + 1)
+	case *mstype.MSOperationTypeS:
+		gt, ok := g.(*mstype.MSOperationTypeS)
+		return ok && r.compatibleLists(et.Left, gt.Left, depth + 1) && r.compatibleDepth(et.Right, gt.Right, depth + 1)
+	case *mstype.MSStructTypeS:
+		// structs and enums are compared by name, they can be recursive
+		gt, ok := g.(*mstype.MSStructTypeS)
+		return ok && gt.Name == et.Name && r.compatibleLists(et.Args, gt.Args, depth + 1)
+	case *mstype.MSEnumTypeS:
+		gt, ok := g.(*mstype.MSEnumTypeS)
+		return ok && gt.Name == et.Name
+	case *mstype.MSInterfaceTypeS:
+		// Structs are only converted when they are assigned
+		// as a whole, '[]circle' is not a '[]shape'.
+		if depth == 0 {
+			return r.implements(g, et)
+		}
+		gt, ok := g.(*mstype.MSInterfaceTypeS)
+		return ok && gt.Name == et.Name
+	}
+
+	return e.Eq(g)
+}
+
+func (r *MSTypeResolver) compatibleLists(expected, got []mstype.MSType, depth int) bool {
+	if len(expected) != len(got) {
+		return false
+	}
+	for i := range expected {
+		if !r.compatibleDepth(expected[i], got[i], depth) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MSTypeResolver) assignable(target, val mstype.MSType) bool {
+	// 'nothing' can be assigned to anything nullable
+	if isNothing(r.underlying(val)) && r.underlying(target).Nullable() {
+		return true
+	}
+	return r.compatible(target, val)
+}
+
+func (r *MSTypeResolver) elemType(t mstype.MSType) (mstype.MSType, bool) {
+	// Type of the elements produced when iterating a value of type t
+
+	switch it := r.underlying(t).(type) {
+	case *msUnknownTypeS:			return unknown, true
+	case *mstype.MSArrayType:		return it.Type, true
+	case *mstype.MSMapTypeS:		return &mstype.MSCompositeTypeS{Types: []mstype.MSType{it.Key, it.Value}}, true
+	case *mstype.MSCompositeTypeS:
+
+		if len(it.Types) == 0 {
+			return unknown, true
+		}
+
+		// Tuples with mixed types give elements of different types
+		for _, et := range it.Types[1:] {
+			if !r.compatible(it.Types[0], et) {
+				return unknown, true
+			}
+		}
+		return it.Types[0], true
+	}
+
+	return nil, false
+}
+
+func isUnknown(t mstype.MSType) bool {
+	_, ok := t.(*msUnknownTypeS)
+	return ok
+}
+
+func isNothing(t mstype.MSType) bool {
+	return mstype.MS_NOTHING.Eq(t)
+}
+
+// --------------------------------------------------------
+// unknown type
+// --------------------------------------------------------
+
+// Type of expressions which can only be known at runtime (unpacking
+// arrays) or which already produced an error. It is compatible with
+// all other types so one mistake does not produce a cascade of errors.
+type msUnknownTypeS struct {}
+
+var unknown mstype.MSType = &msUnknownTypeS{}
+
+func (t *msUnknownTypeS) Eq(o mstype.MSType) bool {
+	return true
+}
+
+func (t *msUnknownTypeS) String() string {
+	return "unknown"
+}
+
+func (t *msUnknownTypeS) Nullable() bool {
+	return true
+}
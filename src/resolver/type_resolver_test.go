@@ -0,0 +1,168 @@
+package resolver
+
+import (
+	"mikescript/src/parser"
+	"mikescript/src/scanner"
+	"slices"
+	"testing"
+)
+
+// Messages of the type errors of src
+func typeErrors(t *testing.T, src string) []string {
+
+	s := scanner.MSScanner{}
+	tokens := s.Scan(src)
+
+	p := parser.MSParser{}
+	p.SetSrc(src)
+	p.SetTokens(tokens)
+
+	program, _ := p.Parse(tokens)
+
+	if len(s.Errors) > 0 || len(p.Errors) > 0 {
+		t.Fatalf("Could not parse '%s': %v %v", src, s.Errors, p.Errors)
+	}
+
+	r := NewMSTypeResolver(program)
+
+	msgs := []string{}
+	for _, err := range r.Resolve() {
+		msgs = append(msgs, err.Message())
+	}
+
+	return msgs
+}
+
+func TestTypeResolver(t *testing.T) {
+
+	tests := []struct {
+		input string
+		errors []string
+	}{
+		// returns
+		{
+			input: "function (int x) >> f -> int { return x; }",
+			errors: []string{},
+		},
+		{
+			input: "function (int x) >> f -> int { return \"x\"; }",
+			errors: []string{"Tried returning value of type 'string', expected type 'int'"},
+		},
+		{
+			input: "function (int x) >> f -> int { if x > 1 { return 1; } }",
+			errors: []string{"Function 'f' must return a value of type 'int'"},
+		},
+		{
+			input: "function (int x) >> f -> int { if x > 1 { return 1; } else { return 2; } }",
+			errors: []string{},
+		},
+		{
+			input: "function (int x) >> f -> int { while true { return 1; } }",
+			errors: []string{},
+		},
+		{
+			input: "function (int x) >> f -> int { while true { if x > 1 { break; } } }",
+			errors: []string{"Function 'f' must return a value of type 'int'"},
+		},
+		{
+			input: "function (int x) >> f -> int { while true { for [0 .. x] .-> i { break; } } }",
+			errors: []string{},
+		},
+		{
+			input: "function (int x) >> f -> int { while x > 1 { return 1; } }",
+			errors: []string{"Function 'f' must return a value of type 'int'"},
+		},
+		// binding
+		{
+			input: "function (int x) >> f -> int { return x; } \"one\" >>= f;",
+			errors: []string{"Cannot bind value of type 'string' to parameter 0 of type 'int'"},
+		},
+		{
+			input: "function (int x) >> f -> int { return x; } 1, 2 >>= f;",
+			errors: []string{"Exceeded arity of '(int -> int)' expected maximum 1 arguments but received 2"},
+		},
+		// assignment
+		{
+			input: "1 => x; 2 -> x;",
+			errors: []string{},
+		},
+		{
+			input: "1 => x; \"two\" -> x;",
+			errors: []string{"Variable 'x' is of type 'int' and cannot be assigned a value of type 'string'"},
+		},
+		{
+			input: "1 -> y;",
+			errors: []string{"Variable 'y' is not defined"},
+		},
+		// conditions
+		{
+			input: "if 1 { 1 >>= print; }",
+			errors: []string{"Condition must be of type 'bool', got 'int'"},
+		},
+		{
+			input: "while \"yes\" { }",
+			errors: []string{"Condition must be of type 'bool', got 'string'"},
+		},
+		{
+			input: "1 => x; xif | x => { 1 >>= print; }",
+			errors: []string{"Condition must be of type 'bool', got 'int'"},
+		},
+		// iteration
+		{
+			input: "for [0 .. 3] .-> i { i + 1 => j; }",
+			errors: []string{},
+		},
+		{
+			input: "for 3 .-> i { }",
+			errors: []string{"Value of type 'int' is not iterable"},
+		},
+		{
+			input: "for [0 .. 3] .-> i { i -> s; \"s\" => s; }",
+			errors: []string{"Variable 's' is not defined"},
+		},
+		// map builtins
+		{
+			input: "map[string]int{} => m; m, \"a\" >>= has => b; b -> b; m, \"a\" >>= delete;",
+			errors: []string{},
+		},
+		{
+			input: "map[string]int{} => m; m, 1 >>= has;",
+			errors: []string{"'has' expected key of type 'string', got 'int'"},
+		},
+		{
+			input: "[]int{} => xs; xs, 1 >>= delete;",
+			errors: []string{"'delete' expected argument of map type, got '[]int'"},
+		},
+		// interface conformance names the method
+		{
+			input: "type interface shape { (-> float) area; (-> string) name; } type struct sq { float s; } function (sq q) >> sq.area -> float { return q.s; } function (sq q) >> sq.name -> string { return \"sq\"; } var sq q; var shape s; q -> s;",
+			errors: []string{},
+		},
+		{
+			input: "type interface shape { (-> float) area; (-> string) name; } type struct sq { float s; } function (sq q) >> sq.area -> float { return q.s; } var sq q; var shape s; q -> s;",
+			errors: []string{"Variable 's' is of type 'shape' and cannot be assigned a value of type 'sq', 'sq' has no method 'name' of type '( -> string)'"},
+		},
+		{
+			input: "type interface shape { (-> float) area; (-> string) name; } type struct sq { float s; } function (sq q) >> sq.area -> float { return q.s; } function (sq q) >> sq.name -> int { return 1; } function (shape s) >> f {} var sq q; q >>= f;",
+			errors: []string{"Cannot bind value of type 'sq' to parameter 0 of type 'shape', method 'name' of 'sq' has type '( -> int)' instead of '( -> string)'"},
+		},
+		{
+			input: "type interface shape { (-> float) area; (-> string) name; } type struct sq { float s; } function (sq q) >> sq.area -> float { return q.s; } function () >> f -> shape { return 1; }",
+			errors: []string{"Tried returning value of type 'int', expected type 'shape', 'int' has no methods"},
+		},
+		// scopes of xif arms
+		{
+			input: "1 => x; xif | x > 3 => \"big\" => s otherwise \"small\" => s; s >>= print;",
+			errors: []string{"Variable 's' is not defined"},
+		},
+	}
+
+	for _, test := range tests {
+
+		received := typeErrors(t, test.input)
+
+		if !slices.Equal(received, test.errors) {
+			t.Errorf("Type errors of '%s'\nexpected: %q\nreceived: %q", test.input, test.errors, received)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package resolver
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/mstype"
+)
+
+// --------------------------------------------------------
+// xif
+// --------------------------------------------------------
+
+func (r *MSTypeResolver) resolveXifStatement(n *ast.XifNodeS) {
+
+	for _, arm := range n.Arms {
+		r.expectCondition(arm.Cond)
+	}
+
+	for _, body := range n.Bodies() {
+		switch b := body.(type) {
+		case *ast.BlockNodeS:	r.resolveBlockNode(b)
+		case ast.ExpNodeI:		r.resolveXifBody(b)
+		}
+	}
+}
+
+func (r *MSTypeResolver) resolveXifExpression(n *ast.XifNodeS) mstype.MSType {
+
+	for _, arm := range n.Arms {
+		r.expectCondition(arm.Cond)
+	}
+
+	// The type of the first body we know the type
+	// of, the other bodies need to agree.
+	var rt mstype.MSType = unknown
+
+	for _, body := range n.Bodies() {
+
+		exp, ok := body.(ast.ExpNodeI)
+
+		if !ok {
+			continue	// reported by the parser
+		}
+
+		t := r.resolveXifBody(exp)
+
+		if isUnknown(rt) {
+			rt = t
+			continue
+		}
+
+		if !r.compatible(rt, t) {
+			msg := fmt.Sprintf("Branch of xif has type '%s', but previous branches have type '%s'", t, rt)
+			r.error(ast.ExpToken(exp), msg)
+		}
+	}
+
+	return rt
+}
+
+// Expression bodies have their own scope, like in the resolver
+func (r *MSTypeResolver) resolveXifBody(exp ast.ExpNodeI) mstype.MSType {
+	r.enterScope()
+	defer r.leaveScope()
+	return r.resolveExpression(exp)
+}
+
+func xifAlwaysReturns(n *ast.XifNodeS) bool {
+	// Without 'otherwise' none of the bodies may run
+
+	if n.Otherwise == nil {
+		return false
+	}
+
+	for _, body := range n.Bodies() {
+
+		block, ok := body.(*ast.BlockNodeS)
+
+		if !ok || !alwaysReturns(block.Statements) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"fmt"
+	"strings"
 	token "mikescript/src/token"
 	utils "mikescript/src/utils"
 )
@@ -13,6 +14,8 @@ const(
 	TAB byte = '\t'
 	NEWLINE byte = '\n'
 	QUOTE byte = '"'
+	BACKTICK byte = '`'
+	BACKSLASH byte = '\\'
 )
 
 type Scanner interface {
@@ -32,6 +35,9 @@ type MSScanner struct {
 	line int 		// Current line number
 	col int 		// Current column number
 
+	// Line comments, they are not part of the tokens
+	Comments []token.Token
+
 	// error information
 	Errors []ScannerError
 }
@@ -50,6 +56,18 @@ func (err ScannerError) String() string {
 	return fmt.Sprintf("[Scanner Error]: %v at line %v col %v", err.msg, err.line, err.col)
 }
 
+func (err ScannerError) Error() string {
+	return err.String()
+}
+
+func (err ScannerError) Message() string {
+	return err.msg
+}
+
+func (err ScannerError) Position() (int, int) {
+	return err.line, err.col
+}
+
 ////////////////////////////////////////////////////////////////
 // 							helpers
 ////////////////////////////////////////////////////////////////
@@ -134,7 +152,7 @@ func (scanner *MSScanner) nextToken() (token.Token, bool) {
 	// handle two character tokens
 	case c == '-' && scanner.advanceIfAtr('>'): tok = token.Token{Type: token.MINUS_GREAT, Lexeme: "<-", Line: scanner.line, Col: scanner.col}
 	case c == '-': 								tok = token.Token{Type: token.MINUS, Lexeme: "-", Line: scanner.line, Col: scanner.col}
-	case c == '/' && scanner.advanceIfAtr('/'):	ok, tok = scanner.skipComment()
+	case c == '/' && scanner.advanceIfAtr('/'):	ok, tok = scanner.scanComment()
 	case c == '/':								tok = token.Token{Type: token.SLASH, Lexeme: "/", Line: scanner.line, Col: scanner.col}
 	case c == '<' && scanner.advanceIfAtr('='):	tok = token.Token{Type: token.LESS_EQ, Lexeme: "<=", Line: scanner.line, Col: scanner.col}
 	case c == '<' && scanner.advanceIfAtr('<'):	tok = token.Token{Type: token.LESS_LESS, Lexeme: "<<", Line: scanner.line, Col: scanner.col}
@@ -159,6 +177,7 @@ func (scanner *MSScanner) nextToken() (token.Token, bool) {
 	case c == '=':								tok = token.Token{Type: token.EQ, Lexeme: "=", Line: scanner.line, Col: scanner.col}
 	// handle string literals
 	case c == '"':								ok, tok = scanner.scanString()
+	case c == '`':								ok, tok = scanner.scanRawString()
 	// handle numbers
 	case c == '.' && scanner.advanceIfAtr('.'):	tok = token.Token{Type: token.DOT_DOT, Lexeme: "..", Line: scanner.line, Col: scanner.col}
 	case c == '.' && scanner.atrIsDigit():		ok, tok = scanner.scanNumber()
@@ -259,16 +278,135 @@ func (scanner *MSScanner) scanString() (bool, token.Token) {
 	// advance r untill we find the matching "
 	// make sure we don't go past the end of the file
 	// also make sure to increment newlines occuring
+	//
+	// Escape sequences are decoded while scanning. An unescaped '{'
+	// starts an interpolated expression, its tokens are added between
+	// the string parts:
+	//
+	// "a {x} b {y} c" -> STRING_HEAD("a ") x STRING_MID(" b ") y STRING_TAIL(" c")
+
+	var str strings.Builder
+	var interpolated bool
+	var valid bool = true
+
 	for scanner.atr() != QUOTE && !scanner.atEnd() {
-		if scanner.atr() == NEWLINE { scanner.newline() }
-		scanner.advance()
+
+		c := scanner.advance()
+
+		switch c {
+		case NEWLINE:
+			scanner.newline()
+			str.WriteByte(c)
+		case BACKSLASH:
+			e, ok := scanner.scanEscape()
+			valid = valid && ok
+			str.WriteByte(e)
+		case '{':
+
+			tt := token.STRING_HEAD
+			if interpolated {
+				tt = token.STRING_MID
+			}
+
+			scanner.addToken(token.Token{Type: tt, Lexeme: str.String(), Line: scanner.line, Col: scanner.col})
+			str.Reset()
+			interpolated = true
+
+			if !scanner.scanInterpolation() {
+				return false, token.Token{}
+			}
+
+		default:
+			str.WriteByte(c)
+		}
 	}
 
 	if scanner.atEnd() {
 		scanner.error("No matching \" found for string", scanner.line, scanner.col)
 		return false, token.Token{}
 	}
-	
+
+	tt := token.STRING
+	if interpolated {
+		tt = token.STRING_TAIL
+	}
+
+	tok := token.Token{Type: tt, Lexeme: str.String(), Line: scanner.line, Col: scanner.col}
+
+	scanner.advance()
+
+	return valid, tok
+}
+
+func (scanner *MSScanner) scanEscape() (byte, bool) {
+
+	// r points to the character after the '\'
+	if scanner.atEnd() {
+		return 0, false
+	}
+
+	c := scanner.advance()
+
+	switch c {
+	case 'n':								return NEWLINE, true
+	case 't':								return TAB, true
+	case 'r':								return '\r', true
+	case '0':								return 0, true
+	case BACKSLASH, QUOTE, '{', '}':		return c, true
+	case NEWLINE:							scanner.newline()
+	}
+
+	scanner.error(fmt.Sprintf("Invalid escape sequence '\\%c'", c), scanner.line, scanner.col)
+
+	return 0, false
+}
+
+func (scanner *MSScanner) scanInterpolation() bool {
+
+	// r points to the character after the '{' opening the
+	// expression. Scan regular tokens untill the matching '}',
+	// braces inside of the expression need to be balanced.
+	depth := 0
+	scanner.l = scanner.r
+
+	for !scanner.atEnd() {
+
+		if scanner.atr() == '}' && depth == 0 {
+			scanner.advance()
+			return true
+		}
+
+		tok, ok := scanner.nextToken()
+
+		if !ok {
+			continue
+		}
+
+		switch tok.Type {
+		case token.LEFT_BRACE:	depth++
+		case token.RIGHT_BRACE:	depth--
+		}
+
+		scanner.addToken(tok)
+	}
+
+	scanner.error("No matching } found for interpolated expression", scanner.line, scanner.col)
+
+	return false
+}
+
+func (scanner *MSScanner) scanRawString() (bool, token.Token) {
+	// Raw strings are copied verbatim, they can span multiple
+	// lines and have no escape sequences or interpolation.
+	for scanner.atr() != BACKTICK && !scanner.atEnd() {
+		if scanner.advance() == NEWLINE { scanner.newline() }
+	}
+
+	if scanner.atEnd() {
+		scanner.error("No matching ` found for raw string", scanner.line, scanner.col)
+		return false, token.Token{}
+	}
+
 	str := scanner.src[scanner.l+1:scanner.r]
 	tok := token.Token{Type: token.STRING, Lexeme: str, Line: scanner.line, Col: scanner.col}
 
@@ -354,7 +492,7 @@ func (scanner *MSScanner) scanNumber() (bool, token.Token) {
 	return true, tok
 }
 
-func (scanner *MSScanner) skipComment() (bool, token.Token) {
+func (scanner *MSScanner) scanComment() (bool, token.Token) {
 
 	// found a comment where l points to the first /
 	// and r points to the second / Now we need to advance
@@ -363,6 +501,12 @@ func (scanner *MSScanner) skipComment() (bool, token.Token) {
 			scanner.advance()
 	}
 
+	// Comments are no tokens, the formatter still needs them
+	raw := scanner.src[scanner.l:scanner.r]
+	str := strings.TrimRight(raw, " \t\r")
+	tok := token.Token{Type: token.COMMENT, Lexeme: str, Line: scanner.line, Col: scanner.col - len(raw) + len(str)}
+	scanner.Comments = append(scanner.Comments, tok)
+
 	return false, token.Token{}
 }
 
@@ -404,6 +548,7 @@ func (scanner *MSScanner) reset() {
 
 	// reset scanner state
 	scanner.tokens = make([]token.Token, 0)
+	scanner.Comments = make([]token.Token, 0)
 	scanner.r = 0
 	scanner.l = 0
 	scanner.line = 1
@@ -161,6 +161,39 @@ func TestScan(t *testing.T) {
 				{Type: token.EOF, Lexeme: ""},
 			},
 		},
+		{
+			input: `"a\tb\n\"c\"\\\{\}";`,
+			tokens: []token.Token{
+				{Type: token.STRING, Lexeme: "a\tb\n\"c\"\\{}"},
+				{Type: token.SEMICOLON, Lexeme: ";"},
+				{Type: token.EOF, Lexeme: ""},
+			},
+		},
+		{
+			input: "`raw \\n {x}\nline`;",
+			tokens: []token.Token{
+				{Type: token.STRING, Lexeme: "raw \\n {x}\nline"},
+				{Type: token.SEMICOLON, Lexeme: ";"},
+				{Type: token.EOF, Lexeme: ""},
+			},
+		},
+		{
+			input: `"x = {x + 1}, {f{"y"}}!";`,
+			tokens: []token.Token{
+				{Type: token.STRING_HEAD, Lexeme: "x = "},
+				{Type: token.IDENTIFIER, Lexeme: "x"},
+				{Type: token.PLUS, Lexeme: "+"},
+				{Type: token.NUMBER_INT, Lexeme: "1"},
+				{Type: token.STRING_MID, Lexeme: ", "},
+				{Type: token.IDENTIFIER, Lexeme: "f"},
+				{Type: token.LEFT_BRACE, Lexeme: "{"},
+				{Type: token.STRING, Lexeme: "y"},
+				{Type: token.RIGHT_BRACE, Lexeme: "}"},
+				{Type: token.STRING_TAIL, Lexeme: "!"},
+				{Type: token.SEMICOLON, Lexeme: ";"},
+				{Type: token.EOF, Lexeme: ""},
+			},
+		},
 	}
 
 	scanner := MSScanner{}
@@ -184,6 +217,31 @@ func TestScan(t *testing.T) {
 
 }
 
+func TestScanComments(t *testing.T) {
+
+	scanner := MSScanner{}
+	tokens := scanner.Scan("// first\n1; // second  \n")
+
+	if len(tokens) != 3 {
+		t.Errorf("Expected comments to be left out of the tokens, got %v", tokens)
+	}
+
+	expected := []token.Token{
+		{Type: token.COMMENT, Lexeme: "// first", Line: 1},
+		{Type: token.COMMENT, Lexeme: "// second", Line: 2},
+	}
+
+	if len(scanner.Comments) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, scanner.Comments)
+	}
+
+	for i, c := range scanner.Comments {
+		if !tokenCompare(c, expected[i]) || c.Line != expected[i].Line {
+			t.Errorf("Expected %v, got %v", expected[i], c)
+		}
+	}
+}
+
 func TestScannerErrors(t *testing.T) {
 
 	var input string
@@ -218,6 +276,33 @@ func TestScannerErrors(t *testing.T) {
 
 	///////////////////////////////////////////////
 
+	input = "\"a\\qb\""
+	expected = []ScannerError{
+		{msg: "Invalid escape sequence '\\q'", line: 1, col: 5},
+	}
+
+	scanner.Scan(input)
+	received = scanner.Errors
+	if !arraysEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+
+	input = "\"a {b\""
+	expected = []ScannerError{
+		{msg: "No matching \" found for string", line: 1, col: 7},
+		{msg: "No matching } found for interpolated expression", line: 1, col: 7},
+	}
+
+	scanner.Scan(input)
+	received = scanner.Errors
+	if !arraysEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	///////////////////////////////////////////////
+
 	input = "?"
 	expected = []ScannerError{
 		{msg: "Unrecognized character", line: 1, col: 2},
@@ -25,12 +25,12 @@ const(
 	MULT							// * 
 	SLASH							// / 
 	SEMICOLON						// ;
-	COLON							// : UNUSED
+	COLON							// : (map entries)
 	PERCENT							// % 
 	EXCLAMATION						// ! 
 	LESS							// < 
 	GREATER							// >
-	BAR								// | (guard in xif) UNUSED
+	BAR								// | (guard in xif)
 	EQ								// = (function call)
 
 	// Double character tokens
@@ -58,16 +58,20 @@ const(
 	// Literals
 	IDENTIFIER						// Identifier (x, y, z, f, etc)
 	STRING							// String literal
+	STRING_HEAD						// Interpolated string up to the first '{'
+	STRING_MID						// Interpolated string between '}' and '{'
+	STRING_TAIL						// Interpolated string after the last '}'
 	NUMBER_INT						// Number literal (no dot)
 	NUMBER_FLOAT					// Number literal (with dot)
+	COMMENT							// Line comment, kept apart from the tokens
 
 	// Keywords
 	FALSE 							// false
 	TRUE 							// true
 	IF								// if
 	ELSE 							// else
-	XIF 							// xif UNUSED
-	OTHERWISE 						// otherwise UNUSED
+	XIF 							// xif
+	OTHERWISE 						// otherwise
 	FOR 							// for
 	WHILE 							// while
 	FUNCTION 						// function
@@ -77,6 +81,12 @@ const(
 	BREAK 							// break
 	VAR								// var
 	TYPE							// type
+	MATCH							// match
+	IMPORT							// import
+	THROW							// throw
+	TRY								// try
+	CATCH							// catch
+	FINALLY							// finally
 
 	// Types
 	INT_TYPE 						// int (64)
@@ -84,7 +94,11 @@ const(
 	STRING_TYPE 					// string
 	BOOLEAN_TYPE 					// boolean
 	NOTHING_TYPE					// nothing
+	ERROR_TYPE						// error
 	STRUCT 							// struct UNUSED
+	ENUM							// enum
+	INTERFACE						// interface
+	MAP								// map
 
 	// End of file
 	EOF								// End of file
@@ -135,8 +149,12 @@ var stmp map[TokenType]string = map[TokenType]string{
 	BAR_BAR: "||",
 	IDENTIFIER: "ID",
 	STRING: "l_str",
+	STRING_HEAD: "l_str_head",
+	STRING_MID: "l_str_mid",
+	STRING_TAIL: "l_str_tail",
 	NUMBER_INT: "l_int",
 	NUMBER_FLOAT: "l_float",
+	COMMENT: "comment",
 	FALSE: "false",
 	TRUE: "true",
 	XIF: "xif",
@@ -158,6 +176,17 @@ var stmp map[TokenType]string = map[TokenType]string{
 	BREAK: "break",
 	VAR: "var",
 	TYPE: "type",
+	MATCH: "match",
+	IMPORT: "import",
+	THROW: "throw",
+	TRY: "try",
+	CATCH: "catch",
+	FINALLY: "finally",
+	ERROR_TYPE: "t_error",
+	STRUCT: "struct",
+	ENUM: "enum",
+	INTERFACE: "interface",
+	MAP: "map",
 }
 
 // Map of keywords
@@ -181,7 +210,17 @@ var Keywords map[string]TokenType = map[string]TokenType{
 	"var": VAR,
 	"type": TYPE,
 	"struct": STRUCT,
+	"enum": ENUM,
+	"interface": INTERFACE,
+	"map": MAP,
+	"match": MATCH,
+	"import": IMPORT,
 	"nothing": NOTHING_TYPE,
+	"error": ERROR_TYPE,
+	"throw": THROW,
+	"try": TRY,
+	"catch": CATCH,
+	"finally": FINALLY,
 }
 
 // implement stringer
@@ -196,4 +235,5 @@ var SimpleTypeKeywords []TokenType = []TokenType{
 	STRING_TYPE,
 	BOOLEAN_TYPE,
 	NOTHING_TYPE,
+	ERROR_TYPE,
 }
\ No newline at end of file
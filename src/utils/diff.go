@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of unchanged lines shown around a change
+const diffContext = 3
+
+type diffLine struct {
+	op byte		// ' ', '-' or '+'
+	text string
+	a, b int	// line numbers in a and b, counting from 0
+}
+
+// Unified diff of the lines of a and b, empty when they are equal
+func Diff(from, to, a, b string) string {
+
+	if a == b {
+		return ""
+	}
+
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from, to)
+
+	for start := 0 ; start < len(lines) ; {
+
+		// Next change, and the context around it
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+
+		if first == len(lines) {
+			break
+		}
+
+		// Changes closer than twice the context share a hunk
+		last := first
+		for i := first ; i < len(lines) && i <= last + 2 * diffContext ; i++ {
+			if lines[i].op != ' ' {
+				last = i
+			}
+		}
+
+		from := max(first - diffContext, 0)
+		to := min(last + diffContext + 1, len(lines))
+
+		writeHunk(&sb, lines[from:to])
+		start = to
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, lines []diffLine) {
+
+	var na, nb int
+	for _, l := range lines {
+		if l.op != '+' { na++ }
+		if l.op != '-' { nb++ }
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", lines[0].a + 1, na, lines[0].b + 1, nb)
+
+	for _, l := range lines {
+		fmt.Fprintf(sb, "%c%s\n", l.op, l.text)
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func diffLines(a, b []string) []diffLine {
+	// Longest common subsequence of the lines, lcs[i][j] is the
+	// length of the one of a[i:] and b[j:].
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1 ; i >= 0 ; i-- {
+		for j := len(b) - 1 ; j >= 0 ; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := []diffLine{}
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	return lines
+}
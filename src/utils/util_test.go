@@ -33,4 +33,20 @@ func TestIsAlpha(t *testing.T) {
 			t.Errorf("Expected %d to not be an alpha", i)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestDiff(t *testing.T) {
+
+	if d := Diff("a", "b", "x\ny\n", "x\ny\n"); d != "" {
+		t.Errorf("Expected no diff for equal strings, got %q", d)
+	}
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n"
+
+	expected := "--- a\n+++ b\n" +
+		"@@ -2,9 +2,10 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n 9\n 10\n+11\n"
+
+	if d := Diff("a", "b", a, b); d != expected {
+		t.Errorf("Expected diff\n%s\ngot\n%s", expected, d)
+	}
+}
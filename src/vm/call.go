@@ -0,0 +1,143 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/interp"
+)
+
+// Binds args to a function of the program or a builtin
+func bind(fn interp.MSVal, args []interp.MSVal) (interp.MSVal, error) {
+	switch f := fn.(type) {
+	case *Closure:
+		return f.bind(args)
+	case interp.MSCallable:
+
+		if f.Arity() < len(args) {
+			msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", f, f.Arity(), len(args))
+			return nil, &RuntimeError{msg}
+		}
+
+		return f.Bind(args)
+
+	default:
+		return nil, &RuntimeError{fmt.Sprintf("Function application is not implemented for type '%s'", fn)}
+	}
+}
+
+// Binds args to fn and calls it. Functions of the program run in a
+// nested loop which returns when the function returns.
+func (vm *VM) call(fn interp.MSVal, args []interp.MSVal) (interp.MSVal, error) {
+	switch f := fn.(type) {
+	case *Closure:
+
+		// Functions declared using 'var' have no name, like in the evaluator
+		if f.proto == nil && len(args) > 0 {
+			return nil, &BindingError{"Cannot bind uninitialized function ''"}
+		}
+
+		if f.proto == nil {
+			return nil, &RuntimeError{"Cannot call uninitialized function ''"}
+		}
+
+		if len(args) > f.Arity() {
+			msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", f, f.Arity(), len(args))
+			return nil, &RuntimeError{msg}
+		}
+
+		if err := vm.enterCall() ; err != nil {
+			return nil, err
+		}
+
+		vm.frames = append(vm.frames, callFrame{proto: f.proto, env: f.frame(args), base: len(vm.stack)})
+
+		return vm.run(len(vm.frames) - 1)
+
+	case interp.MSCallable:
+
+		// Builtins only use the streams of the host evaluator
+		if len(args) > 0 {
+
+			bound, err := bind(fn, args)
+
+			if err != nil {
+				return nil, err
+			}
+
+			f = bound.(interp.MSCallable)
+		}
+
+		return f.Call(vm.host)
+
+	default:
+		return nil, &RuntimeError{fmt.Sprintf("Function call is not implemented for type '%s'", fn)}
+	}
+}
+
+// Like the evaluator, the depth of the calls is limited before the
+// nested loops of 'call' run out of Go stack. The program itself is
+// the first frame.
+func (vm *VM) enterCall() error {
+
+	if vm.MaxDepth > 0 && len(vm.frames) - 1 >= vm.MaxDepth {
+		return &RuntimeError{fmt.Sprintf("Exceeded the maximum call depth of %v", vm.MaxDepth)}
+	}
+
+	return nil
+}
+
+// 'args .>> f' and 'args .>>= f', binds each element of args
+func (vm *VM) iterBind(fn, args interp.MSVal, call bool) (interp.MSVal, error) {
+
+	iterable, ok := args.(interp.MSIterable)
+
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Function application arguments are not iterable, got type '%s'", args.Type())}
+	}
+
+	elems, err := iterable.Elems()
+
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]interp.MSVal, len(elems))
+	for i, elem := range elems {
+
+		if call {
+			vals[i], err = vm.call(fn, []interp.MSVal{elem})
+		} else {
+			vals[i], err = bind(fn, []interp.MSVal{elem})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return iterable.From(vals)
+}
+
+// '.= fns', calls each function of an iterable, or fns itself
+func (vm *VM) iterCall(fns interp.MSVal) (interp.MSVal, error) {
+
+	iterable, ok := fns.(interp.MSIterable)
+
+	if !ok {
+		return vm.call(fns, nil)
+	}
+
+	elems, err := iterable.Elems()
+
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]interp.MSVal, len(elems))
+	for i, fn := range elems {
+		if vals[i], err = vm.call(fn, nil) ; err != nil {
+			return nil, err
+		}
+	}
+
+	return iterable.From(vals)
+}
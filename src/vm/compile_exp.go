@@ -0,0 +1,353 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+	"strconv"
+)
+
+// Opcodes of the binary operators with an instruction of their own,
+// other operators use BINARY.
+var binaryOps = map[token.TokenType]Op{
+	token.PLUS:			OP_ADD,
+	token.MULT:			OP_MULT,
+	token.SLASH:		OP_DIV,
+	token.PERCENT:		OP_MOD,
+	token.GREATER:		OP_GREATER,
+	token.GREATER_EQ:	OP_GREATER_EQ,
+	token.LESS:			OP_LESS,
+	token.LESS_EQ:		OP_LESS_EQ,
+	token.EQ_EQ:		OP_EQ,
+}
+
+func (c *MSCompiler) expression(node ast.ExpNodeI) {
+
+	if c.err != nil {
+		return
+	}
+
+	defer c.locate(ast.ExpToken(node))()
+
+	switch n := node.(type) {
+	case *ast.LiteralExpNodeS:				c.literal(n)
+	case *ast.VariableExpNodeS:				c.load(n)
+	case *ast.GroupExpNodeS:				c.expression(n.Node)
+	case *ast.StarredExpNodeS:				c.expression(n.Node)	// only unpacked in arguments
+	case *ast.AssignmentNodeS:				c.expression(n.Exp) ; c.store(n.Identifier)
+	case *ast.DeclAssignNodeS:				c.expression(n.Exp) ; c.define(c.declare(n.Identifier))
+	case *ast.BinaryExpNodeS:				c.binary(n)
+	case *ast.LogicalExpNodeS:				c.logical(n)
+	case *ast.UnaryExpNodeS:				c.unary(n)
+	case *ast.FuncAppNodeS:					c.funcApp(n)
+	case *ast.FuncCallNodeS:				c.funcCall(n)
+	case *ast.IterableFuncAppNodeS:			c.expression(n.Fun) ; c.expression(n.Args) ; c.emit(OP_ITER_BIND)
+	case *ast.IterableFuncAppAndCallNodeS:	c.expression(n.Fun) ; c.expression(n.Args) ; c.emit(OP_ITER_BIND_CALL)
+	case *ast.IterableFuncCallNodeS:		c.expression(n.Fun) ; c.emit(OP_ITER_CALL)
+	case *ast.TupleNodeS:					c.emit(OP_TUPLE, c.arguments(n.Expressions))
+	case *ast.ArrayIndexNodeS:				c.expression(n.Target) ; c.expression(n.Index) ; c.emit(OP_INDEX)
+	case *ast.ArrayConstructorNodeS:		c.arrayConstructor(n)
+	case *ast.ArrayAssignmentNodeS:			c.arrayAssignment(n)
+	case *ast.FieldAccessNodeS:				c.expression(n.Target) ; c.emit(OP_FIELD, c.nameOperand(n.Field.VarName()))
+	case *ast.FieldAssignmentNode:			c.fieldAssignment(n)
+	case *ast.RangeConstructorNodeS:		c.rangeConstructor(n)
+	case *ast.MapConstructorNodeS:			c.mapConstructor(n)
+	case *ast.InterpolationNodeS:			c.interpolation(n)
+	case *ast.XifNodeS:						c.xif(n, true)
+	case *ast.FuncExpNodeS:					c.funcExp(n)
+	case *ast.MatchNodeS:					c.match(n)
+	case *ast.StructConstructorNodeS:		c.structConstructor(n)
+	default:								c.fail(&CompileError{fmt.Sprintf("Unknown expression type: '%#v'", node)})
+	}
+}
+
+func (c *MSCompiler) literal(n *ast.LiteralExpNodeS) {
+
+	var val interp.MSVal
+
+	switch n.Tk.Type {
+	case token.NUMBER_INT:
+
+		i, err := strconv.Atoi(n.Tk.Lexeme)
+		if err != nil {
+			c.fail(&CompileError{fmt.Sprintf("Could not convert '%v' to 'int'", n.Tk.Lexeme)})
+			return
+		}
+		val = interp.MSInt{Val: i}
+
+	case token.NUMBER_FLOAT:
+
+		f, err := strconv.ParseFloat(n.Tk.Lexeme, 64)
+		if err != nil {
+			c.fail(&CompileError{fmt.Sprintf("Could not convert '%v' to 'float'", n.Tk.Lexeme)})
+			return
+		}
+		val = interp.MSFloat{Val: f}
+
+	case token.STRING:			val = interp.MSString{Val: n.Tk.Lexeme}
+	case token.TRUE:			val = interp.MSBool{Val: true}
+	case token.FALSE:			val = interp.MSBool{Val: false}
+	case token.NOTHING_TYPE:	c.emit(OP_NOTHING) ; return
+	default:
+		c.fail(&CompileError{fmt.Sprintf("Literal type '%#v' is not defined.", n)})
+		return
+	}
+
+	c.emit(OP_CONST, c.constant(val))
+}
+
+func (c *MSCompiler) binary(n *ast.BinaryExpNodeS) {
+
+	c.expression(n.Left)
+	c.expression(n.Right)
+
+	if op, ok := binaryOps[n.Op.Type] ; ok {
+		c.emit(op)
+		return
+	}
+
+	c.fn.proto.Ops = append(c.fn.proto.Ops, n.Op.Type)
+	c.emit(OP_BINARY, len(c.fn.proto.Ops) - 1)
+}
+
+func (c *MSCompiler) logical(n *ast.LogicalExpNodeS) {
+
+	// short circuit, the left value is the result when it decides
+	op := OP_AND
+	if n.Op.Type == token.BAR_BAR {
+		op = OP_OR
+	}
+
+	c.expression(n.Left)
+	end := c.emit(op, 0)
+	c.expression(n.Right)
+	c.patch(end)
+}
+
+func (c *MSCompiler) unary(n *ast.UnaryExpNodeS) {
+
+	c.expression(n.Node)
+
+	switch n.Op.Type {
+	case token.MINUS:		c.emit(OP_NEG)
+	case token.EXCLAMATION:	c.emit(OP_NOT)
+	default:				c.fail(&CompileError{fmt.Sprintf("Unknown unary operator: %v", n.Op.Lexeme)})
+	}
+}
+
+func (c *MSCompiler) funcApp(n *ast.FuncAppNodeS) {
+	// The function is evaluated before the arguments
+	c.expression(n.Fun)
+	c.emit(OP_BIND, c.arguments(n.Args))
+}
+
+func (c *MSCompiler) funcCall(n *ast.FuncCallNodeS) {
+
+	// 'args >>= f' calls without creating the bound function
+	if app, ok := n.Fun.(*ast.FuncAppNodeS) ; ok {
+		c.expression(app.Fun)
+		c.emit(OP_BIND_CALL, c.arguments(app.Args))
+		return
+	}
+
+	c.expression(n.Fun)
+	c.emit(OP_CALL)
+}
+
+// Compiles arguments, starred arguments are unpacked by the
+// instruction using them. Returns the number of values pushed.
+func (c *MSCompiler) arguments(args []ast.ExpNodeI) int {
+
+	for _, arg := range args {
+		if st, ok := arg.(*ast.StarredExpNodeS) ; ok {
+			c.expression(st.Node)
+			c.emit(OP_SPREAD)
+		} else {
+			c.expression(arg)
+		}
+	}
+
+	return len(args)
+}
+
+func (c *MSCompiler) arrayConstructor(n *ast.ArrayConstructorNodeS) {
+
+	t, err := resolveType(n.Type, c.current())
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	if n.N != nil {
+		c.expression(n.N)
+		c.emit(OP_ARRAY_N, c.typeOperand(t))
+		return
+	}
+
+	for _, v := range n.Vals {
+		c.expression(v)
+	}
+
+	c.emit(OP_ARRAY, c.typeOperand(t), len(n.Vals))
+}
+
+func (c *MSCompiler) arrayAssignment(n *ast.ArrayAssignmentNodeS) {
+	c.expression(n.Target)
+	c.expression(n.Index)
+	c.expression(n.Value)
+	c.emit(OP_SET_INDEX)
+}
+
+func (c *MSCompiler) fieldAssignment(n *ast.FieldAssignmentNode) {
+	c.expression(n.Target)
+	c.expression(n.Value)
+	c.emit(OP_SET_FIELD, c.nameOperand(n.Field.VarName()))
+}
+
+func (c *MSCompiler) rangeConstructor(n *ast.RangeConstructorNodeS) {
+
+	if n.From == nil || n.To == nil {
+		c.fail(unsupported("ranges without bounds"))
+		return
+	}
+
+	c.expression(n.From)
+	c.expression(n.To)
+	c.emit(OP_RANGE)
+}
+
+func (c *MSCompiler) mapConstructor(n *ast.MapConstructorNodeS) {
+
+	t, err := resolveType(n.Type, c.current())
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	for i := range n.Keys {
+		c.expression(n.Keys[i])
+		c.expression(n.Vals[i])
+	}
+
+	c.emit(OP_MAP, c.typeOperand(t.(*mstype.MSMapTypeS)), len(n.Keys))
+}
+
+func (c *MSCompiler) interpolation(n *ast.InterpolationNodeS) {
+
+	for _, part := range n.Parts {
+		c.expression(part)
+	}
+
+	c.emit(OP_CONCAT, len(n.Parts))
+}
+
+func (c *MSCompiler) funcExp(n *ast.FuncExpNodeS) {
+
+	// Inferred by the type resolver for the short form
+	if n.Rt == nil {
+		c.fail(&CompileError{"Could not determine the return type of the anonymous function"})
+		return
+	}
+
+	ft, err := resolveOperationType(n.GetFuncType(), c.current())
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	c.function("<anonymous>", n.Params, ft, n.Body)
+}
+
+// Struct constructors are not part of the language: the parser does not
+// produce them and the evaluator does not run them, structs are created
+// by declaring a variable of the struct type.
+func (c *MSCompiler) structConstructor(n *ast.StructConstructorNodeS) {
+	c.fail(&CompileError{fmt.Sprintf("Struct constructor of '%s' is not supported, declare a variable of the struct type instead", n.Name)})
+}
+
+// A match is a function of the matched value, each arm tests the
+// variant of the value and returns its body when it matches.
+func (c *MSCompiler) match(n *ast.MatchNodeS) {
+
+	// Set by the parser or inferred by the type resolver
+	if n.Rt == nil {
+		c.fail(&CompileError{"Could not determine the result type of match"})
+		return
+	}
+
+	rt, err := resolveType(n.Rt, c.current())
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	et, err := c.matchedEnum(n)
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	ft := &mstype.MSOperationTypeS{Left: []mstype.MSType{et}, Right: rt}
+
+	c.enterFunction(&Proto{Name: "match", Params: []string{""}, Type: ft})
+	value := c.newSlot("")
+
+	for _, arm := range n.Arms {
+
+		variant := "_"
+		if !arm.IsWildcard() {
+			variant = arm.Variant.VarName()
+		}
+
+		// Like the resolver, the payload is in a scope of the arm
+		c.enterScope()
+
+		c.emit(OP_GET_LOCAL, value)
+		next := c.emit(OP_VARIANT, c.nameOperand(variant), 0)
+
+		// The last payload value is on top
+		for i := len(arm.Bindings) - 1 ; i >= 0 ; i-- {
+			if b := arm.Bindings[i] ; b.VarName() != "_" {
+				c.define(c.declare(b))
+			}
+			c.emit(OP_POP)
+		}
+
+		c.expression(arm.Body)
+		c.emit(OP_RETURN)
+
+		c.patchOperand(next + 3)
+		c.leaveScope()
+	}
+
+	c.emit(OP_GET_LOCAL, value)
+	c.emit(OP_NO_MATCH)
+
+	c.leaveFunction()
+}
+
+func (c *MSCompiler) matchedEnum(n *ast.MatchNodeS) (*mstype.MSEnumTypeS, error) {
+	// The enum is found through the variants used in the patterns
+
+	for _, arm := range n.Arms {
+
+		if arm.IsWildcard() {
+			continue
+		}
+
+		if et, ok := c.current().lookupVariant(arm.Variant.VarName()) ; ok {
+			return et, nil
+		}
+
+		return nil, &CompileError{fmt.Sprintf("'%s' is not a variant of an enum", arm.Variant.VarName())}
+	}
+
+	return nil, &CompileError{"Match expression needs at least one variant"}
+}
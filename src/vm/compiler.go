@@ -0,0 +1,784 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/ast"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"mikescript/src/resolver"
+	"mikescript/src/token"
+)
+
+/*
+Compiles a resolved program to bytecode. Variables the resolver
+found in a local scope get a slot in the frame of the function
+declaring them, the depth given by the resolver finds the scope and
+with it the slot. All other variables are globals, numbered by name.
+
+Blocks and loop iterations do not get their own frame: every local
+of a function has its own slot, so entering a block costs nothing.
+Locals used by the functions declared in a function are stored in
+cells instead, every declaration starts a new cell so a function
+created in a loop keeps the variables of its iteration.
+*/
+
+type funcState struct {
+	proto *Proto
+	parent *funcState
+	loops []*loopState
+	captured map[int]bool	// slots used by the functions declared in this function
+}
+
+type loopState struct {
+	start int				// target of 'continue'
+	breaks []int			// jumps to patch to the end of the loop
+}
+
+type MSCompiler struct {
+	vlocals map[*ast.VariableExpNodeS]int
+	fn *funcState				// function being compiled
+	scopes []*scope				// local scopes, like the resolver's scope stack
+	global *scope				// types declared at the top level
+	globals map[string]int		// globals of the program or module being compiled
+	program *Program
+	modules *resolver.MSModuleLoader	// loads the modules used in 'import'
+	imported map[string]int		// compiled modules by path
+	tk token.Token				// innermost node with a position being compiled
+	err error					// first error, compilation stops at the first error
+}
+
+func NewMSCompiler(vlocals map[*ast.VariableExpNodeS]int) *MSCompiler {
+	return &MSCompiler{vlocals: vlocals}
+}
+
+// Shares the modules loaded by the type resolver, like the evaluator
+func (c *MSCompiler) SetModules(l *resolver.MSModuleLoader) {
+	c.modules = l
+}
+
+func (c *MSCompiler) Compile(node *ast.Program) (*Program, error) {
+
+	main := &Proto{Name: "main", Type: &mstype.MSOperationTypeS{Right: mstype.MS_NOTHING}}
+
+	c.fn = &funcState{proto: main}
+	c.scopes = nil
+	c.global = newScope(c.fn, nil)
+	c.globals = make(map[string]int)
+	c.program = &Program{Main: main, globals: c.globals, structScopes: make(map[*mstype.MSStructTypeS]*scope)}
+	c.imported = make(map[string]int)
+	c.err = nil
+
+	for _, name := range builtinNames {
+		c.globalIndex(name)
+	}
+
+	// The program evaluates to its last expression statement
+	for i, stmt := range node.Statements {
+
+		if ex, ok := stmt.(*ast.ExStmtNodeS) ; ok && i == len(node.Statements) - 1 {
+			c.expression(ex.Ex)
+			c.emit(OP_RETURN)
+			break
+		}
+
+		c.statement(stmt)
+	}
+
+	c.emit(OP_NOTHING)
+	c.emit(OP_RETURN)
+	c.boxCaptured()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return c.program, nil
+}
+
+// --------------------------------------------------------
+// statements
+// --------------------------------------------------------
+
+func (c *MSCompiler) statement(node ast.StmtNodeI) {
+
+	if c.err != nil {
+		return
+	}
+
+	defer c.locate(ast.StmtToken(node))()
+
+	switch n := node.(type) {
+	case *ast.Program:					c.statements(n.Statements)
+	case *ast.BlockNodeS:				c.block(n)
+	case *ast.VarDeclNodeS:				c.varDecl(n)
+	case *ast.ExStmtNodeS:				c.expression(n.Ex) ; c.emit(OP_POP)
+	case *ast.IfNodeS:					c.ifStatement(n)
+	case *ast.WhileNodeS:				c.whileStatement(n)
+	case *ast.ForNodeS:					c.forStatement(n)
+	case *ast.ContinueNodeS:			c.continueStatement(n)
+	case *ast.BreakNodeS:				c.breakStatement(n)
+	case *ast.ReturnNodeS:				c.returnStatement(n)
+	case *ast.FuncDeclNodeS:			c.funcDecl(n)
+	case *ast.TypeDefStatementS:		c.typeDecl(n)
+	case *ast.StructDeclarationNodeS:	c.structDecl(n)
+	case *ast.XifNodeS:					c.xif(n, false)
+	case *ast.EnumDeclarationNodeS:		c.enumDecl(n)
+	case *ast.InterfaceDeclarationNodeS:	c.fail(unsupported("interfaces"))
+	case *ast.ImportNodeS:				c.importStatement(n)
+	case *ast.ThrowNodeS:				c.fail(unsupported("exceptions"))
+	case *ast.TryNodeS:					c.fail(unsupported("exceptions"))
+	default:							c.fail(&CompileError{fmt.Sprintf("Unknown statement type: %v", node)})
+	}
+}
+
+func (c *MSCompiler) statements(stmts []ast.StmtNodeI) {
+	for _, stmt := range stmts {
+		c.statement(stmt)
+	}
+}
+
+func (c *MSCompiler) block(n *ast.BlockNodeS) {
+	c.enterScope()
+	c.statements(n.Statements)
+	c.leaveScope()
+}
+
+func (c *MSCompiler) varDecl(n *ast.VarDeclNodeS) {
+
+	t, err := resolveType(n.Vartype, c.current())
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	c.emit(OP_ZERO, c.typeOperand(t))
+	c.define(c.declare(n.Identifier))
+	c.emit(OP_POP)
+}
+
+func (c *MSCompiler) ifStatement(n *ast.IfNodeS) {
+
+	c.expression(n.Condition)
+	skipThen := c.emit(OP_JUMP_IF_FALSE, 0)
+
+	c.statement(n.ThenStmt)
+
+	if n.ElseStmt == nil {
+		c.patch(skipThen)
+		return
+	}
+
+	skipElse := c.emit(OP_JUMP, 0)
+	c.patch(skipThen)
+	c.statement(n.ElseStmt)
+	c.patch(skipElse)
+}
+
+func (c *MSCompiler) whileStatement(n *ast.WhileNodeS) {
+
+	start := c.here()
+
+	c.expression(n.Condition)
+	exit := c.emit(OP_JUMP_IF_FALSE, 0)
+
+	c.enterLoop(start)
+	c.statement(n.Body)
+	c.emit(OP_JUMP, start)
+
+	c.patch(exit)
+	c.leaveLoop()
+}
+
+func (c *MSCompiler) forStatement(n *ast.ForNodeS) {
+
+	// The iterator lives in a hidden slot of the function
+	c.expression(n.Iterable)
+	it := c.newSlot("")
+	c.emit(OP_ITER, it)
+
+	start := c.here()
+	next := c.emit(OP_FOR_NEXT, it, 0)
+
+	// Like the resolver, the loop variable and the body share a scope
+	c.enterLoop(start)
+	c.enterScope()
+	c.define(c.declare(n.LoopVar))
+	c.emit(OP_POP)
+	c.statements(n.Body.Statements)
+	c.leaveScope()
+	c.emit(OP_JUMP, start)
+
+	c.patchOperand(next + 3)
+	c.leaveLoop()
+}
+
+func (c *MSCompiler) continueStatement(n *ast.ContinueNodeS) {
+
+	if len(c.fn.loops) == 0 {
+		c.fail(&CompileError{fmt.Sprintf("'continue' outside of a loop at line %d", n.Tk.Line)})
+		return
+	}
+
+	c.emit(OP_JUMP, c.fn.loops[len(c.fn.loops)-1].start)
+}
+
+func (c *MSCompiler) breakStatement(n *ast.BreakNodeS) {
+
+	if len(c.fn.loops) == 0 {
+		c.fail(&CompileError{fmt.Sprintf("'break' outside of a loop at line %d", n.Tk.Line)})
+		return
+	}
+
+	loop := c.fn.loops[len(c.fn.loops)-1]
+	loop.breaks = append(loop.breaks, c.emit(OP_JUMP, 0))
+}
+
+func (c *MSCompiler) returnStatement(n *ast.ReturnNodeS) {
+
+	if n.HasReturnValue() {
+		c.expression(n.Node)
+	} else {
+		c.emit(OP_NOTHING)
+	}
+
+	c.emit(OP_RETURN)
+}
+
+func (c *MSCompiler) funcDecl(n *ast.FuncDeclNodeS) {
+
+	if len(n.TypeParams) > 0 {
+		c.fail(unsupported("generic functions"))
+		return
+	}
+
+	if n.IsMethod() {
+		c.fail(unsupported("methods"))
+		return
+	}
+
+	ft, err := resolveOperationType(n.GetFuncType(), c.current())
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	// Declared before the body, the function can call itself
+	target := c.declare(n.Fname)
+	if !target.global {
+		c.emit(OP_CLEAR_LOCAL, target.index)
+	}
+
+	c.function(n.Fname.VarName(), n.Params, ft, n.Body)
+	c.define(target)
+	c.emit(OP_POP)
+}
+
+// Compiles the body to a new proto, leaves the closure on the stack
+func (c *MSCompiler) function(name string, params []ast.FuncParamS, ft *mstype.MSOperationTypeS, body *ast.BlockNodeS) {
+
+	proto := &Proto{Name: name, Type: ft}
+	for _, p := range params {
+		proto.Params = append(proto.Params, p.VarName())
+	}
+
+	c.enterFunction(proto)
+
+	// Parameters are the first slots, in the same scope as the body
+	c.enterScope()
+	for _, p := range params {
+		c.current().vars[p.VarName()] = c.newSlot(p.VarName())
+	}
+	c.statements(body.Statements)
+	c.emit(OP_NOTHING)
+	c.emit(OP_RETURN)
+	c.leaveScope()
+
+	c.leaveFunction()
+}
+
+func (c *MSCompiler) enterFunction(proto *Proto) {
+	c.fn = &funcState{proto: proto, parent: c.fn}
+}
+
+// Back in the enclosing function, the closure is left on the stack
+func (c *MSCompiler) leaveFunction() {
+
+	c.boxCaptured()
+
+	proto := c.fn.proto
+	c.fn = c.fn.parent
+
+	c.fn.proto.Protos = append(c.fn.proto.Protos, proto)
+	c.emit(OP_CLOSURE, len(c.fn.proto.Protos) - 1)
+}
+
+func (c *MSCompiler) typeDecl(n *ast.TypeDefStatementS) {
+
+	t, err := resolveType(n.Type, c.current())
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	c.current().types[n.Tname.VarName()] = t
+}
+
+func (c *MSCompiler) structDecl(n *ast.StructDeclarationNodeS) {
+
+	if len(n.TypeParams) > 0 {
+		c.fail(unsupported("generic structs"))
+		return
+	}
+
+	st := n.GetStructType()
+
+	c.current().types[n.Name.VarName()] = st
+	c.program.structScopes[st] = c.current()
+}
+
+func (c *MSCompiler) enumDecl(n *ast.EnumDeclarationNodeS) {
+
+	et := n.GetEnumType()
+
+	// Added first, payloads can refer to the enum itself
+	c.current().types[n.Name.VarName()] = et
+
+	// Plain variants are values, the others constructors
+	for i, v := range et.Variants {
+
+		types, err := resolveTypes(v.Types, c.current())
+
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		var val interp.MSVal = interp.MSEnum{EType: et, Variant: v.Name}
+
+		if len(types) > 0 {
+			val = interp.MSVariantConstructor{EType: et, Variant: v.Name, Types: types}
+		}
+
+		c.emit(OP_CONST, c.constant(val))
+		c.define(c.declare(n.Variants[i].Name))
+		c.emit(OP_POP)
+	}
+}
+
+func (c *MSCompiler) importStatement(n *ast.ImportNodeS) {
+
+	if c.modules == nil {
+		c.modules = resolver.NewMSModuleLoader(".")
+	}
+
+	// Set when the type resolver loaded the module
+	path := n.File
+	if path == "" {
+		path = n.Path.Lexeme
+	}
+
+	m, err := c.modules.Load(path)
+
+	if err != nil {
+		c.fail(&CompileError{err.Error()})
+		return
+	}
+
+	// Every module is compiled once
+	index, ok := c.imported[m.Path]
+	if !ok {
+		index = c.module(m)
+	}
+
+	c.emit(OP_IMPORT, index)
+	c.define(c.declare(n.Alias))
+	c.emit(OP_POP)
+
+	// Types named through the alias are found in the module
+	c.current().namespaces[n.Alias.VarName()] = c.program.Modules[index].scope
+}
+
+// Compiles the top-level code of a module to its own proto. Like in
+// the evaluator the module starts with its own copy of the builtins.
+func (c *MSCompiler) module(m *resolver.MSModule) int {
+
+	main := &Proto{Name: m.Name(), Type: &mstype.MSOperationTypeS{Right: mstype.MS_NOTHING}}
+	module := &Module{Name: m.Name(), Main: main, Globals: make(map[string]int)}
+
+	c.program.Modules = append(c.program.Modules, module)
+	index := len(c.program.Modules) - 1
+	c.imported[m.Path] = index
+
+	// Restore the program being compiled when we are done
+	fn, scopes, global, globals := c.fn, c.scopes, c.global, c.globals
+	defer func() {
+		c.fn, c.scopes, c.global, c.globals = fn, scopes, global, globals
+	}()
+
+	c.fn = &funcState{proto: main}
+	c.scopes = nil
+	c.global = newScope(c.fn, nil)
+	c.globals = module.Globals
+	module.scope = c.global
+
+	for k, v := range m.VLocals {
+		c.vlocals[k] = v
+	}
+
+	for i, name := range builtinNames {
+		c.emit(OP_GET_GLOBAL, i)
+		c.emit(OP_DEF_GLOBAL, c.globalIndex(name))
+		c.emit(OP_POP)
+	}
+
+	c.statements(m.Ast.Statements)
+	c.emit(OP_NOTHING)
+	c.emit(OP_RETURN)
+	c.boxCaptured()
+
+	return index
+}
+
+func (c *MSCompiler) xif(n *ast.XifNodeS, value bool) {
+
+	ends := []int{}
+
+	for _, arm := range n.Arms {
+		c.expression(arm.Cond)
+		next := c.emit(OP_JUMP_IF_FALSE, 0)
+		c.xifBody(arm.Body, value)
+		ends = append(ends, c.emit(OP_JUMP, 0))
+		c.patch(next)
+	}
+
+	switch {
+	case n.Otherwise != nil:	c.xifBody(n.Otherwise, value)
+	case value:					c.emit(OP_NOTHING)
+	}
+
+	for _, end := range ends {
+		c.patch(end)
+	}
+}
+
+func (c *MSCompiler) xifBody(body ast.ASTNodeI, value bool) {
+	switch b := body.(type) {
+	case *ast.BlockNodeS:
+
+		c.block(b)
+		if value {
+			c.emit(OP_NOTHING)
+		}
+
+	case ast.ExpNodeI:
+
+		// Its own scope, like the resolver gives it
+		c.enterScope()
+		c.expression(b)
+		c.leaveScope()
+
+		if !value {
+			c.emit(OP_POP)
+		}
+
+	}
+}
+
+// --------------------------------------------------------
+// scopes and variables
+// --------------------------------------------------------
+
+// Where a declared variable is stored
+type target struct {
+	global bool
+	index int			// global or slot
+}
+
+func (c *MSCompiler) current() *scope {
+	if len(c.scopes) == 0 {
+		return c.global
+	}
+	return c.scopes[len(c.scopes)-1]
+}
+
+func (c *MSCompiler) enterScope() {
+
+	s := newScope(c.fn, c.current())
+
+	// The code of the scope is recorded for 'env'
+	parent := -1
+	if s.parent.fn == c.fn {
+		parent = s.parent.index
+	}
+
+	s.index = len(c.fn.proto.scopes)
+	c.fn.proto.scopes = append(c.fn.proto.scopes, scopeRange{start: c.here(), parent: parent, vars: s.vars})
+
+	c.scopes = append(c.scopes, s)
+}
+
+func (c *MSCompiler) leaveScope() {
+	c.fn.proto.scopes[c.current().index].end = c.here()
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *MSCompiler) enterLoop(start int) {
+	c.fn.loops = append(c.fn.loops, &loopState{start: start})
+}
+
+func (c *MSCompiler) leaveLoop() {
+
+	loop := c.fn.loops[len(c.fn.loops)-1]
+	for _, b := range loop.breaks {
+		c.patch(b)
+	}
+
+	c.fn.loops = c.fn.loops[:len(c.fn.loops)-1]
+}
+
+func (c *MSCompiler) newSlot(name string) int {
+	c.fn.proto.Slots = append(c.fn.proto.Slots, name)
+	return len(c.fn.proto.Slots) - 1
+}
+
+func (c *MSCompiler) globalIndex(name string) int {
+
+	if g, ok := c.globals[name] ; ok {
+		return g
+	}
+
+	// Globals of modules are listed under the module name
+	listed := name
+	if top := c.global.fn.proto ; top != c.program.Main {
+		listed = top.Name + "." + name
+	}
+
+	c.program.Globals = append(c.program.Globals, listed)
+	c.globals[name] = len(c.program.Globals) - 1
+
+	return c.globals[name]
+}
+
+func (c *MSCompiler) declare(v *ast.VariableExpNodeS) target {
+
+	name := v.VarName()
+
+	if len(c.scopes) == 0 {
+		return target{global: true, index: c.globalIndex(name)}
+	}
+
+	// Declaring a name again in the same scope reuses its slot
+	s := c.current()
+	slot, ok := s.vars[name]
+
+	if !ok {
+		slot = c.newSlot(name)
+		s.vars[name] = slot
+	}
+
+	return target{index: slot}
+}
+
+func (c *MSCompiler) define(t target) {
+	if t.global {
+		c.emit(OP_DEF_GLOBAL, t.index)
+	} else {
+		c.emit(OP_DEF_LOCAL, t.index)
+	}
+}
+
+func (c *MSCompiler) load(v *ast.VariableExpNodeS) {
+	c.access(v, OP_GET_GLOBAL, OP_GET_LOCAL, OP_GET_UPVALUE)
+}
+
+func (c *MSCompiler) store(v *ast.VariableExpNodeS) {
+	c.access(v, OP_SET_GLOBAL, OP_SET_LOCAL, OP_SET_UPVALUE)
+}
+
+func (c *MSCompiler) access(v *ast.VariableExpNodeS, global, local, upvalue Op) {
+
+	name := v.VarName()
+	depth, ok := c.vlocals[v]
+
+	if !ok {
+
+		c.emit(global, c.globalIndex(name))
+		return
+	}
+
+	if depth >= len(c.scopes) {
+		c.fail(&CompileError{fmt.Sprintf("Variable '%s' at line %d is resolved outside of any scope", name, v.Name.Line)})
+		return
+	}
+
+	s := c.scopes[len(c.scopes) - 1 - depth]
+	slot, ok := s.vars[name]
+
+	if !ok {
+		c.fail(&CompileError{fmt.Sprintf("Variable '%s' at line %d is not declared in its scope", name, v.Name.Line)})
+		return
+	}
+
+	// Variables of enclosing functions are captured by the function
+	if s.fn != c.fn {
+		c.emit(upvalue, c.upvalue(c.fn, s.fn, slot, name))
+	} else {
+		c.emit(local, slot)
+	}
+}
+
+// Upvalue of fn for slot of the enclosing function owner, the
+// functions in between capture it as well.
+func (c *MSCompiler) upvalue(fn *funcState, owner *funcState, slot int, name string) int {
+
+	local := fn.parent == owner
+	index := slot
+
+	if local {
+		if owner.captured == nil {
+			owner.captured = make(map[int]bool)
+		}
+		owner.captured[slot] = true
+	} else {
+		index = c.upvalue(fn.parent, owner, slot, name)
+	}
+
+	for i, u := range fn.proto.Upvalues {
+		if u.local == local && u.index == index {
+			return i
+		}
+	}
+
+	fn.proto.Upvalues = append(fn.proto.Upvalues, upvalue{local: local, index: index, name: name})
+	return len(fn.proto.Upvalues) - 1
+}
+
+// Instructions of the slots of captured variables
+var cellOps = map[Op]Op{
+	OP_GET_LOCAL: OP_GET_CELL, OP_SET_LOCAL: OP_SET_CELL,
+	OP_DEF_LOCAL: OP_DEF_CELL, OP_CLEAR_LOCAL: OP_NEW_CELL,
+}
+
+// Once the function is complete its captured slots are known, the
+// instructions using them are replaced by the ones using cells.
+func (c *MSCompiler) boxCaptured() {
+
+	p := c.fn.proto
+	p.Cells = make([]bool, len(p.Slots))
+
+	if len(c.fn.captured) == 0 {
+		return
+	}
+
+	for s := range c.fn.captured {
+		p.Cells[s] = true
+	}
+
+	for ip := 0 ; ip < len(p.Code) ; ip += 1 + 2*operands[p.Code[ip]] {
+		if cell, ok := cellOps[Op(p.Code[ip])] ; ok && p.Cells[p.operand(ip + 1)] {
+			p.Code[ip] = byte(cell)
+		}
+	}
+}
+
+// --------------------------------------------------------
+// emitting code
+// --------------------------------------------------------
+
+func (c *MSCompiler) here() int {
+	return len(c.fn.proto.Code)
+}
+
+// Appends an instruction, returns where it starts
+func (c *MSCompiler) emit(op Op, args ...int) int {
+
+	at := c.here()
+	p := c.fn.proto
+
+	if n := len(p.positions) ; n == 0 || p.positions[n-1].tk != c.tk {
+		p.positions = append(p.positions, position{start: at, tk: c.tk})
+	}
+
+	p.Code = append(p.Code, byte(op))
+	for _, arg := range args {
+
+		if arg < 0 || arg > maxOperand {
+			c.fail(&CompileError{fmt.Sprintf("Operand %d of '%s' does not fit in 16 bits, the program is too large", arg, op)})
+		}
+
+		p.Code = append(p.Code, byte(arg >> 8), byte(arg))
+	}
+
+	return at
+}
+
+// Code emitted until the returned function is called belongs to the
+// node at tk, like the evaluator locates errors at the innermost node
+// with a position.
+func (c *MSCompiler) locate(tk token.Token) func() {
+
+	if tk.Line == 0 {
+		return func() {}
+	}
+
+	outer := c.tk
+	c.tk = tk
+
+	return func() {
+		c.tk = outer
+	}
+}
+
+// Points the jump at 'at' to the next instruction
+func (c *MSCompiler) patch(at int) {
+	c.patchOperand(at + 1)
+}
+
+func (c *MSCompiler) patchOperand(at int) {
+
+	to := c.here()
+
+	if to > maxOperand {
+		c.fail(&CompileError{"Jump does not fit in 16 bits, the program is too large"})
+	}
+
+	c.fn.proto.Code[at] = byte(to >> 8)
+	c.fn.proto.Code[at + 1] = byte(to)
+}
+
+func (c *MSCompiler) constant(v interp.MSVal) int {
+	c.fn.proto.Consts = append(c.fn.proto.Consts, v)
+	return len(c.fn.proto.Consts) - 1
+}
+
+func (c *MSCompiler) typeOperand(t mstype.MSType) int {
+	c.fn.proto.Types = append(c.fn.proto.Types, t)
+	return len(c.fn.proto.Types) - 1
+}
+
+func (c *MSCompiler) nameOperand(name string) int {
+
+	for i, n := range c.fn.proto.Names {
+		if n == name {
+			return i
+		}
+	}
+
+	c.fn.proto.Names = append(c.fn.proto.Names, name)
+	return len(c.fn.proto.Names) - 1
+}
+
+func (c *MSCompiler) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func unsupported(what string) error {
+	return &CompileError{fmt.Sprintf("%s are not supported on the VM", what)}
+}
+
+const maxOperand = 0xFFFF
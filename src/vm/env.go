@@ -0,0 +1,109 @@
+package vm
+
+import (
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"slices"
+)
+
+///////////////////////////////////////////////////////////////
+// env
+///////////////////////////////////////////////////////////////
+
+// The 'env' builtin of the vm, prints the variables in scope where
+// it is called like the evaluator's 'env'. The scopes are found
+// using the code of the frames, a frame has no scopes of its own.
+type printEnv struct {
+	vm *VM
+}
+
+func (p *printEnv) Type() mstype.MSType {
+	return &mstype.MSOperationTypeS{Left: []mstype.MSType{}, Right: mstype.MS_NOTHING}
+}
+
+func (p *printEnv) String() string {
+	return ">> print_env -> nothing"
+}
+
+func (p *printEnv) Nullable() bool {
+	return false
+}
+
+func (p *printEnv) NullVal() interp.MSVal {
+	return nil
+}
+
+func (p *printEnv) Call(_evaluator *interp.MSEvaluator) (interp.MSVal, error) {
+	interp.WriteScopes(p.vm.Stdout, p.vm.scopes())
+	return interp.MSNothing{}, nil
+}
+
+func (p *printEnv) Bind(args []interp.MSVal) (interp.MSVal, error) {
+
+	if len(args) > 0 {
+		return nil, &RuntimeError{"Cannot bind print_env function."}
+	}
+
+	return p, nil
+}
+
+func (p *printEnv) Arity() int {
+	return 0
+}
+
+// Variables in scope of the calling frame, the globals first
+func (vm *VM) scopes() []map[string]interp.MSVal {
+
+	scopes := []map[string]interp.MSVal{}
+
+	// From the innermost scope out, through the functions
+	// the calling function is declared in
+	cf := vm.frames[len(vm.frames)-1]
+	top := cf.proto
+
+	for f, ip := cf.env, cf.ip ; f != nil ; f, ip = f.parent, f.at {
+		for i := f.proto.scopeAt(ip) ; i >= 0 ; i = f.proto.scopes[i].parent {
+			scopes = append(scopes, f.variables(f.proto.scopes[i].vars))
+		}
+		top = f.proto
+	}
+
+	// Code of a module sees the globals of the module
+	names := vm.program.globals
+	for _, m := range vm.program.Modules {
+		if m.Main == top {
+			names = m.Globals
+		}
+	}
+
+	globals := make(map[string]interp.MSVal)
+	for name, g := range names {
+		if vm.globals[g] != nil {
+			globals[name] = vm.globals[g]
+		}
+	}
+
+	scopes = append(scopes, globals)
+	slices.Reverse(scopes)
+
+	return scopes
+}
+
+// Values of the declared slots of vars
+func (f *frame) variables(vars map[string]int) map[string]interp.MSVal {
+
+	vals := make(map[string]interp.MSVal)
+	for name, s := range vars {
+
+		v := f.slots[s]
+		if c, ok := v.(*cell) ; ok {
+			v = c.v
+		}
+
+		if v != nil {
+			vals[name] = v
+		}
+	}
+
+	return vals
+}
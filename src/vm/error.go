@@ -0,0 +1,27 @@
+package vm
+
+// Program uses something the compiler has no bytecode for
+type CompileError struct {
+	msg string
+}
+
+func (e *CompileError) Error() string {
+	return "Compile error: " + e.msg
+}
+
+// Same prefix as the errors of the tree-walking evaluator
+type RuntimeError struct {
+	msg string
+}
+
+func (e *RuntimeError) Error() string {
+	return "Evaluation error: " + e.msg
+}
+
+type BindingError struct {
+	msg string
+}
+
+func (e *BindingError) Error() string {
+	return "Binding error:" + e.msg
+}
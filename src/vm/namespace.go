@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+)
+
+///////////////////////////////////////////////////////////////
+// Namespace of an imported module
+///////////////////////////////////////////////////////////////
+
+// Runs module m the first time it is imported, like the evaluator
+// every import of the module gets the same namespace.
+func (vm *VM) importModule(m int) (*namespace, error) {
+
+	if ns, ok := vm.namespaces[m] ; ok {
+
+		if ns == nil {
+			msg := fmt.Sprintf("Import cycle, module '%s' is imported while it is being evaluated", vm.program.Modules[m].Name)
+			return nil, &RuntimeError{msg}
+		}
+
+		return ns, nil
+	}
+
+	vm.namespaces[m] = nil
+
+	module := vm.program.Modules[m]
+
+	if _, err := vm.call(&Closure{proto: module.Main, typ: module.Main.Type}, nil) ; err != nil {
+		delete(vm.namespaces, m)
+		return nil, err
+	}
+
+	ns := &namespace{module: module, vm: vm}
+	vm.namespaces[m] = ns
+
+	return ns, nil
+}
+
+// Value of the name a module is imported under, 'geo.area' gets
+// the global 'area' of the module.
+type namespace struct {
+	module *Module
+	vm *VM
+}
+
+func (n *namespace) Type() mstype.MSType {
+	return &namespaceType{module: n.module}
+}
+
+func (n *namespace) String() string {
+	return fmt.Sprintf("module %s", n.module.Name)
+}
+
+func (n *namespace) Nullable() bool {
+	return false
+}
+
+func (n *namespace) NullVal() interp.MSVal {
+	return nil
+}
+
+// --------------------------------------------------------
+// Implements MSFieldable
+// --------------------------------------------------------
+
+func (n *namespace) Get(field string) (interp.MSVal, error) {
+
+	if err := n.ValidField(field) ; err != nil {
+		return nil, err
+	}
+
+	return n.vm.globals[n.module.Globals[field]], nil
+}
+
+func (n *namespace) Set(field string, val interp.MSVal) (interp.MSVal, error) {
+	msg := fmt.Sprintf("Cannot assign to '%s', members of module '%s' are read only", field, n.module.Name)
+	return nil, &RuntimeError{msg}
+}
+
+func (n *namespace) ValidField(field string) error {
+
+	// Globals used but never defined by the module have an index too
+	if g, ok := n.module.Globals[field] ; !ok || n.vm.globals[g] == nil {
+		msg := fmt.Sprintf("Module '%s' has no member '%s'", n.module.Name, field)
+		return &RuntimeError{msg}
+	}
+
+	return nil
+}
+
+func (n *namespace) ValidValue(field string, val interp.MSVal) error {
+	return n.ValidField(field)
+}
+
+// --------------------------------------------------------
+// namespace type
+// --------------------------------------------------------
+
+type namespaceType struct {
+	module *Module
+}
+
+func (t *namespaceType) Eq(o mstype.MSType) bool {
+	other, ok := o.(*namespaceType)
+	return ok && other.module == t.module
+}
+
+func (t *namespaceType) String() string {
+	return fmt.Sprintf("module %s", t.module.Name)
+}
+
+func (t *namespaceType) Nullable() bool {
+	return false
+}
@@ -0,0 +1,104 @@
+package vm
+
+type Op byte
+
+// Instructions are one opcode byte followed by the operands of the
+// opcode. Operands are 16 bit unsigned integers, big endian.
+const (
+	OP_CONST Op = iota		// k				push constant k
+	OP_NOTHING				// 				push nothing
+	OP_POP					//				pop the top value
+	OP_GET_LOCAL			// s				push slot s of the current frame
+	OP_SET_LOCAL			// s				assign top to slot s
+	OP_DEF_LOCAL			// s				declare slot s with top
+	OP_CLEAR_LOCAL			// s				start a new variable in slot s, defined later
+	OP_GET_CELL				// s				push the value of the cell in slot s
+	OP_SET_CELL				// s				assign top to the cell in slot s
+	OP_DEF_CELL				// s				declare slot s with top in a new cell
+	OP_NEW_CELL				// s				start a new variable in slot s with an empty cell
+	OP_GET_UPVALUE			// u				push the value of cell u of the current function
+	OP_SET_UPVALUE			// u				assign top to cell u of the current function
+	OP_GET_GLOBAL			// g				push global g
+	OP_SET_GLOBAL			// g				assign top to global g
+	OP_DEF_GLOBAL			// g				declare global g with top
+	OP_ADD					//				binary operators
+	OP_MULT
+	OP_DIV
+	OP_MOD
+	OP_GREATER
+	OP_GREATER_EQ
+	OP_LESS
+	OP_LESS_EQ
+	OP_EQ
+	OP_BINARY				// t				binary operator token t
+	OP_NEG
+	OP_NOT
+	OP_JUMP					// a				jump to a
+	OP_JUMP_IF_FALSE		// a				pop condition, jump to a when false
+	OP_AND					// a				jump to a keeping the top when false, pop otherwise
+	OP_OR					// a				jump to a keeping the top when true, pop otherwise
+	OP_CLOSURE				// p				push a function of proto p capturing its upvalues
+	OP_BIND					// n				bind the n values on top to the function below them
+	OP_CALL					//				call the function on top
+	OP_BIND_CALL			// n				bind and call without creating the bound function
+	OP_RETURN				//				return top from the current function
+	OP_SPREAD				//				mark the top as unpacked in BIND, BIND_CALL and TUPLE
+	OP_ITER_BIND			//				'args .>> f'
+	OP_ITER_BIND_CALL		//				'args .>>= f'
+	OP_ITER_CALL			//				'.= fs'
+	OP_TUPLE				// n				tuple of the n values on top
+	OP_ARRAY				// t n			array of type t of the n values on top
+	OP_ARRAY_N				// t				array of type t with the size on top
+	OP_MAP					// t n			map of type t of the n key value pairs on top
+	OP_RANGE				//				range [from..to]
+	OP_INDEX				//				target[index]
+	OP_SET_INDEX			//				value -> target[index]
+	OP_FIELD				// f				target.f
+	OP_SET_FIELD			// f				value -> target.f
+	OP_CONCAT				// n				concatenate the strings of the n values on top
+	OP_ZERO					// t				push the default value of type t
+	OP_ITER					// s				pop an iterable into iterator slot s
+	OP_FOR_NEXT				// s a			push the next value of iterator s, jump to a when done
+	OP_VARIANT				// f a			pop an enum, push its payload when its variant is f or f is '_', jump to a otherwise
+	OP_NO_MATCH				//				fail on the enum on top, no arm of the match matched it
+	OP_IMPORT				// m			push the namespace of module m, running it the first time
+)
+
+var operands = [...]int{
+	OP_CONST: 1, OP_GET_LOCAL: 1, OP_SET_LOCAL: 1, OP_DEF_LOCAL: 1,
+	OP_CLEAR_LOCAL: 1, OP_GET_CELL: 1, OP_SET_CELL: 1, OP_DEF_CELL: 1, OP_NEW_CELL: 1,
+	OP_GET_UPVALUE: 1, OP_SET_UPVALUE: 1,
+	OP_GET_GLOBAL: 1, OP_SET_GLOBAL: 1, OP_DEF_GLOBAL: 1,
+	OP_BINARY: 1,
+	OP_JUMP: 1, OP_JUMP_IF_FALSE: 1, OP_AND: 1, OP_OR: 1,
+	OP_CLOSURE: 1, OP_BIND: 1, OP_BIND_CALL: 1,
+	OP_TUPLE: 1, OP_ARRAY: 2, OP_ARRAY_N: 1, OP_MAP: 2,
+	OP_FIELD: 1, OP_SET_FIELD: 1, OP_CONCAT: 1, OP_ZERO: 1,
+	OP_ITER: 1, OP_FOR_NEXT: 2, OP_VARIANT: 2, OP_IMPORT: 1,
+}
+
+var opNames = [...]string{
+	OP_CONST: "CONST", OP_NOTHING: "NOTHING", OP_POP: "POP",
+	OP_GET_LOCAL: "GET_LOCAL", OP_SET_LOCAL: "SET_LOCAL", OP_DEF_LOCAL: "DEF_LOCAL",
+	OP_CLEAR_LOCAL: "CLEAR_LOCAL", OP_GET_CELL: "GET_CELL", OP_SET_CELL: "SET_CELL", OP_DEF_CELL: "DEF_CELL",
+	OP_NEW_CELL: "NEW_CELL", OP_GET_UPVALUE: "GET_UPVALUE", OP_SET_UPVALUE: "SET_UPVALUE",
+	OP_GET_GLOBAL: "GET_GLOBAL", OP_SET_GLOBAL: "SET_GLOBAL", OP_DEF_GLOBAL: "DEF_GLOBAL",
+	OP_ADD: "ADD", OP_MULT: "MULT", OP_DIV: "DIV", OP_MOD: "MOD",
+	OP_GREATER: "GREATER", OP_GREATER_EQ: "GREATER_EQ", OP_LESS: "LESS", OP_LESS_EQ: "LESS_EQ",
+	OP_EQ: "EQ", OP_BINARY: "BINARY", OP_NEG: "NEG", OP_NOT: "NOT",
+	OP_JUMP: "JUMP", OP_JUMP_IF_FALSE: "JUMP_IF_FALSE", OP_AND: "AND", OP_OR: "OR",
+	OP_CLOSURE: "CLOSURE", OP_BIND: "BIND", OP_CALL: "CALL", OP_BIND_CALL: "BIND_CALL",
+	OP_RETURN: "RETURN", OP_SPREAD: "SPREAD",
+	OP_ITER_BIND: "ITER_BIND", OP_ITER_BIND_CALL: "ITER_BIND_CALL", OP_ITER_CALL: "ITER_CALL",
+	OP_TUPLE: "TUPLE", OP_ARRAY: "ARRAY", OP_ARRAY_N: "ARRAY_N", OP_MAP: "MAP", OP_RANGE: "RANGE",
+	OP_INDEX: "INDEX", OP_SET_INDEX: "SET_INDEX", OP_FIELD: "FIELD", OP_SET_FIELD: "SET_FIELD",
+	OP_CONCAT: "CONCAT", OP_ZERO: "ZERO", OP_ITER: "ITER", OP_FOR_NEXT: "FOR_NEXT",
+	OP_VARIANT: "VARIANT", OP_NO_MATCH: "NO_MATCH", OP_IMPORT: "IMPORT",
+}
+
+func (op Op) String() string {
+	if int(op) < len(opNames) && opNames[op] != "" {
+		return opNames[op]
+	}
+	return "UNKNOWN"
+}
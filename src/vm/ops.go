@@ -0,0 +1,221 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+)
+
+// Operator tokens of the opcodes, used for the operations on values
+// other than ints and in error messages.
+var opTokens = [...]token.TokenType{
+	OP_ADD:			token.PLUS,
+	OP_MULT:		token.MULT,
+	OP_DIV:			token.SLASH,
+	OP_MOD:			token.PERCENT,
+	OP_GREATER:		token.GREATER,
+	OP_GREATER_EQ:	token.GREATER_EQ,
+	OP_LESS:		token.LESS,
+	OP_LESS_EQ:		token.LESS_EQ,
+	OP_EQ:			token.EQ_EQ,
+	OP_NEG:			token.MINUS,
+	OP_NOT:			token.EXCLAMATION,
+	OP_AND:			token.AMP_AMP,
+	OP_OR:			token.BAR_BAR,
+}
+
+// --------------------------------------------------------
+// operators
+// --------------------------------------------------------
+
+func binary(op Op, tt token.TokenType, lval, rval interp.MSVal) (interp.MSVal, error) {
+
+	// Most arithmetic in loops is on ints, everything else
+	// is left to the evaluator's operators.
+	if l, ok := lval.(interp.MSInt) ; ok {
+		if r, ok := rval.(interp.MSInt) ; ok {
+			switch op {
+			case OP_ADD:		return interp.MSInt{Val: l.Val + r.Val}, nil
+			case OP_MULT:		return interp.MSInt{Val: l.Val * r.Val}, nil
+			case OP_GREATER:	return interp.MSBool{Val: l.Val > r.Val}, nil
+			case OP_GREATER_EQ:	return interp.MSBool{Val: l.Val >= r.Val}, nil
+			case OP_LESS:		return interp.MSBool{Val: l.Val < r.Val}, nil
+			case OP_LESS_EQ:	return interp.MSBool{Val: l.Val <= r.Val}, nil
+			case OP_EQ:			return interp.MSBool{Val: l.Val == r.Val}, nil
+			case OP_MOD:
+				if r.Val != 0 {
+					return interp.MSInt{Val: l.Val % r.Val}, nil
+				}
+			}
+		}
+	}
+
+	return interp.BinaryOp(tt, lval, rval)
+}
+
+func unary(op Op, val interp.MSVal) (interp.MSVal, error) {
+
+	switch v := val.(type) {
+	case interp.MSInt:
+		if op == OP_NEG {
+			return interp.MSInt{Val: -v.Val}, nil
+		}
+	case interp.MSBool:
+		if op == OP_NOT {
+			return interp.MSBool{Val: !v.Val}, nil
+		}
+	}
+
+	tk := token.Token{Type: opTokens[op], Lexeme: opTokens[op].String()}
+
+	return interp.UnaryOp(tk, val)
+}
+
+// --------------------------------------------------------
+// constructors
+// --------------------------------------------------------
+
+func (vm *VM) arrayOfSize(t mstype.MSType, size interp.MSVal) (interp.MSVal, error) {
+
+	n, ok := size.(interp.MSInt)
+
+	if !ok {
+		msg := fmt.Sprintf("Value '%s' is of type '%s', expected type '%s'", size, size.Type(), mstype.MS_INT)
+		return nil, &RuntimeError{msg}
+	}
+
+	if n.Val < 0 {
+		return nil, &RuntimeError{fmt.Sprintf("Cannot initialize arrays of negative size, received '%d'", n.Val)}
+	}
+
+	vals := make([]interp.MSVal, n.Val)
+	for i := range vals {
+
+		v, err := vm.zero(t, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vals[i] = v
+	}
+
+	return interp.MSArray{Values: vals, VType: t}, nil
+}
+
+func newMap(mt *mstype.MSMapTypeS, kvs []interp.MSVal) (interp.MSVal, error) {
+
+	m := interp.NewMSMap(mt.Key, mt.Value)
+
+	for i := 0 ; i < len(kvs) ; i += 2 {
+		if _, err := m.Set(kvs[i], kvs[i+1]) ; err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func newRange(from, to interp.MSVal) (interp.MSVal, error) {
+
+	f, ok := from.(interp.MSInt)
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Range constructor 'from' value must be of type 'int', got '%s'", from.Type())}
+	}
+
+	t, ok := to.(interp.MSInt)
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Range constructor 'to' value must be of type 'int', got '%s'", to.Type())}
+	}
+
+	if t.Val < f.Val {
+		msg := fmt.Sprintf("Range constructor 'to' value must be greater than or equal to 'from' value, got from='%d' to='%d'", f.Val, t.Val)
+		return nil, &RuntimeError{msg}
+	}
+
+	vals := make([]interp.MSVal, t.Val - f.Val)
+	for i := range vals {
+		vals[i] = interp.MSInt{Val: f.Val + i}
+	}
+
+	return interp.MSArray{Values: vals, VType: mstype.MS_INT}, nil
+}
+
+// --------------------------------------------------------
+// indexing and fields
+// --------------------------------------------------------
+
+func index(target, idx interp.MSVal) (interp.MSVal, error) {
+
+	indexable, ok := target.(interp.MSIndexable)
+
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Value '%s' of type '%s' is not indexable.", target, target.Type())}
+	}
+
+	return indexable.Get(idx)
+}
+
+func setIndex(target, idx, val interp.MSVal) (interp.MSVal, error) {
+
+	indexable, ok := target.(interp.MSIndexable)
+
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Value '%s' of type '%s' is not indexable.", target, target.Type())}
+	}
+
+	// New map keys have no current value
+	if _, ok := val.(interp.MSNothing) ; ok {
+
+		cur, err := indexable.Get(idx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		val = nullable(cur, val)
+	}
+
+	// The element type was checked by the type resolver
+	if arr, ok := target.(interp.MSArray) ; ok {
+
+		if err := arr.ValidIndex(idx) ; err != nil {
+			return nil, err
+		}
+
+		arr.Values[idx.(interp.MSInt).Val] = val
+
+		return val, nil
+	}
+
+	return indexable.Set(idx, val)
+}
+
+func field(target interp.MSVal, name string) (interp.MSVal, error) {
+
+	fieldable, ok := target.(interp.MSFieldable)
+
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Value '%s' of type '%s' has no fields", target, target.Type())}
+	}
+
+	return fieldable.Get(name)
+}
+
+func setField(target interp.MSVal, name string, val interp.MSVal) (interp.MSVal, error) {
+
+	fieldable, ok := target.(interp.MSFieldable)
+
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Value '%s' of type '%s' has no fields", target, target.Type())}
+	}
+
+	cur, err := fieldable.Get(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fieldable.Set(name, nullable(cur, val))
+}
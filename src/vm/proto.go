@@ -0,0 +1,129 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"mikescript/src/token"
+	"strings"
+)
+
+// Compiled function. Locals live in numbered slots of the frame of
+// a call, the parameters are the first slots.
+type Proto struct {
+	Name string
+	Params []string
+	Type *mstype.MSOperationTypeS	// resolved parameter and return types
+	Code []byte
+	Consts []interp.MSVal
+	Types []mstype.MSType			// operands of ARRAY, ARRAY_N, MAP and ZERO
+	Names []string					// field and variant names
+	Ops []token.TokenType			// operands of BINARY
+	Protos []*Proto					// functions declared in this function
+	Slots []string					// slot names, empty for hidden slots
+	Cells []bool					// slots captured by the functions declared in this function
+	Upvalues []upvalue				// cells captured from the enclosing functions
+	positions []position			// nodes the code was compiled from, used by errors
+	scopes []scopeRange				// scopes of the function, used by 'env'
+}
+
+// Variable of an enclosing function used by a function. The cell is
+// slot 'index' of the enclosing function when local, upvalue 'index'
+// of the enclosing function otherwise.
+type upvalue struct {
+	local bool
+	index int
+	name string
+}
+
+// Code from start on belongs to the node at tk, up to the next position
+type position struct {
+	start int
+	tk token.Token
+}
+
+// Code of a scope of a function, from start up to end
+type scopeRange struct {
+	start, end int
+	parent int						// enclosing scope of the same function, -1 for none
+	vars map[string]int				// slots of the variables declared in the scope
+}
+
+// Result of compiling a program, the top-level code is the main proto.
+type Program struct {
+	Main *Proto
+	Globals []string					// global names by index
+	Modules []*Module					// imported modules, operands of IMPORT
+	globals map[string]int				// globals of the program by name
+	structScopes map[*mstype.MSStructTypeS]*scope	// scope a struct is declared in
+}
+
+// Compiled module, the top-level code runs when it is first imported.
+// The globals of a module are globals of the program under their own
+// indices, so every module has its own.
+type Module struct {
+	Name string
+	Main *Proto
+	Globals map[string]int				// globals of the module by name
+	scope *scope						// types declared at the top level
+}
+
+func (p *Proto) operand(ip int) int {
+	return int(p.Code[ip]) << 8 | int(p.Code[ip+1])
+}
+
+// Token of the innermost node with a position the code at ip was
+// compiled from
+func (p *Proto) tokenAt(ip int) token.Token {
+
+	tk := token.Token{}
+	for _, pos := range p.positions {
+		if pos.start > ip {
+			break
+		}
+		tk = pos.tk
+	}
+
+	return tk
+}
+
+// Innermost scope of the code at ip, -1 for none
+func (p *Proto) scopeAt(ip int) int {
+
+	at := -1
+	for i, s := range p.scopes {
+		if s.start <= ip && ip < s.end && (at < 0 || s.start >= p.scopes[at].start) {
+			at = i
+		}
+	}
+
+	return at
+}
+
+// Lists the instructions of p and the functions declared in p.
+func Disassemble(p *Proto) string {
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "== %s (%d slots) ==\n", p.Name, len(p.Slots))
+
+	for ip := 0 ; ip < len(p.Code) ; {
+
+		op := Op(p.Code[ip])
+		args := []string{}
+
+		for i := 0 ; i < operands[op] ; i++ {
+			args = append(args, fmt.Sprintf("%d", p.operand(ip + 1 + 2*i)))
+		}
+
+		fmt.Fprintf(&sb, "%04d %-14s %s\n", ip, op, strings.Join(args, " "))
+
+		ip += 1 + 2*operands[op]
+	}
+
+	for _, fp := range p.Protos {
+		sb.WriteString(Disassemble(fp))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,247 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+)
+
+// Compile time scope, mirrors the scopes of the resolver so the
+// depth of a variable finds the scope it is declared in.
+type scope struct {
+	vars map[string]int					// slots of the variables declared in this scope
+	types map[string]mstype.MSType		// resolved types declared in this scope
+	fn *funcState						// function the slots belong to
+	parent *scope
+	index int							// in the scopes of the proto, -1 for the top level
+	namespaces map[string]*scope		// top-level scopes of the modules imported in this scope
+}
+
+func newScope(fn *funcState, parent *scope) *scope {
+	return &scope{
+		vars: make(map[string]int),
+		types: make(map[string]mstype.MSType),
+		fn: fn,
+		parent: parent,
+		index: -1,
+		namespaces: make(map[string]*scope),
+	}
+}
+
+func (s *scope) lookupType(name string) (mstype.MSType, bool) {
+	for ; s != nil ; s = s.parent {
+		if t, ok := s.types[name] ; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (s *scope) lookupNamespace(name string) (*scope, bool) {
+	for ; s != nil ; s = s.parent {
+		if ms, ok := s.namespaces[name] ; ok {
+			return ms, true
+		}
+	}
+	return nil, false
+}
+
+// Enum declaring the variant
+func (s *scope) lookupVariant(name string) (*mstype.MSEnumTypeS, bool) {
+	for ; s != nil ; s = s.parent {
+		for _, t := range s.types {
+			if et, ok := t.(*mstype.MSEnumTypeS) ; ok {
+				if _, ok := et.Variant(name) ; ok {
+					return et, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// --------------------------------------------------------
+// type resolution
+// --------------------------------------------------------
+
+// Replaces named types by the types they refer to in scope s. Struct
+// types are kept as declared, their fields are resolved when a value
+// of the struct is created.
+func resolveType(t mstype.MSType, s *scope) (mstype.MSType, error) {
+	switch tt := t.(type) {
+	case nil:						return mstype.MS_NOTHING, nil
+	case *mstype.MSSimpleTypeS:		return tt, nil
+	case *mstype.MSStructTypeS:		return tt, nil
+	case *mstype.MSArrayType:
+
+		et, err := resolveType(tt.Type, s)
+		return &mstype.MSArrayType{Type: et}, err
+
+	case *mstype.MSMapTypeS:
+
+		kv, err := resolveTypes([]mstype.MSType{tt.Key, tt.Value}, s)
+		if err != nil {
+			return nil, err
+		}
+		return &mstype.MSMapTypeS{Key: kv[0], Value: kv[1]}, nil
+
+	case *mstype.MSCompositeTypeS:
+
+		ts, err := resolveTypes(tt.Types, s)
+		return &mstype.MSCompositeTypeS{Types: ts}, err
+
+	case *mstype.MSOperationTypeS:
+		return resolveOperationType(tt, s)
+	case *mstype.MSNamedTypeS:
+		return resolveNamedType(tt, s)
+	case *mstype.MSTypeVarS:
+		return nil, unsupported("generic functions")
+	case *mstype.MSEnumTypeS:		return tt, nil
+	case *mstype.MSInterfaceTypeS:
+		return nil, unsupported("interfaces")
+	default:
+		return nil, &CompileError{fmt.Sprintf("Unknown type '%v'", t)}
+	}
+}
+
+func resolveTypes(ts []mstype.MSType, s *scope) ([]mstype.MSType, error) {
+	resolved := make([]mstype.MSType, len(ts))
+	for i, t := range ts {
+		rt, err := resolveType(t, s)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = rt
+	}
+	return resolved, nil
+}
+
+func resolveOperationType(ot *mstype.MSOperationTypeS, s *scope) (*mstype.MSOperationTypeS, error) {
+
+	left, err := resolveTypes(ot.Left, s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := resolveType(ot.Right, s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &mstype.MSOperationTypeS{Left: left, Right: right}, nil
+}
+
+func resolveNamedType(nt *mstype.MSNamedTypeS, s *scope) (mstype.MSType, error) {
+
+	// 'namespace.name', the type is resolved in the module declaring it
+	if nt.Namespace != "" {
+
+		ms, ok := s.lookupNamespace(nt.Namespace)
+
+		if !ok {
+			return nil, &CompileError{fmt.Sprintf("Could not resolve type '%s', '%s' is not a module", nt, nt.Namespace)}
+		}
+
+		return resolveNamedType(&mstype.MSNamedTypeS{Name: nt.Name, Args: nt.Args}, ms)
+	}
+
+	if len(nt.Args) > 0 {
+		return nil, unsupported("generic structs")
+	}
+
+	t, ok := s.lookupType(nt.Name)
+
+	if !ok {
+		return nil, &CompileError{fmt.Sprintf("Unknown type '%s'", nt.Name)}
+	}
+
+	return t, nil
+}
+
+// --------------------------------------------------------
+// default values
+// --------------------------------------------------------
+
+// Default value of a resolved type, like the evaluator's typeToVal.
+// Structs nested in another value are 'nothing'.
+func (vm *VM) zero(t mstype.MSType, nested bool) (interp.MSVal, error) {
+	switch tt := t.(type) {
+	case *mstype.MSSimpleTypeS:
+
+		switch tt.Rt {
+		case mstype.RT_INT:		return interp.MSInt{Val: 0}, nil
+		case mstype.RT_FLOAT:	return interp.MSFloat{Val: 0.0}, nil
+		case mstype.RT_STRING:	return interp.MSString{Val: ""}, nil
+		case mstype.RT_BOOL:	return interp.MSBool{Val: false}, nil
+		case mstype.RT_ERROR:	return interp.MSError{Kind: interp.ErrorKind}, nil
+		default:				return interp.MSNothing{}, nil
+		}
+
+	case *mstype.MSCompositeTypeS:
+
+		vals := make([]interp.MSVal, len(tt.Types))
+		for i, et := range tt.Types {
+			v, err := vm.zero(et, nested)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = v
+		}
+		return interp.MSTuple{Values: vals}, nil
+
+	case *mstype.MSOperationTypeS:	return &Closure{typ: tt}, nil
+	case *mstype.MSArrayType:		return interp.MSArray{Values: []interp.MSVal{}, VType: tt.Type}, nil
+	case *mstype.MSMapTypeS:		return interp.NewMSMap(tt.Key, tt.Value), nil
+	case *mstype.MSStructTypeS:		return vm.zeroStruct(tt, nested)
+	case *mstype.MSEnumTypeS:		return interp.MSEnum{EType: tt}, nil
+	default:						return nil, &RuntimeError{fmt.Sprintf("Type '%v' has no default value", t)}
+	}
+}
+
+func (vm *VM) zeroStruct(st *mstype.MSStructTypeS, nested bool) (interp.MSVal, error) {
+
+	if nested {
+		return interp.MSStruct{Name: st.Name, Fields: nil, SType: st}, nil
+	}
+
+	fields, err := vm.structFields(st)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interp.MSVal)
+	for name, ft := range fields {
+		v, err := vm.zero(ft, true)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+
+	return interp.MSStruct{Name: st.Name, Fields: values, SType: st}, nil
+}
+
+func (vm *VM) structFields(st *mstype.MSStructTypeS) (map[string]mstype.MSType, error) {
+	// Field types are resolved in the scope of the declaration once
+	// all of its types are known, so structs can refer to each other.
+
+	if fields, ok := vm.fields[st] ; ok {
+		return fields, nil
+	}
+
+	fields := make(map[string]mstype.MSType)
+	for name, ft := range st.Fields {
+		rt, err := resolveType(ft, vm.program.structScopes[st])
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = rt
+	}
+
+	vm.fields[st] = fields
+
+	return fields, nil
+}
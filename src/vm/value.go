@@ -0,0 +1,233 @@
+package vm
+
+import (
+	"fmt"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"strings"
+)
+
+// Locals of a call. The functions declared in the call capture the
+// cells of the locals they use, the enclosing frames are only kept
+// for 'env'.
+type frame struct {
+	slots []interp.MSVal
+	cells []*cell			// upvalues of the function
+	parent *frame			// frame of the enclosing function
+	proto *Proto
+	at int					// where the function was created in the enclosing function
+}
+
+// Cell of slot s, a captured variable used before its declaration
+// gets an empty cell.
+func (f *frame) cell(s int) *cell {
+
+	c, ok := f.slots[s].(*cell)
+
+	if !ok {
+		c = &cell{}
+		f.slots[s] = c
+	}
+
+	return c
+}
+
+///////////////////////////////////////////////////////////////
+// Function
+///////////////////////////////////////////////////////////////
+
+// Function value of the vm, the counterpart of interp.MSFunction.
+// Functions declared using 'var (...->...) f;' have no proto.
+type Closure struct {
+	proto *Proto
+	env *frame
+	at int						// code of env after creating the function
+	cells []*cell				// captured variables, see 'Proto.Upvalues'
+	args []interp.MSVal			// bound arguments
+	typ *mstype.MSOperationTypeS
+}
+
+func (c *Closure) Arity() int {
+	return len(c.typ.Left) - len(c.args)
+}
+
+func (c *Closure) Type() mstype.MSType {
+	return &mstype.MSOperationTypeS{Left: c.typ.Left[len(c.args):], Right: c.typ.Right}
+}
+
+func (c *Closure) String() string {
+
+	if c.proto == nil {
+		return "{}"
+	}
+
+	ps := []string{}
+	for i, t := range c.typ.Left {
+
+		val := "_"
+		if i < len(c.args) {
+			val = c.args[i].String()
+		}
+
+		ps = append(ps, fmt.Sprintf("(%v %s = %s)", t, c.proto.Params[i], val))
+	}
+
+	strs := []string{}
+	if len(ps) > 0 {
+		strs = append(strs, strings.Join(ps, ", "))
+	}
+	strs = append(strs, ">>", c.proto.Name, "->", fmt.Sprintf("%v", c.typ.Right), "{...}")
+
+	return strings.Join(strs, " ")
+}
+
+func (c *Closure) Nullable() bool {
+	return true
+}
+
+func (c *Closure) NullVal() interp.MSVal {
+	return &Closure{typ: c.Type().(*mstype.MSOperationTypeS)}
+}
+
+func (c *Closure) bind(args []interp.MSVal) (*Closure, error) {
+
+	if c.proto == nil {
+		return nil, &BindingError{"Cannot bind uninitialized function ''"}
+	}
+
+	if len(args) > c.Arity() {
+		msg := fmt.Sprintf("Exceeded arity of '%s' expected maximum %v arguments but received %v", c, c.Arity(), len(args))
+		return nil, &RuntimeError{msg}
+	}
+
+	bound := make([]interp.MSVal, 0, len(c.args) + len(args))
+	bound = append(bound, c.args...)
+	bound = append(bound, args...)
+
+	return &Closure{proto: c.proto, env: c.env, at: c.at, cells: c.cells, args: bound, typ: c.typ}, nil
+}
+
+// Frame of a call binding args after the bound arguments
+func (c *Closure) frame(args []interp.MSVal) *frame {
+
+	slots := make([]interp.MSVal, len(c.proto.Slots))
+	n := copy(slots, c.args)
+	copy(slots[n:], args)
+
+	// Captured parameters live in cells like other captured locals
+	for i := range c.proto.Params {
+		if c.proto.Cells[i] {
+			slots[i] = &cell{v: slots[i]}
+		}
+	}
+
+	return &frame{slots: slots, cells: c.cells, parent: c.env, proto: c.proto, at: c.at}
+}
+
+///////////////////////////////////////////////////////////////
+// internal values
+///////////////////////////////////////////////////////////////
+
+// Starred value, its elements are unpacked by the instruction
+// using it. Never visible to programs.
+type spread struct {
+	elems []interp.MSVal
+}
+
+func (s spread) Type() mstype.MSType {
+	return mstype.MS_NOTHING
+}
+
+func (s spread) String() string {
+	return "*"
+}
+
+func (s spread) Nullable() bool {
+	return false
+}
+
+func (s spread) NullVal() interp.MSVal {
+	return nil
+}
+
+// Variable captured by a function, stored in the slot of the variable
+type cell struct {
+	v interp.MSVal			// nil until the variable is declared
+}
+
+func (c *cell) Type() mstype.MSType {
+	return mstype.MS_NOTHING
+}
+
+func (c *cell) String() string {
+	return "cell"
+}
+
+func (c *cell) Nullable() bool {
+	return false
+}
+
+func (c *cell) NullVal() interp.MSVal {
+	return nil
+}
+
+// State of a for loop, stored in a hidden slot
+type iterator struct {
+	elems []interp.MSVal
+	next int
+}
+
+func (it *iterator) Type() mstype.MSType {
+	return mstype.MS_NOTHING
+}
+
+func (it *iterator) String() string {
+	return "iterator"
+}
+
+func (it *iterator) Nullable() bool {
+	return false
+}
+
+func (it *iterator) NullVal() interp.MSVal {
+	return nil
+}
+
+func hasSpread(vals []interp.MSVal) bool {
+	for _, v := range vals {
+		if _, ok := v.(spread) ; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Unpacks starred values
+func flatten(vals []interp.MSVal) []interp.MSVal {
+
+	if !hasSpread(vals) {
+		return vals
+	}
+
+	flat := []interp.MSVal{}
+	for _, v := range vals {
+		if s, ok := v.(spread) ; ok {
+			flat = append(flat, s.elems...)
+		} else {
+			flat = append(flat, v)
+		}
+	}
+
+	return flat
+}
+
+func unpack(val interp.MSVal) ([]interp.MSVal, error) {
+
+	iterable, ok := val.(interp.MSIterable)
+
+	if !ok {
+		return nil, &RuntimeError{fmt.Sprintf("Tried unpacking %s, which is not iterable", val.Type())}
+	}
+
+	return iterable.Elems()
+}
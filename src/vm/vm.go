@@ -0,0 +1,714 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"os"
+	"strings"
+)
+
+/*
+Stack based virtual machine running compiled programs. Values are
+the values of the tree-walking evaluator, so both backends share
+operators, builtins and printing.
+
+The vm trusts the type resolver: values are not type checked when
+they are bound or assigned, only where the operation itself needs it.
+*/
+
+type VM struct {
+	program *Program
+	globals []interp.MSVal
+	stack []interp.MSVal
+	frames []callFrame
+	fields map[*mstype.MSStructTypeS]map[string]mstype.MSType	// resolved struct fields
+	namespaces map[int]*namespace	// imported modules, nil while a module runs
+	host *interp.MSEvaluator		// streams of the builtins
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin io.Reader
+	Args []string					// the 'args' builtin
+	MaxDepth int					// function calls in progress, zero means no limit
+}
+
+type callFrame struct {
+	proto *Proto
+	ip int
+	env *frame
+	base int				// stack size when the call started
+}
+
+// Globals defined before the program runs
+var builtinNames = []string{"print", "len", "rand", "err", "args", "getenv", "setenv", "exit", "read_file", "read_lines", "write_file", "append_file", "list_dir", "exists", "remove", "input", "env", "has", "delete"}
+
+func NewVM(program *Program) *VM {
+
+	vm := &VM{
+		program: program,
+		globals: make([]interp.MSVal, len(program.Globals)),
+		stack: make([]interp.MSVal, 0, 256),
+		fields: make(map[*mstype.MSStructTypeS]map[string]mstype.MSType),
+		namespaces: make(map[int]*namespace),
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin: os.Stdin,
+		MaxDepth: interp.DefaultMaxDepth,
+	}
+
+	vm.globals[0] = interp.MSBuiltinPrint()
+	vm.globals[1] = interp.MSBuiltinLen()
+	vm.globals[2] = interp.MSBuiltinRand()
+	vm.globals[3] = interp.MSBuiltinErr()
+	vm.globals[5] = interp.MSBuiltinGetenv()
+	vm.globals[6] = interp.MSBuiltinSetenv()
+	vm.globals[7] = interp.MSBuiltinExit()
+	vm.globals[8] = interp.MSBuiltinReadFile()
+	vm.globals[9] = interp.MSBuiltinReadLines()
+	vm.globals[10] = interp.MSBuiltinWriteFile()
+	vm.globals[11] = interp.MSBuiltinAppendFile()
+	vm.globals[12] = interp.MSBuiltinListDir()
+	vm.globals[13] = interp.MSBuiltinExists()
+	vm.globals[14] = interp.MSBuiltinRemove()
+	vm.globals[15] = interp.MSBuiltinInput()
+	vm.globals[16] = &printEnv{vm: vm}
+	vm.globals[17] = interp.MSBuiltinHas()
+	vm.globals[18] = interp.MSBuiltinDelete()
+
+	return vm
+}
+
+func (vm *VM) Run() (interp.MSVal, error) {
+
+	main := vm.program.Main
+	env := &frame{slots: make([]interp.MSVal, len(main.Slots)), proto: main}
+
+	vm.stack = vm.stack[:0]
+	vm.frames = append(vm.frames[:0], callFrame{proto: main, env: env})
+	vm.globals[4] = interp.MSBuiltinArgs(vm.Args)
+
+	// Builtins only use the streams of the evaluator they get
+	vm.host = &interp.MSEvaluator{Stdout: vm.Stdout, Stderr: vm.Stderr, Stdin: vm.Stdin}
+
+	return vm.run(0)
+}
+
+// --------------------------------------------------------
+// stack
+// --------------------------------------------------------
+
+func (vm *VM) push(v interp.MSVal) {
+	vm.stack = append(vm.stack, v)
+}
+
+func (vm *VM) pop() interp.MSVal {
+	v := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return v
+}
+
+func (vm *VM) top() interp.MSVal {
+	return vm.stack[len(vm.stack)-1]
+}
+
+// Pops the n values on top, the result is a copy
+func (vm *VM) popN(n int) []interp.MSVal {
+	vals := make([]interp.MSVal, n)
+	copy(vals, vm.stack[len(vm.stack)-n:])
+	vm.stack = vm.stack[:len(vm.stack)-n]
+	return vals
+}
+
+// --------------------------------------------------------
+// execution
+// --------------------------------------------------------
+
+// Runs until the frame at index 'stop' returns, the frames below
+// 'stop' belong to a caller outside of this loop.
+func (vm *VM) run(stop int) (interp.MSVal, error) {
+
+	cf := &vm.frames[len(vm.frames)-1]
+	p, code, ip, env := cf.proto, cf.proto.Code, cf.ip, cf.env
+
+
+	for {
+
+		at := ip
+		op := Op(code[ip])
+		ip++
+
+		switch op {
+		case OP_CONST:		vm.push(p.Consts[read(code, &ip)])
+		case OP_NOTHING:	vm.push(interp.MSNothing{})
+		case OP_POP:		vm.stack = vm.stack[:len(vm.stack)-1]
+
+		// ------------------------------------------------
+		// variables
+		// ------------------------------------------------
+
+		case OP_GET_LOCAL:
+
+			s := read(code, &ip)
+			v := env.slots[s]
+
+			if v == nil {
+				return vm.fail(stop, at, notDefined(p.Slots[s]))
+			}
+
+			vm.push(v)
+
+		case OP_SET_LOCAL:
+
+			if err := vm.assign(env, read(code, &ip)) ; err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+		case OP_DEF_LOCAL:		env.slots[read(code, &ip)] = vm.top()
+		case OP_CLEAR_LOCAL:	env.slots[read(code, &ip)] = nil
+		case OP_GET_CELL:
+
+			s := read(code, &ip)
+			v := env.cell(s).v
+
+			if v == nil {
+				return vm.fail(stop, at, notDefined(p.Slots[s]))
+			}
+
+			vm.push(v)
+
+		case OP_SET_CELL:
+
+			s := read(code, &ip)
+
+			if err := vm.assignCell(env.cell(s), p.Slots[s]) ; err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+		case OP_DEF_CELL:
+
+			// A cell of the variable captured before its
+			// declaration is kept, otherwise this is a new one
+			s := read(code, &ip)
+
+			if c, ok := env.slots[s].(*cell) ; ok && c.v == nil {
+				c.v = vm.top()
+			} else {
+				env.slots[s] = &cell{v: vm.top()}
+			}
+
+		case OP_NEW_CELL:	env.slots[read(code, &ip)] = &cell{}
+		case OP_GET_UPVALUE:
+
+			u := read(code, &ip)
+			v := env.cells[u].v
+
+			if v == nil {
+				return vm.fail(stop, at, notDefined(p.Upvalues[u].name))
+			}
+
+			vm.push(v)
+
+		case OP_SET_UPVALUE:
+
+			u := read(code, &ip)
+
+			if err := vm.assignCell(env.cells[u], p.Upvalues[u].name) ; err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+		case OP_GET_GLOBAL:
+
+			g := read(code, &ip)
+
+			if vm.globals[g] == nil {
+				return vm.fail(stop, at, notDefined(vm.program.Globals[g]))
+			}
+
+			vm.push(vm.globals[g])
+
+		case OP_SET_GLOBAL:
+
+			g := read(code, &ip)
+			cur := vm.globals[g]
+
+			if cur == nil {
+				return vm.fail(stop, at, notDefined(vm.program.Globals[g]))
+			}
+
+			vm.stack[len(vm.stack)-1] = nullable(cur, vm.top())
+			vm.globals[g] = vm.top()
+
+		case OP_DEF_GLOBAL:	vm.globals[read(code, &ip)] = vm.top()
+
+		// ------------------------------------------------
+		// operators
+		// ------------------------------------------------
+
+		case OP_ADD, OP_MULT, OP_DIV, OP_MOD, OP_GREATER, OP_GREATER_EQ, OP_LESS, OP_LESS_EQ, OP_EQ:
+
+			r := vm.pop()
+			v, err := binary(op, opTokens[op], vm.top(), r)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.stack[len(vm.stack)-1] = v
+
+		case OP_BINARY:
+
+			t := p.Ops[read(code, &ip)]
+			r := vm.pop()
+			v, err := interp.BinaryOp(t, vm.top(), r)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.stack[len(vm.stack)-1] = v
+
+		case OP_NEG, OP_NOT:
+
+			v, err := unary(op, vm.top())
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.stack[len(vm.stack)-1] = v
+
+		// ------------------------------------------------
+		// jumps
+		// ------------------------------------------------
+
+		case OP_JUMP:	ip = read(code, &ip)
+		case OP_JUMP_IF_FALSE:
+
+			to := read(code, &ip)
+			cond := vm.pop()
+			b, ok := cond.(interp.MSBool)
+
+			if !ok {
+				return vm.fail(stop, at, &RuntimeError{fmt.Sprintf("Condition must be of type bool, got '%v'", cond.Type())})
+			}
+
+			if !b.Val {
+				ip = to
+			}
+
+		case OP_AND, OP_OR:
+
+			to := read(code, &ip)
+			b, ok := vm.top().(interp.MSBool)
+
+			if !ok {
+				msg := fmt.Sprintf("Logical operator '%v' is not defined for type '%v'", opTokens[op], vm.top())
+				return vm.fail(stop, at, &RuntimeError{msg})
+			}
+
+			// The left value decides, it is the result
+			if b.Val == (op == OP_OR) {
+				ip = to
+			} else {
+				vm.stack = vm.stack[:len(vm.stack)-1]
+			}
+
+		// ------------------------------------------------
+		// functions
+		// ------------------------------------------------
+
+		case OP_CLOSURE:
+
+			fp := p.Protos[read(code, &ip)]
+
+			cells := make([]*cell, len(fp.Upvalues))
+			for i, u := range fp.Upvalues {
+				if u.local {
+					cells[i] = env.cell(u.index)
+				} else {
+					cells[i] = env.cells[u.index]
+				}
+			}
+
+			vm.push(&Closure{proto: fp, env: env, at: ip, cells: cells, typ: fp.Type})
+
+		case OP_BIND:
+
+			args := flatten(vm.popN(read(code, &ip)))
+			v, err := bind(vm.pop(), args)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_CALL, OP_BIND_CALL:
+
+			n := 0
+			if op == OP_BIND_CALL {
+				n = read(code, &ip)
+			}
+
+			fn := vm.stack[len(vm.stack)-n-1]
+			args := vm.stack[len(vm.stack)-n:]
+
+			// Functions of the program run in this loop, the
+			// arguments are copied to the frame of the call
+			if c, ok := fn.(*Closure) ; ok && c.proto != nil && len(args) <= c.Arity() && !hasSpread(args) {
+
+				if err := vm.enterCall() ; err != nil {
+					return vm.fail(stop, at, err)
+				}
+
+				callee := c.frame(args)
+				vm.stack = vm.stack[:len(vm.stack)-n-1]
+
+				vm.frames[len(vm.frames)-1].ip = ip
+				vm.frames = append(vm.frames, callFrame{proto: c.proto, env: callee, base: len(vm.stack)})
+
+				p, code, ip, env = c.proto, c.proto.Code, 0, callee
+				continue
+			}
+
+			args = flatten(vm.popN(n))
+			vm.pop()
+
+			vm.frames[len(vm.frames)-1].ip = ip
+			v, err := vm.call(fn, args)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_RETURN:
+
+			v := vm.pop()
+
+			vm.stack = vm.stack[:vm.frames[len(vm.frames)-1].base]
+			vm.frames = vm.frames[:len(vm.frames)-1]
+
+			if len(vm.frames) == stop {
+				return v, nil
+			}
+
+			vm.push(v)
+
+			cf := &vm.frames[len(vm.frames)-1]
+			p, code, ip, env = cf.proto, cf.proto.Code, cf.ip, cf.env
+
+		case OP_SPREAD:
+
+			elems, err := unpack(vm.pop())
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(spread{elems: elems})
+
+		case OP_ITER_BIND, OP_ITER_BIND_CALL, OP_ITER_CALL:
+
+			vm.frames[len(vm.frames)-1].ip = ip
+
+			var v interp.MSVal
+			var err error
+
+			switch op {
+			case OP_ITER_BIND:		args := vm.pop() ; v, err = vm.iterBind(vm.pop(), args, false)
+			case OP_ITER_BIND_CALL:	args := vm.pop() ; v, err = vm.iterBind(vm.pop(), args, true)
+			case OP_ITER_CALL:		v, err = vm.iterCall(vm.pop())
+			}
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		// ------------------------------------------------
+		// values
+		// ------------------------------------------------
+
+		case OP_TUPLE:		vm.push(interp.MSTuple{Values: flatten(vm.popN(read(code, &ip)))})
+		case OP_ARRAY:
+
+			t := p.Types[read(code, &ip)]
+			vm.push(interp.MSArray{Values: vm.popN(read(code, &ip)), VType: t})
+
+		case OP_ARRAY_N:
+
+			v, err := vm.arrayOfSize(p.Types[read(code, &ip)], vm.pop())
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_MAP:
+
+			mt := p.Types[read(code, &ip)].(*mstype.MSMapTypeS)
+			v, err := newMap(mt, vm.popN(2 * read(code, &ip)))
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_RANGE:
+
+			to := vm.pop()
+			v, err := newRange(vm.pop(), to)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_INDEX:
+
+			idx := vm.pop()
+			v, err := index(vm.pop(), idx)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_SET_INDEX:
+
+			val := vm.pop()
+			idx := vm.pop()
+			v, err := setIndex(vm.pop(), idx, val)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_FIELD:
+
+			v, err := field(vm.pop(), p.Names[read(code, &ip)])
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_SET_FIELD:
+
+			val := vm.pop()
+			v, err := setField(vm.pop(), p.Names[read(code, &ip)], val)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		case OP_CONCAT:
+
+			var sb strings.Builder
+			for _, v := range vm.popN(read(code, &ip)) {
+				sb.WriteString(v.String())
+			}
+
+			vm.push(interp.MSString{Val: sb.String()})
+
+		case OP_ZERO:
+
+			v, err := vm.zero(p.Types[read(code, &ip)], false)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(v)
+
+		// ------------------------------------------------
+		// for loops
+		// ------------------------------------------------
+
+		case OP_ITER:
+
+			s := read(code, &ip)
+			val := vm.pop()
+			iterable, ok := val.(interp.MSIterable)
+
+			if !ok {
+				return vm.fail(stop, at, &RuntimeError{fmt.Sprintf("Value of type '%v' is not iterable", val.Type())})
+			}
+
+			elems, err := iterable.Elems()
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			env.slots[s] = &iterator{elems: elems}
+
+		case OP_FOR_NEXT:
+
+			it := env.slots[read(code, &ip)].(*iterator)
+			done := read(code, &ip)
+
+			if it.next >= len(it.elems) {
+				ip = done
+				continue
+			}
+
+			vm.push(it.elems[it.next])
+			it.next++
+
+		case OP_VARIANT:
+
+			variant := p.Names[read(code, &ip)]
+			next := read(code, &ip)
+
+			e, ok := vm.pop().(interp.MSEnum)
+
+			switch {
+			case !ok:
+				return vm.fail(stop, at, &RuntimeError{"Can only match on enums"})
+			case e.IsNil():
+				return vm.fail(stop, at, &RuntimeError{fmt.Sprintf("Cannot match on 'nothing' of type '%s'", e.EType.Name)})
+			case variant == "_":
+			case variant == e.Variant:
+				for _, v := range e.Values {
+					vm.push(v)
+				}
+			default:
+				ip = next
+			}
+
+		case OP_NO_MATCH:
+
+			e := vm.pop().(interp.MSEnum)
+			return vm.fail(stop, at, &RuntimeError{fmt.Sprintf("No match arm for variant '%s'", e.Variant)})
+
+		case OP_IMPORT:
+
+			m := read(code, &ip)
+
+			vm.frames[len(vm.frames)-1].ip = ip
+			ns, err := vm.importModule(m)
+
+			if err != nil {
+				return vm.fail(stop, at, err)
+			}
+
+			vm.push(ns)
+
+		default:
+			return vm.fail(stop, at, &RuntimeError{fmt.Sprintf("Unknown instruction '%s' in '%s'", op, p.Name)})
+		}
+	}
+}
+
+// Assigns the top to slot s, 'nothing' becomes the null value of
+// the current value like in the evaluator.
+func (vm *VM) assign(f *frame, s int) error {
+
+	cur := f.slots[s]
+
+	if cur == nil {
+		return notDefined(f.proto.Slots[s])
+	}
+
+	v := nullable(cur, vm.top())
+	vm.stack[len(vm.stack)-1] = v
+	f.slots[s] = v
+
+	return nil
+}
+
+// Like assign, for the cell of a captured variable
+func (vm *VM) assignCell(c *cell, name string) error {
+
+	if c.v == nil {
+		return notDefined(name)
+	}
+
+	v := nullable(c.v, vm.top())
+	vm.stack[len(vm.stack)-1] = v
+	c.v = v
+
+	return nil
+}
+
+func nullable(cur, v interp.MSVal) interp.MSVal {
+	if _, ok := v.(interp.MSNothing) ; ok && cur.Nullable() {
+		return cur.NullVal()
+	}
+	return v
+}
+
+func notDefined(name string) error {
+	return &RuntimeError{fmt.Sprintf("Variable '%s' is not defined", name)}
+}
+
+// Reads the operand at ip and moves ip past it
+func read(code []byte, ip *int) int {
+	i := *ip
+	*ip = i + 2
+	return int(code[i]) << 8 | int(code[i+1])
+}
+
+// Unwinds the frames of this loop
+// Ends the frames of this loop, the instruction at 'at' failed
+func (vm *VM) fail(stop int, at int, err error) (interp.MSVal, error) {
+
+	vm.frames[len(vm.frames)-1].ip = at + 1
+	err = vm.locate(err)
+
+	vm.frames = vm.frames[:stop]
+	return nil, err
+}
+
+// Locates err like the evaluator, at the failing instruction and the
+// calls of the frames. Errors of a nested loop are located already.
+func (vm *VM) locate(err error) error {
+
+	if _, ok := err.(*interp.RuntimeError) ; ok {
+		return err
+	}
+
+	// Innermost call first, the saved ip is past the instruction
+	trace := make([]interp.TraceFrame, len(vm.frames))
+	for i, f := range vm.frames {
+
+		name := f.proto.Name
+		if vm.topLevel(f.proto) {
+			name = ""
+		}
+
+		trace[len(vm.frames) - 1 - i] = interp.TraceFrame{Name: name, Tk: f.proto.tokenAt(f.ip - 1)}
+	}
+
+	return &interp.RuntimeError{Err: err, Tk: trace[0].Tk, Trace: trace}
+}
+
+// Code of the program or of a module
+func (vm *VM) topLevel(p *Proto) bool {
+
+	if p == vm.program.Main {
+		return true
+	}
+
+	for _, m := range vm.program.Modules {
+		if p == m.Main {
+			return true
+		}
+	}
+
+	return false
+}
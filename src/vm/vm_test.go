@@ -0,0 +1,203 @@
+package vm
+
+import (
+	"bytes"
+	"mikescript/src/ast"
+	"mikescript/src/interp"
+	"mikescript/src/mstype"
+	"mikescript/src/parser"
+	"mikescript/src/resolver"
+	"mikescript/src/scanner"
+	"strings"
+	"testing"
+)
+
+// Compiles src, the vm runs it with its output in the buffer
+func compile(t *testing.T, src string) (*VM, *bytes.Buffer) {
+
+	s := scanner.MSScanner{}
+	tokens := s.Scan(src)
+
+	p := parser.MSParser{}
+	p.SetSrc(src)
+	p.SetTokens(tokens)
+
+	program, _ := p.Parse(tokens)
+
+	if len(s.Errors) > 0 || len(p.Errors) > 0 {
+		t.Fatalf("Could not parse '%s': %v %v", src, s.Errors, p.Errors)
+	}
+
+	r := resolver.NewMSResolver(program)
+	r.Reset()
+	vlocals, _ := r.Resolve()
+
+	tr := resolver.NewMSTypeResolver(program)
+
+	if errs := tr.Resolve() ; len(errs) > 0 {
+		t.Fatalf("Type errors in '%s': %v", src, errs)
+	}
+
+	compiled, err := NewMSCompiler(vlocals).Compile(program)
+
+	if err != nil {
+		t.Fatalf("Could not compile '%s': %v", src, err)
+	}
+
+	var out bytes.Buffer
+
+	vm := NewVM(compiled)
+	vm.Stdout = &out
+
+	return vm, &out
+}
+
+func TestMaxDepth(t *testing.T) {
+
+	tests := []struct {
+		input string
+		maxDepth int
+		err string
+	}{
+		{
+			input: "function (int n) >> f -> int { return n + 1 >>= f; } 0 >>= f;",
+			maxDepth: interp.DefaultMaxDepth,
+			err: "Exceeded the maximum call depth of 10000",
+		},
+		{
+			input: "function (int n) >> f -> int { return n + 1 >>= f; } 0 >>= f;",
+			maxDepth: 100,
+			err: "Exceeded the maximum call depth of 100",
+		},
+		{
+			input: "function (int n) >> f -> int { if n == 0 { return 0; } return n - 1 >>= f; } 100 >>= f >>= print;",
+			maxDepth: 101,
+			err: "",
+		},
+		{
+			// Calls through 'call' nest the loop running the program
+			input: "function (int n) >> f -> int { return .= (n + 1 >> f); } 0 >>= f;",
+			maxDepth: 100,
+			err: "Exceeded the maximum call depth of 100",
+		},
+	}
+
+	for _, test := range tests {
+
+		vm, _ := compile(t, test.input)
+		vm.MaxDepth = test.maxDepth
+
+		_, err := vm.Run()
+
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("Unexpected error running '%s': %v", test.input, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("Expected error '%s' running '%s', received '%v'", test.err, test.input, err)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+
+	src := `1 => a;
+function (int x) >> f {
+    {
+        2 => y;
+        =env;
+    }
+    3 => z;
+}
+5 >>= f;
+`
+
+	vm, out := compile(t, src)
+
+	if _, err := vm.Run() ; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// One table per scope, 'z' is not declared yet
+	expected := []string{
+		"| int                  | a                    | 1",
+		"----------01----------+",
+		"| int                  | x                    | 5",
+		"----------02----------+",
+		"| int                  | y                    | 2",
+		"----------03----------+",
+	}
+
+	received := out.String()
+
+	for _, e := range expected {
+		if !strings.Contains(received, e) {
+			t.Errorf("Expected 'env' output containing '%s', received:\n%s", e, received)
+		}
+	}
+
+	if strings.Contains(received, "| z ") {
+		t.Errorf("Expected no 'z' in the 'env' output, received:\n%s", received)
+	}
+}
+
+func TestMatch(t *testing.T) {
+
+	tests := []struct {
+		input string
+		output string
+		err string
+	}{
+		{
+			input: `type enum shape { circle(float), rect(float, float), dot, }
+function (int k) >> f -> string {
+    1.0, 2.0 >>= rect => s;
+    return s >>= match -> string {
+        circle(r) => "c";
+        rect(_, h) => "r {h} {k}";
+        _ => "other";
+    };
+}
+3 >>= f >>= print;
+dot >>= match -> int { circle(r) => 1; _ => 2; } >>= print;`,
+			output: "r 2 3\n2\n",
+			err: "",
+		},
+		{
+			input: "type enum light { red, green, } var light l; l >>= match -> int { red => 1; green => 2; } >>= print;",
+			output: "",
+			err: "Cannot match on 'nothing' of type 'light'",
+		},
+	}
+
+	for _, test := range tests {
+
+		vm, out := compile(t, test.input)
+
+		_, err := vm.Run()
+
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("Unexpected error running '%s': %v", test.input, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("Expected error '%s' running '%s', received '%v'", test.err, test.input, err)
+		case out.String() != test.output:
+			t.Errorf("Expected output %q running '%s', received %q", test.output, test.input, out.String())
+		}
+	}
+}
+
+func TestStructConstructor(t *testing.T) {
+
+	// Not produced by the parser, the node is built by hand
+	program := &ast.Program{Statements: []ast.StmtNodeI{
+		&ast.ExStmtNodeS{Ex: &ast.StructConstructorNodeS{Name: &mstype.MSNamedTypeS{Name: "point"}}},
+	}}
+
+	_, err := NewMSCompiler(nil).Compile(program)
+
+	expected := "Struct constructor of 'point' is not supported"
+
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error '%s', received '%v'", expected, err)
+	}
+}